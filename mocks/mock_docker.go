@@ -84,6 +84,31 @@ func (mr *MockClientMockRecorder) CreateExec(arg0 interface{}) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateExec", reflect.TypeOf((*MockClient)(nil).CreateExec), arg0)
 }
 
+// CreateVolume mocks base method
+func (m *MockClient) CreateVolume(arg0 go_dockerclient.CreateVolumeOptions) (*go_dockerclient.Volume, error) {
+	ret := m.ctrl.Call(m, "CreateVolume", arg0)
+	ret0, _ := ret[0].(*go_dockerclient.Volume)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateVolume indicates an expected call of CreateVolume
+func (mr *MockClientMockRecorder) CreateVolume(arg0 interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateVolume", reflect.TypeOf((*MockClient)(nil).CreateVolume), arg0)
+}
+
+// DownloadFromContainer mocks base method
+func (m *MockClient) DownloadFromContainer(arg0 string, arg1 go_dockerclient.DownloadFromContainerOptions) error {
+	ret := m.ctrl.Call(m, "DownloadFromContainer", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DownloadFromContainer indicates an expected call of DownloadFromContainer
+func (mr *MockClientMockRecorder) DownloadFromContainer(arg0, arg1 interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DownloadFromContainer", reflect.TypeOf((*MockClient)(nil).DownloadFromContainer), arg0, arg1)
+}
+
 // Info mocks base method
 func (m *MockClient) Info() (*go_dockerclient.DockerInfo, error) {
 	ret := m.ctrl.Call(m, "Info")
@@ -123,6 +148,44 @@ func (mr *MockClientMockRecorder) InspectExec(arg0 interface{}) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "InspectExec", reflect.TypeOf((*MockClient)(nil).InspectExec), arg0)
 }
 
+// InspectImage mocks base method
+func (m *MockClient) InspectImage(arg0 string) (*go_dockerclient.Image, error) {
+	ret := m.ctrl.Call(m, "InspectImage", arg0)
+	ret0, _ := ret[0].(*go_dockerclient.Image)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// InspectImage indicates an expected call of InspectImage
+func (mr *MockClientMockRecorder) InspectImage(arg0 interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "InspectImage", reflect.TypeOf((*MockClient)(nil).InspectImage), arg0)
+}
+
+// InspectVolume mocks base method
+func (m *MockClient) InspectVolume(arg0 string) (*go_dockerclient.Volume, error) {
+	ret := m.ctrl.Call(m, "InspectVolume", arg0)
+	ret0, _ := ret[0].(*go_dockerclient.Volume)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// InspectVolume indicates an expected call of InspectVolume
+func (mr *MockClientMockRecorder) InspectVolume(arg0 interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "InspectVolume", reflect.TypeOf((*MockClient)(nil).InspectVolume), arg0)
+}
+
+// KillContainer mocks base method
+func (m *MockClient) KillContainer(arg0 go_dockerclient.KillContainerOptions) error {
+	ret := m.ctrl.Call(m, "KillContainer", arg0)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// KillContainer indicates an expected call of KillContainer
+func (mr *MockClientMockRecorder) KillContainer(arg0 interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "KillContainer", reflect.TypeOf((*MockClient)(nil).KillContainer), arg0)
+}
+
 // ListContainers mocks base method
 func (m *MockClient) ListContainers(arg0 go_dockerclient.ListContainersOptions) ([]go_dockerclient.APIContainers, error) {
 	ret := m.ctrl.Call(m, "ListContainers", arg0)
@@ -149,6 +212,19 @@ func (mr *MockClientMockRecorder) ListImages(arg0 interface{}) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListImages", reflect.TypeOf((*MockClient)(nil).ListImages), arg0)
 }
 
+// ListVolumes mocks base method
+func (m *MockClient) ListVolumes(arg0 go_dockerclient.ListVolumesOptions) ([]go_dockerclient.Volume, error) {
+	ret := m.ctrl.Call(m, "ListVolumes", arg0)
+	ret0, _ := ret[0].([]go_dockerclient.Volume)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListVolumes indicates an expected call of ListVolumes
+func (mr *MockClientMockRecorder) ListVolumes(arg0 interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListVolumes", reflect.TypeOf((*MockClient)(nil).ListVolumes), arg0)
+}
+
 // Logs mocks base method
 func (m *MockClient) Logs(arg0 go_dockerclient.LogsOptions) error {
 	ret := m.ctrl.Call(m, "Logs", arg0)
@@ -185,6 +261,54 @@ func (mr *MockClientMockRecorder) RemoveContainer(arg0 interface{}) *gomock.Call
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RemoveContainer", reflect.TypeOf((*MockClient)(nil).RemoveContainer), arg0)
 }
 
+// RemoveImage mocks base method
+func (m *MockClient) RemoveImage(arg0 string) error {
+	ret := m.ctrl.Call(m, "RemoveImage", arg0)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RemoveImage indicates an expected call of RemoveImage
+func (mr *MockClientMockRecorder) RemoveImage(arg0 interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RemoveImage", reflect.TypeOf((*MockClient)(nil).RemoveImage), arg0)
+}
+
+// RemoveVolume mocks base method
+func (m *MockClient) RemoveVolume(arg0 string) error {
+	ret := m.ctrl.Call(m, "RemoveVolume", arg0)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RemoveVolume indicates an expected call of RemoveVolume
+func (mr *MockClientMockRecorder) RemoveVolume(arg0 interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RemoveVolume", reflect.TypeOf((*MockClient)(nil).RemoveVolume), arg0)
+}
+
+// ResizeContainerTTY mocks base method
+func (m *MockClient) ResizeContainerTTY(arg0 string, arg1, arg2 int) error {
+	ret := m.ctrl.Call(m, "ResizeContainerTTY", arg0, arg1, arg2)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ResizeContainerTTY indicates an expected call of ResizeContainerTTY
+func (mr *MockClientMockRecorder) ResizeContainerTTY(arg0, arg1, arg2 interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ResizeContainerTTY", reflect.TypeOf((*MockClient)(nil).ResizeContainerTTY), arg0, arg1, arg2)
+}
+
+// ResizeExecTTY mocks base method
+func (m *MockClient) ResizeExecTTY(arg0 string, arg1, arg2 int) error {
+	ret := m.ctrl.Call(m, "ResizeExecTTY", arg0, arg1, arg2)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ResizeExecTTY indicates an expected call of ResizeExecTTY
+func (mr *MockClientMockRecorder) ResizeExecTTY(arg0, arg1, arg2 interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ResizeExecTTY", reflect.TypeOf((*MockClient)(nil).ResizeExecTTY), arg0, arg1, arg2)
+}
+
 // StartContainer mocks base method
 func (m *MockClient) StartContainer(arg0 string, arg1 *go_dockerclient.HostConfig) error {
 	ret := m.ctrl.Call(m, "StartContainer", arg0, arg1)
@@ -222,6 +346,18 @@ func (mr *MockClientMockRecorder) StartExecNonBlocking(arg0, arg1 interface{}) *
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "StartExecNonBlocking", reflect.TypeOf((*MockClient)(nil).StartExecNonBlocking), arg0, arg1)
 }
 
+// StopContainer mocks base method
+func (m *MockClient) StopContainer(arg0 string, arg1 uint) error {
+	ret := m.ctrl.Call(m, "StopContainer", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// StopContainer indicates an expected call of StopContainer
+func (mr *MockClientMockRecorder) StopContainer(arg0, arg1 interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "StopContainer", reflect.TypeOf((*MockClient)(nil).StopContainer), arg0, arg1)
+}
+
 // Version mocks base method
 func (m *MockClient) Version() (*go_dockerclient.Env, error) {
 	ret := m.ctrl.Call(m, "Version")
@@ -234,3 +370,16 @@ func (m *MockClient) Version() (*go_dockerclient.Env, error) {
 func (mr *MockClientMockRecorder) Version() *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Version", reflect.TypeOf((*MockClient)(nil).Version))
 }
+
+// WaitContainer mocks base method
+func (m *MockClient) WaitContainer(arg0 string) (int, error) {
+	ret := m.ctrl.Call(m, "WaitContainer", arg0)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// WaitContainer indicates an expected call of WaitContainer
+func (mr *MockClientMockRecorder) WaitContainer(arg0 interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "WaitContainer", reflect.TypeOf((*MockClient)(nil).WaitContainer), arg0)
+}
@@ -22,6 +22,7 @@ import (
 	"github.com/sirupsen/logrus"
 
 	"github.com/adobe/sledgehammer/slh/config"
+	"github.com/adobe/sledgehammer/slh/tool"
 	bolt "github.com/coreos/bbolt"
 )
 
@@ -42,10 +43,13 @@ var (
 
 // Alias is a struct that will be used when the user installs a tool. That will create an alias that can be used as a shortcut.
 type Alias struct {
-	Name     string `json:"name"`
-	Registry string `json:"registry"`
-	Tool     string `json:"tool"`
-	Version  string `json:"version"`
+	Name     string       `json:"name"`
+	Registry string       `json:"registry"`
+	Tool     string       `json:"tool"`
+	Version  string       `json:"version"`
+	Env      []string     `json:"env,omitempty"`
+	Network  string       `json:"network,omitempty"`
+	Limits   *tool.Limits `json:"limits,omitempty"`
 }
 
 // Aliases is the main access point for adding/removing/editing aliases
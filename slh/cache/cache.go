@@ -42,37 +42,39 @@ type cacheItem struct {
 // New will return a new cache instance
 func New(db config.Database) *Cache {
 	return &Cache{
-		Versions:  newVersionCache(db.DB),
-		Container: newContainerCache(db.DB),
-		Registry:  newRegistryCache(db.DB),
+		Versions:  newVersionCache(db),
+		Container: newContainerCache(db),
+		Registry:  newRegistryCache(db),
 	}
 }
 
 // Add is the function to add any data to the chache
-func add(db *bolt.DB, bucket string, name string, item json.RawMessage, ttl time.Duration) error {
+func add(db config.Database, bucket string, name string, item json.RawMessage, ttl time.Duration) error {
 	if name == "" {
 		return ErrorNameRequired
 	}
+	b, err := json.Marshal(cacheItem{
+		ValidUntil: time.Now().Add(ttl).Unix(),
+		Item:       item,
+	})
+	if err != nil {
+		return err
+	}
+	logrus.WithFields(logrus.Fields{
+		"tool":  name,
+		"until": time.Now().Add(ttl).Unix(),
+		"item":  string(item)}).Infoln("Cached remote versions")
 	return db.Update(func(tx *bolt.Tx) error {
 		bucket, err := tx.CreateBucketIfNotExists([]byte(bucket))
 		if err != nil {
 			return err
 		}
-		b, err := json.Marshal(cacheItem{
-			ValidUntil: time.Now().Add(ttl).Unix(),
-			Item:       item,
-		})
-		logrus.WithFields(logrus.Fields{
-			"tool":  name,
-			"until": time.Now().Add(ttl).Unix(),
-			"item":  string(item)}).Infoln("Cached remote versions")
-
 		return bucket.Put([]byte(name), b)
 	})
 }
 
 // Clear will clear the given cache entry in the given bucket
-func clear(db *bolt.DB, bucketName string, entry string) error {
+func clear(db config.Database, bucketName string, entry string) error {
 	return db.Update(func(tx *bolt.Tx) error {
 		bucket, err := tx.CreateBucketIfNotExists([]byte(bucketName))
 		if err != nil {
@@ -82,20 +84,43 @@ func clear(db *bolt.DB, bucketName string, entry string) error {
 	})
 }
 
+// lookup will return the given cache entry even if it has expired, nothing will be fetched.
+// If the entry has never been cached, nil is returned.
+func lookup(db config.Database, bucketName string, name string) (json.RawMessage, error) {
+	var item json.RawMessage
+	err := db.DB.View(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket([]byte(bucketName))
+		if bucket == nil {
+			return nil
+		}
+		jsonCache := bucket.Get([]byte(name))
+		if jsonCache == nil {
+			return nil
+		}
+		cachedItem := cacheItem{}
+		if err := json.Unmarshal(jsonCache, &cachedItem); err != nil {
+			return err
+		}
+		item = cachedItem.Item
+		return nil
+	})
+	return item, err
+}
+
 // Resolve will return the string of the given name from the cache if possible.
 // If the strings are not available, the callback will be called and added to the cache
 // The fallback takes no arguments and requires the strings and a duration as return values.
-func resolve(db *bolt.DB, bucket string, name string, fallback func(oldValue json.RawMessage) (json.RawMessage, time.Duration, error)) (json.RawMessage, error) {
+func resolve(db config.Database, bucket string, name string, fallback func(oldValue json.RawMessage) (json.RawMessage, time.Duration, error)) (json.RawMessage, error) {
 	cachedItem := &cacheItem{}
 	// logrus.WithField("tool", to.Data().Name).Infoln("Checking db for remote versions")
-	err := db.Update(func(tx *bolt.Tx) error {
-		bucket, err := tx.CreateBucketIfNotExists([]byte(bucket))
-		if err != nil {
-			return err
+	err := db.DB.View(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket([]byte(bucket))
+		if bucket == nil {
+			return nil
 		}
 		jsonCache := bucket.Get([]byte(name))
 		if jsonCache != nil {
-			err = json.Unmarshal(jsonCache, &cachedItem)
+			err := json.Unmarshal(jsonCache, &cachedItem)
 			if err != nil {
 				return err
 			}
@@ -12,11 +12,21 @@ written permission of Adobe.
 package cache
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
 	"time"
 
 	"github.com/adobe/sledgehammer/slh/config"
+	"github.com/adobe/sledgehammer/slh/settings"
 	"github.com/adobe/sledgehammer/slh/tool"
+	"github.com/adobe/sledgehammer/utils"
 	"github.com/coreos/bbolt"
 	docker "github.com/fsouza/go-dockerclient"
 	"github.com/sirupsen/logrus"
@@ -25,18 +35,52 @@ import (
 var (
 	// DaemonContainerBucket is the name of the bucket where the ids of daemon containers are cached
 	DaemonContainerBucket = "DaemonContainerCache"
-	// ContainerIDTTL represents the time after that a daemon container will be renewed
+	// ContainerIDTTL is the default time after that a daemon container nobody executed a command in will be stopped
 	ContainerIDTTL = 10 * time.Minute
+	// ErrorNoDaemon will be thrown if there is no daemon running for a tool
+	ErrorNoDaemon = errors.New("No daemon running for the tool")
+	// CollectInterval is the minimum time between two garbage collections of containers in the background
+	CollectInterval = time.Hour
+	// GarbageCollectionBucket is the name of the bucket where the time of the last garbage collection is cached
+	GarbageCollectionBucket = "GarbageCollection"
+	// LockDir is the directory next to the database with the lock files that guard the start of daemons
+	LockDir              = "locks"
+	containerEntryPrefix = "container/"
+	aliasSeparator       = "#"
 )
 
 // Container represents the container cache. If the tool is daemonized then it can be that the daemon is already running.
 // Normally it is then cached in the database.
 // This provides a fast way to detect running daemons to a certain degree
 type Container struct {
-	db *bolt.DB
+	db config.Database
 }
 
-func newContainerCache(db *bolt.DB) Container {
+// Daemon is a cached daemon container of a tool
+type Daemon struct {
+	Entry       string
+	ID          string
+	Registry    string
+	Tool        string
+	Alias       string
+	Image       string
+	Version     string
+	Fingerprint string
+	IdleUntil   time.Time
+}
+
+// containerItem is the cached container of a tool together with the fingerprint of the configuration it has been created with
+type containerItem struct {
+	ID          string `json:"id"`
+	Fingerprint string `json:"fingerprint"`
+}
+
+// Idle will return true if nobody executed a command in the daemon within the idle timeout
+func (d Daemon) Idle() bool {
+	return d.IdleUntil.Before(time.Now())
+}
+
+func newContainerCache(db config.Database) Container {
 	return Container{db: db}
 }
 
@@ -48,34 +92,38 @@ func (c *Container) ClearAll() error {
 
 // Clear will clear the entry of the given tool.
 // This is useful if you want to clear things
-func (c *Container) Clear(to tool.Tool, tag string) error {
-	return clear(c.db, DaemonContainerBucket, getContainerCacheEntry(to, tag))
+func (c *Container) Clear(to tool.Tool, tag string, alias string) error {
+	return clear(c.db, DaemonContainerBucket, getContainerCacheEntry(to, tag, alias))
 }
 
-// CurrentDaemons will return all currently cached daemon tools.
+// CurrentDaemons will return all currently cached daemon tools, sorted by their cache entry.
 // Useful to kill all currently running daemons.
-func (c *Container) CurrentDaemons() (map[string]string, error) {
+func (c *Container) CurrentDaemons() ([]Daemon, error) {
 	logrus.Debugln("Getting all currently running daemons")
-	containerIDs := map[string]string{}
+	daemons := []Daemon{}
 
-	err := c.db.Update(func(tx *bolt.Tx) error {
-		bucket, err := tx.CreateBucketIfNotExists([]byte(DaemonContainerBucket))
-		if err != nil {
-			return err
-		}
+	err := c.db.DB.View(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket([]byte(DaemonContainerBucket))
 		if bucket != nil {
-			err = bucket.ForEach(func(key []byte, value []byte) error {
+			err := bucket.ForEach(func(key []byte, value []byte) error {
 				var item cacheItem
 				err := json.Unmarshal(value, &item)
 				if err != nil {
 					return err
 				}
-				id := ""
-				err = json.Unmarshal(item.Item, &id)
+				container, err := decodeContainerItem(item.Item)
 				if err != nil {
 					return err
 				}
-				containerIDs[string(key)] = id
+				// tools that are no daemons are cached with an empty id
+				if len(container.ID) == 0 {
+					return nil
+				}
+				daemon := parseContainerCacheEntry(string(key))
+				daemon.ID = container.ID
+				daemon.Fingerprint = container.Fingerprint
+				daemon.IdleUntil = time.Unix(item.ValidUntil, 0)
+				daemons = append(daemons, daemon)
 				return nil
 			})
 			if err != nil {
@@ -85,57 +133,263 @@ func (c *Container) CurrentDaemons() (map[string]string, error) {
 		return nil
 	})
 	if err != nil {
-		return containerIDs, err
-	}
-	return containerIDs, nil
-}
-
-// Get will return the id of the running container if possible
-func (c *Container) Get(to tool.Tool, tag string, cfg *config.Config, mos []string) (string, error) {
-	logrus.WithField("entry", getContainerCacheEntry(to, tag)).Debugln("Check container")
-	id, err := resolve(
-		c.db,
-		DaemonContainerBucket,
-		getContainerCacheEntry(to, tag),
-		func(oldValue json.RawMessage) (json.RawMessage, time.Duration, error) {
-			if oldValue != nil {
-				// shutdown container if possible
-				var id string
-				err := json.Unmarshal(oldValue, &id)
-				if err == nil {
-					cfg.Docker.Docker.RemoveContainer(docker.RemoveContainerOptions{
-						Force: true,
-						ID:    id,
-					})
-				}
-			}
-			id, err := tool.StartIfDaemon(&tool.ExecutionOptions{
-				Tool:    to,
-				Mounts:  mos,
-				IO:      cfg.IO,
-				Docker:  &cfg.Docker,
-				Version: tag,
-			})
-			if err != nil {
-				return json.RawMessage{}, ContainerIDTTL, err
-			}
-			b, err := json.Marshal(id)
-			return b, ContainerIDTTL, err
-		},
-	)
+		return daemons, err
+	}
+	sort.Slice(daemons, func(i, j int) bool {
+		return daemons[i].Entry < daemons[j].Entry
+	})
+	return daemons, nil
+}
+
+// DaemonIDs will return the container ids of all cached daemons
+func (c *Container) DaemonIDs() (map[string]bool, error) {
+	ids := map[string]bool{}
+	daemons, err := c.CurrentDaemons()
+	for _, d := range daemons {
+		ids[d.ID] = true
+	}
+	return ids, err
+}
+
+// CollectDue will return true if the last garbage collection is older than the collect interval.
+// The garbage collection is marked as done, so only one caller will get true within the interval.
+func (c *Container) CollectDue() (bool, error) {
+	due := false
+	_, err := resolve(c.db, GarbageCollectionBucket, "last", func(json.RawMessage) (json.RawMessage, time.Duration, error) {
+		due = true
+		b, err := json.Marshal(time.Now().Unix())
+		return b, CollectInterval, err
+	})
+	return due, err
+}
+
+// Find will return all daemons of the given tool. If the registry is empty, daemons from all registries are returned.
+func (c *Container) Find(registry string, name string) ([]Daemon, error) {
+	found := []Daemon{}
+	daemons, err := c.CurrentDaemons()
+	if err != nil {
+		return found, err
+	}
+	for _, d := range daemons {
+		if d.Tool == name && (len(registry) == 0 || d.Registry == registry) {
+			found = append(found, d)
+		}
+	}
+	if len(found) == 0 {
+		return found, ErrorNoDaemon
+	}
+	return found, nil
+}
+
+// Stop will remove the container of the given daemon and clear its cache entry
+func (c *Container) Stop(client config.Docker, d Daemon) error {
+	logrus.WithField("id", d.ID).WithField("tool", d.Tool).Debugln("Stopping daemon")
+	err := client.Docker.RemoveContainer(docker.RemoveContainerOptions{
+		Force: true,
+		ID:    d.ID,
+	})
+	if _, ok := err.(*docker.NoSuchContainer); err != nil && !ok {
+		return err
+	}
+	return clear(c.db, DaemonContainerBucket, d.Entry)
+}
+
+// Reap will stop all daemons that have not been used within the idle timeout and return them.
+// Daemons that still execute a command, e.g. a build that runs longer than the idle timeout, are kept.
+// The daemon of the given execution options is kept as well, it has just been resolved by Get but is only written when the database is closed.
+func (c *Container) Reap(client config.Docker, current *tool.ExecutionOptions) ([]Daemon, error) {
+	reaped := []Daemon{}
+	daemons, err := c.CurrentDaemons()
 	if err != nil {
+		return reaped, err
+	}
+	keep := ""
+	if current != nil {
+		keep = getContainerCacheEntry(current.Tool, current.Version, current.Alias)
+	}
+	for _, d := range daemons {
+		if d.Entry == keep || !d.Idle() || busy(client, d.ID) {
+			continue
+		}
+		logrus.WithField("id", d.ID).WithField("tool", d.Tool).Infoln("Reaping idle daemon")
+		if err := c.Stop(client, d); err != nil {
+			return reaped, err
+		}
+		reaped = append(reaped, d)
+	}
+	return reaped, nil
+}
+
+// busy will return true if a command is still running in the given container
+func busy(client config.Docker, id string) bool {
+	container, err := client.Docker.InspectContainer(id)
+	if err != nil {
+		return false
+	}
+	for _, execID := range container.ExecIDs {
+		exec, err := client.Docker.InspectExec(execID)
+		if err == nil && exec.Running {
+			logrus.WithField("id", id).WithField("exec", execID).Debugln("Daemon is still executing a command")
+			return true
+		}
+	}
+	return false
+}
+
+// IdleTimeout will return the time after that an unused daemon will be stopped
+func (c *Container) IdleTimeout() time.Duration {
+	value, found, err := settings.New(c.db).Get(settings.DaemonIdle)
+	if err != nil || !found {
+		return ContainerIDTTL
+	}
+	timeout, err := time.ParseDuration(value)
+	if err != nil {
+		logrus.WithField("value", value).Warnln("Invalid daemon idle timeout, using the default")
+		return ContainerIDTTL
+	}
+	return timeout
+}
+
+// Get will return the id of the running container if possible.
+// Every call resets the idle timeout of the daemon.
+// If the daemon has been created with a different configuration, e.g. mounts have been added since, it will be recreated.
+func (c *Container) Get(opt *tool.ExecutionOptions) (string, error) {
+	entry := getContainerCacheEntry(opt.Tool, opt.Version, opt.Alias)
+	fingerprint := tool.Fingerprint(opt)
+	idle := c.IdleTimeout()
+	logrus.WithField("entry", entry).Debugln("Check container")
+	fallback := func(oldValue json.RawMessage) (json.RawMessage, time.Duration, error) {
+		raw, err := c.start(opt, entry, fingerprint, oldValue)
+		return raw, idle, err
+	}
+
+	raw, err := resolve(c.db, DaemonContainerBucket, entry, fallback)
+	if err != nil || raw == nil {
 		return "", err
 	}
+	container, err := decodeContainerItem(raw)
+	if err != nil || len(container.ID) == 0 {
+		return "", err
+	}
+	if container.Fingerprint != fingerprint {
+		logrus.WithField("id", container.ID).WithField("entry", entry).Infoln("Configuration of the daemon changed, recreating it")
+		// start the new daemon directly, the cleared entry might not be written yet
+		raw, err = c.start(opt, entry, fingerprint, raw)
+		if err != nil {
+			return "", err
+		}
+		container, err = decodeContainerItem(raw)
+		if err != nil {
+			return "", err
+		}
+	}
+	return container.ID, add(c.db, DaemonContainerBucket, entry, raw, idle)
+}
 
-	if id != nil {
-		pID := ""
-		err := json.Unmarshal(id, &pID)
-		return pID, err
+// start will start the daemon of the tool and remove the given outdated one.
+// Parallel invocations only read the cache and write it when they are done, so starting is guarded by a lock file per daemon.
+// The lock file keeps the last started daemon, if a parallel invocation started it in the meantime, it is used instead.
+// An expired daemon that still executes a command is kept and its idle timeout is reset instead, it is replaced once it is not busy anymore.
+func (c *Container) start(opt *tool.ExecutionOptions, entry string, fingerprint string, oldValue json.RawMessage) (json.RawMessage, error) {
+	if opt.Tool.Data().Daemon == nil {
+		return json.Marshal(containerItem{Fingerprint: fingerprint})
+	}
+	old := containerItem{}
+	if oldValue != nil {
+		old, _ = decodeContainerItem(oldValue)
+	}
+
+	lock, err := c.lock(entry)
+	if err != nil {
+		return nil, err
+	}
+	defer lock.Close()
+	if raw, err := ioutil.ReadAll(lock); err == nil && len(raw) > 0 {
+		started, err := decodeContainerItem(raw)
+		if err == nil && len(started.ID) > 0 && started.ID != old.ID && started.Fingerprint == fingerprint && running(*opt.Docker, started.ID) {
+			logrus.WithField("id", started.ID).WithField("entry", entry).Infoln("Daemon has been started by a parallel invocation")
+			return raw, nil
+		}
 	}
-	return "", err
+
+	if len(old.ID) > 0 && old.Fingerprint == fingerprint && busy(*opt.Docker, old.ID) {
+		logrus.WithField("id", old.ID).WithField("entry", entry).Infoln("Daemon still executes a command, keeping it")
+		return oldValue, nil
+	}
+	if len(old.ID) > 0 {
+		// shutdown container if possible
+		opt.Docker.Docker.RemoveContainer(docker.RemoveContainerOptions{
+			Force: true,
+			ID:    old.ID,
+		})
+	}
+	id, err := tool.StartIfDaemon(opt)
+	if err != nil {
+		return nil, err
+	}
+	raw, err := json.Marshal(containerItem{ID: id, Fingerprint: fingerprint})
+	if err != nil {
+		return nil, err
+	}
+	if err := lock.Truncate(0); err != nil {
+		return nil, err
+	}
+	_, err = lock.WriteAt(raw, 0)
+	return raw, err
+}
+
+// lock will wait for the lock file of the given cache entry, the lock is released when the file is closed
+func (c *Container) lock(entry string) (*os.File, error) {
+	dir := filepath.Join(filepath.Dir(c.db.DB.Path()), LockDir)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, err
+	}
+	hash := sha256.Sum256([]byte(entry))
+	logrus.WithField("entry", entry).Debugln("Waiting for the lock of the daemon")
+	return utils.LockFile(filepath.Join(dir, hex.EncodeToString(hash[:8])+".lock"))
 }
 
-// GetContainerCacheEntry will return the name of the cache entry for the given tool and version
-func getContainerCacheEntry(to tool.Tool, version string) string {
-	return "container/" + to.Data().Registry + "/" + to.Data().Name + "/" + to.Data().Image + ":" + version
+// running will return true if the given container is running
+func running(client config.Docker, id string) bool {
+	container, err := client.Docker.InspectContainer(id)
+	return err == nil && container.State.Running
+}
+
+// decodeContainerItem will decode a cached container.
+// Older versions of Sledgehammer only cached the id, these entries have no fingerprint and will be recreated.
+func decodeContainerItem(raw json.RawMessage) (containerItem, error) {
+	item := containerItem{}
+	if len(raw) > 0 && raw[0] == '"' {
+		err := json.Unmarshal(raw, &item.ID)
+		return item, err
+	}
+	err := json.Unmarshal(raw, &item)
+	return item, err
+}
+
+// GetContainerCacheEntry will return the name of the cache entry for the given tool, version and alias.
+// Every alias gets its own daemon, the network, limits and security of an alias are part of the container.
+func getContainerCacheEntry(to tool.Tool, version string, alias string) string {
+	entry := containerEntryPrefix + to.Data().Registry + "/" + to.Data().Name + "/" + to.Data().Image + ":" + version
+	if len(alias) > 0 {
+		entry += aliasSeparator + alias
+	}
+	return entry
+}
+
+// parseContainerCacheEntry will return the daemon described by the given cache entry
+func parseContainerCacheEntry(entry string) Daemon {
+	d := Daemon{Entry: entry}
+	if i := strings.LastIndex(entry, aliasSeparator); i >= 0 {
+		entry, d.Alias = entry[:i], entry[i+len(aliasSeparator):]
+	}
+	parts := strings.SplitN(strings.TrimPrefix(entry, containerEntryPrefix), "/", 3)
+	if len(parts) != 3 {
+		return d
+	}
+	d.Registry, d.Tool, d.Image = parts[0], parts[1], parts[2]
+	if i := strings.LastIndex(d.Image, ":"); i >= 0 {
+		d.Image, d.Version = d.Image[:i], d.Image[i+1:]
+	}
+	return d
 }
@@ -12,6 +12,7 @@ written permission of Adobe.
 package cache_test
 
 import (
+	"errors"
 	"testing"
 	"time"
 
@@ -24,6 +25,10 @@ import (
 	"github.com/adobe/sledgehammer/mocks"
 	"github.com/adobe/sledgehammer/slh/cache"
 	"github.com/adobe/sledgehammer/slh/config"
+	"github.com/adobe/sledgehammer/slh/environment"
+	"github.com/adobe/sledgehammer/slh/mount"
+	"github.com/adobe/sledgehammer/slh/settings"
+	"github.com/adobe/sledgehammer/utils/db"
 	"github.com/adobe/sledgehammer/utils/test"
 	"github.com/golang/mock/gomock"
 )
@@ -74,11 +79,12 @@ func TestContainer(t *testing.T) {
 						Entry: []string{"foo"},
 					},
 				}).AnyTimes()
-				c.Container.Get(t, "1", &config.Config{
-					Docker: config.Docker{
-						Docker: m,
-					},
-				}, []string{})
+				c.Container.Get(&tool.ExecutionOptions{
+					Tool:    t,
+					Version: "1",
+					Docker:  &config.Docker{Docker: m},
+					Mounts:  []mount.Mount{},
+				})
 			},
 			expected: "foobar",
 		},
@@ -96,17 +102,147 @@ func TestContainer(t *testing.T) {
 						Entry: []string{"foo"},
 					},
 				}).AnyTimes()
-				c.Container.Get(t, "1", &config.Config{
-					Docker: config.Docker{
-						Docker: m,
-					},
-				}, []string{})
+				c.Container.Get(&tool.ExecutionOptions{
+					Tool:    t,
+					Version: "1",
+					Docker:  &config.Docker{Docker: m},
+					Mounts:  []mount.Mount{},
+				})
+				m.EXPECT().InspectContainer("foobar").Return(&docker.Container{ID: "foobar"}, nil)
 				m.EXPECT().RemoveContainer(gomock.Any())
 				m.EXPECT().CreateContainer(gomock.Any()).Return(&docker.Container{ID: "foobar2"}, nil)
 				m.EXPECT().StartContainer(gomock.Any(), gomock.Any())
 			},
 			expected: "foobar2",
 		},
+		{
+			name: "Container ID in cache expired while the daemon is busy",
+			before: func(c *cache.Cache, m *mocks.MockClient, t *mocks.MockTool) {
+				cache.ContainerIDTTL = 0 * time.Microsecond
+				m.EXPECT().CreateContainer(gomock.Any()).Return(&docker.Container{ID: "foobar"}, nil)
+				m.EXPECT().StartContainer(gomock.Any(), gomock.Any())
+				t.EXPECT().Data().Return(&tool.Data{
+					Registry: "foo",
+					Name:     "bar",
+					Daemon: &tool.Daemon{
+						Entry: []string{"foo"},
+					},
+				}).AnyTimes()
+				c.Container.Get(&tool.ExecutionOptions{
+					Tool:    t,
+					Version: "1",
+					Docker:  &config.Docker{Docker: m},
+					Mounts:  []mount.Mount{},
+				})
+				m.EXPECT().InspectContainer("foobar").Return(&docker.Container{ID: "foobar", ExecIDs: []string{"exec"}}, nil)
+				m.EXPECT().InspectExec("exec").Return(&docker.ExecInspect{Running: true}, nil)
+			},
+			expected: "foobar",
+		},
+		{
+			name: "Mounts of the daemon changed",
+			before: func(c *cache.Cache, m *mocks.MockClient, t *mocks.MockTool) {
+				cache.ContainerIDTTL = 10 * time.Minute
+				m.EXPECT().CreateContainer(gomock.Any()).Return(&docker.Container{ID: "foobar"}, nil)
+				m.EXPECT().StartContainer(gomock.Any(), gomock.Any())
+				t.EXPECT().Data().Return(&tool.Data{
+					Registry: "foo",
+					Name:     "bar",
+					Daemon: &tool.Daemon{
+						Entry: []string{"foo"},
+					},
+				}).AnyTimes()
+				c.Container.Get(&tool.ExecutionOptions{
+					Tool:    t,
+					Version: "1",
+					Docker:  &config.Docker{Docker: m},
+					Mounts:  []mount.Mount{mount.Bind("/foo")},
+				})
+				m.EXPECT().RemoveContainer(docker.RemoveContainerOptions{ID: "foobar", Force: true})
+				m.EXPECT().CreateContainer(gomock.Any()).Return(&docker.Container{ID: "foobar2"}, nil)
+				m.EXPECT().StartContainer(gomock.Any(), gomock.Any())
+			},
+			expected: "foobar2",
+		},
+		{
+			name: "Transient mounts are not mounted into the daemon",
+			before: func(c *cache.Cache, m *mocks.MockClient, t *mocks.MockTool) {
+				cache.ContainerIDTTL = 10 * time.Minute
+				m.EXPECT().CreateContainer(gomock.Any()).DoAndReturn(func(opts docker.CreateContainerOptions) (*docker.Container, error) {
+					for _, mo := range opts.HostConfig.Mounts {
+						if mo.Source == "/transient" {
+							return nil, errors.New("Transient mount in daemon")
+						}
+					}
+					return &docker.Container{ID: "foobar"}, nil
+				})
+				m.EXPECT().StartContainer(gomock.Any(), gomock.Any())
+				t.EXPECT().Data().Return(&tool.Data{
+					Registry: "foo",
+					Name:     "bar",
+					Daemon: &tool.Daemon{
+						Entry: []string{"foo"},
+					},
+				}).AnyTimes()
+				c.Container.Get(&tool.ExecutionOptions{
+					Tool:            t,
+					Version:         "1",
+					Docker:          &config.Docker{Docker: m},
+					Mounts:          []mount.Mount{},
+					TransientMounts: []string{"/transient"},
+				})
+			},
+			expected: "foobar",
+		},
+		{
+			name: "Alias has its own daemon",
+			before: func(c *cache.Cache, m *mocks.MockClient, t *mocks.MockTool) {
+				cache.ContainerIDTTL = 10 * time.Minute
+				m.EXPECT().CreateContainer(gomock.Any()).Return(&docker.Container{ID: "foobar"}, nil)
+				m.EXPECT().StartContainer(gomock.Any(), gomock.Any())
+				t.EXPECT().Data().Return(&tool.Data{
+					Registry: "foo",
+					Name:     "bar",
+					Daemon: &tool.Daemon{
+						Entry: []string{"foo"},
+					},
+				}).AnyTimes()
+				c.Container.Get(&tool.ExecutionOptions{
+					Tool:    t,
+					Version: "1",
+					Docker:  &config.Docker{Docker: m},
+					Mounts:  []mount.Mount{},
+					Network: "none",
+					Alias:   "baz",
+				})
+				m.EXPECT().CreateContainer(gomock.Any()).Return(&docker.Container{ID: "foobar2"}, nil)
+				m.EXPECT().StartContainer(gomock.Any(), gomock.Any())
+			},
+			expected: "foobar2",
+		},
+		{
+			name: "Alias of the daemon changed",
+			before: func(c *cache.Cache, m *mocks.MockClient, t *mocks.MockTool) {
+				cache.ContainerIDTTL = 10 * time.Minute
+				m.EXPECT().CreateContainer(gomock.Any()).Return(&docker.Container{ID: "foobar"}, nil)
+				m.EXPECT().StartContainer(gomock.Any(), gomock.Any())
+				t.EXPECT().Data().Return(&tool.Data{
+					Registry: "foo",
+					Name:     "bar",
+					Daemon: &tool.Daemon{
+						Entry: []string{"foo"},
+					},
+				}).AnyTimes()
+				c.Container.Get(&tool.ExecutionOptions{
+					Tool:     t,
+					Version:  "1",
+					Docker:   &config.Docker{Docker: m},
+					Mounts:   []mount.Mount{},
+					Policies: []environment.Policy{environment.FromAlias([]string{"GOPATH"})},
+				})
+			},
+			expected: "foobar",
+		},
 	}
 
 	for _, tt := range cases {
@@ -128,16 +264,12 @@ func TestContainer(t *testing.T) {
 			}
 
 			// do GET
-			id, err := c.Container.Get(
-				toolMock,
-				"1",
-				&config.Config{
-					Docker: config.Docker{
-						Docker: dockerMock,
-					},
-				},
-				[]string{},
-			)
+			id, err := c.Container.Get(&tool.ExecutionOptions{
+				Tool:    toolMock,
+				Version: "1",
+				Docker:  &config.Docker{Docker: dockerMock},
+				Mounts:  []mount.Mount{},
+			})
 
 			// execute after
 			if err != nil {
@@ -149,6 +281,191 @@ func TestContainer(t *testing.T) {
 	}
 }
 
+func TestDaemons(t *testing.T) {
+	cases := []struct {
+		name    string
+		idle    string
+		before  func(*mocks.MockClient)
+		current bool
+		reaped  int
+		daemons int
+	}{
+		{
+			name:    "Daemon within the idle timeout",
+			idle:    "1h",
+			daemons: 1,
+		},
+		{
+			name: "Idle daemon is reaped",
+			idle: "0s",
+			before: func(m *mocks.MockClient) {
+				m.EXPECT().InspectContainer("foobar").Return(&docker.Container{ID: "foobar", ExecIDs: []string{"done"}}, nil)
+				m.EXPECT().InspectExec("done").Return(&docker.ExecInspect{ID: "done"}, nil)
+				m.EXPECT().RemoveContainer(docker.RemoveContainerOptions{ID: "foobar", Force: true})
+			},
+			reaped: 1,
+		},
+		{
+			name:    "Idle daemon that has just been used",
+			idle:    "0s",
+			current: true,
+			daemons: 1,
+		},
+		{
+			name: "Reaped daemon is already gone",
+			idle: "0s",
+			before: func(m *mocks.MockClient) {
+				m.EXPECT().InspectContainer("foobar").Return(nil, &docker.NoSuchContainer{ID: "foobar"})
+				m.EXPECT().RemoveContainer(gomock.Any()).Return(&docker.NoSuchContainer{ID: "foobar"})
+			},
+			reaped: 1,
+		},
+		{
+			name: "Idle daemon that still executes a command",
+			idle: "0s",
+			before: func(m *mocks.MockClient) {
+				m.EXPECT().InspectContainer("foobar").Return(&docker.Container{ID: "foobar", ExecIDs: []string{"done", "build"}}, nil)
+				m.EXPECT().InspectExec("done").Return(&docker.ExecInspect{ID: "done"}, nil)
+				m.EXPECT().InspectExec("build").Return(&docker.ExecInspect{ID: "build", Running: true}, nil)
+			},
+			daemons: 1,
+		},
+	}
+
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			db := test.NewTestDB(t)
+			defer test.Close(db, t)
+			c := cache.New(config.Database{DB: db})
+			err := settings.New(config.Database{DB: db}).Set(settings.DaemonIdle, tt.idle)
+			assert.NoError(t, err)
+
+			mockCtrl := gomock.NewController(t)
+			defer mockCtrl.Finish()
+			dockerMock := mocks.NewMockClient(mockCtrl)
+			dockerMock.EXPECT().CreateContainer(gomock.Any()).Return(&docker.Container{ID: "foobar"}, nil)
+			dockerMock.EXPECT().StartContainer(gomock.Any(), gomock.Any())
+
+			opt := &tool.ExecutionOptions{
+				Tool: &tool.LocalTool{
+					Core: tool.Data{
+						Registry: "foo",
+						Name:     "bar",
+						Image:    "adobe/bar",
+						Daemon:   &tool.Daemon{Entry: []string{"foo"}},
+					},
+				},
+				Version: "1.0",
+				Docker:  &config.Docker{Docker: dockerMock},
+			}
+			_, err = c.Container.Get(opt)
+			assert.NoError(t, err)
+
+			daemons, err := c.Container.Find("", "bar")
+			assert.NoError(t, err)
+			if assert.Len(t, daemons, 1) {
+				assert.Equal(t, "foobar", daemons[0].ID)
+				assert.Equal(t, "foo", daemons[0].Registry)
+				assert.Equal(t, "adobe/bar", daemons[0].Image)
+				assert.Equal(t, "1.0", daemons[0].Version)
+			}
+
+			if tt.before != nil {
+				tt.before(dockerMock)
+			}
+			var current *tool.ExecutionOptions
+			if tt.current {
+				current = opt
+			}
+			reaped, err := c.Container.Reap(config.Docker{Docker: dockerMock}, current)
+			assert.NoError(t, err)
+			assert.Len(t, reaped, tt.reaped)
+
+			daemons, err = c.Container.CurrentDaemons()
+			assert.NoError(t, err)
+			assert.Len(t, daemons, tt.daemons)
+		})
+	}
+}
+
+func TestAliasDaemon(t *testing.T) {
+	database := test.NewTestDB(t)
+	defer test.Close(database, t)
+	c := cache.New(config.Database{DB: database})
+
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+	dockerMock := mocks.NewMockClient(mockCtrl)
+	dockerMock.EXPECT().CreateContainer(gomock.Any()).Return(&docker.Container{ID: "foobar"}, nil)
+	dockerMock.EXPECT().StartContainer(gomock.Any(), gomock.Any())
+
+	_, err := c.Container.Get(&tool.ExecutionOptions{
+		Tool: &tool.LocalTool{
+			Core: tool.Data{
+				Registry: "foo",
+				Name:     "bar",
+				Image:    "adobe/bar",
+				Daemon:   &tool.Daemon{Entry: []string{"foo"}},
+			},
+		},
+		Version: "1.0",
+		Alias:   "baz",
+		Docker:  &config.Docker{Docker: dockerMock},
+	})
+	assert.NoError(t, err)
+
+	daemons, err := c.Container.Find("foo", "bar")
+	assert.NoError(t, err)
+	if assert.Len(t, daemons, 1) {
+		assert.Equal(t, "foobar", daemons[0].ID)
+		assert.Equal(t, "baz", daemons[0].Alias)
+		assert.Equal(t, "adobe/bar", daemons[0].Image)
+		assert.Equal(t, "1.0", daemons[0].Version)
+	}
+}
+
+func TestParallelDaemon(t *testing.T) {
+	database := test.NewTestDB(t)
+	defer test.Close(database, t)
+
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+	dockerMock := mocks.NewMockClient(mockCtrl)
+	// the daemon is only started once
+	dockerMock.EXPECT().CreateContainer(gomock.Any()).Return(&docker.Container{ID: "foobar"}, nil)
+	dockerMock.EXPECT().StartContainer(gomock.Any(), gomock.Any())
+	dockerMock.EXPECT().InspectContainer("foobar").Return(&docker.Container{ID: "foobar", State: docker.State{Running: true}}, nil)
+
+	opt := &tool.ExecutionOptions{
+		Tool: &tool.LocalTool{
+			Core: tool.Data{
+				Registry: "foo",
+				Name:     "bar",
+				Image:    "adobe/bar",
+				Daemon:   &tool.Daemon{Entry: []string{"foo"}},
+			},
+		},
+		Version: "1.0",
+		Docker:  &config.Docker{Docker: dockerMock},
+	}
+	// both invocations only read the database, the cache entry of the first one is not written yet
+	for _, batch := range []*db.Batch{{}, {}} {
+		c := cache.New(config.Database{DB: database, Batch: batch})
+		id, err := c.Container.Get(opt)
+		assert.NoError(t, err)
+		assert.Equal(t, "foobar", id)
+	}
+}
+
+func TestFindDaemon(t *testing.T) {
+	db := test.NewTestDB(t)
+	defer test.Close(db, t)
+	c := cache.New(config.Database{DB: db})
+
+	_, err := c.Container.Find("", "bar")
+	assert.Equal(t, cache.ErrorNoDaemon, err)
+}
+
 // test clear
 
 // test registry
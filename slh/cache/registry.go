@@ -15,6 +15,7 @@ import (
 	"encoding/json"
 	"time"
 
+	"github.com/adobe/sledgehammer/slh/config"
 	"github.com/adobe/sledgehammer/slh/registry"
 	bolt "github.com/coreos/bbolt"
 )
@@ -28,10 +29,10 @@ var (
 
 // Registry will store the time when the registry was updated the last time
 type Registry struct {
-	db *bolt.DB
+	db config.Database
 }
 
-func newRegistryCache(db *bolt.DB) Registry {
+func newRegistryCache(db config.Database) Registry {
 	return Registry{db: db}
 }
 
@@ -71,6 +72,18 @@ func (r *Registry) LastUpdate(reg registry.Registry) (time.Time, error) {
 	return time.Now(), err
 }
 
+// Cached will return the last update of the registry without updating it.
+// If the registry has never been updated, the zero time is returned.
+func (r *Registry) Cached(reg registry.Registry) (time.Time, error) {
+	lastUpdate, err := lookup(r.db, RegistryBucket, getRegistryCacheEntry(reg))
+	lu := time.Time{}
+	if err != nil || lastUpdate == nil {
+		return lu, err
+	}
+	err = json.Unmarshal(lastUpdate, &lu)
+	return lu, err
+}
+
 func getRegistryCacheEntry(reg registry.Registry) string {
 	return "registry/" + reg.Data().Name
 }
@@ -37,10 +37,10 @@ var (
 
 // Version is the struct to work with cached versions
 type Version struct {
-	db *bolt.DB
+	db config.Database
 }
 
-func newVersionCache(db *bolt.DB) Version {
+func newVersionCache(db config.Database) Version {
 	return Version{db: db}
 }
 
@@ -63,6 +63,11 @@ func (v *Version) Clear(to tool.Tool) error {
 	return clear(v.db, RemoteVersionBucket, getRemoteVersionCacheEntry(to))
 }
 
+// ClearLocal will clear the cached local versions of this tool
+func (v *Version) ClearLocal(to tool.Tool) error {
+	return clear(v.db, LocalVersionBucket, getLocalVersionCacheEntry(to))
+}
+
 // Local will return the remote versions of a tool from the cache if possible
 func (v *Version) Local(to tool.Tool, client config.Docker) ([]string, error) {
 	logrus.WithField("entry", getLocalVersionCacheEntry(to)).Debugln("Checking local versions")
@@ -111,6 +116,17 @@ func (v *Version) Remote(to tool.Tool) ([]string, error) {
 	return nil, err
 }
 
+// Cached will return the last known remote versions of a tool without fetching them, even if they are outdated
+func (v *Version) Cached(to tool.Tool) ([]string, error) {
+	versions, err := lookup(v.db, RemoteVersionBucket, getRemoteVersionCacheEntry(to))
+	if err != nil || versions == nil {
+		return nil, err
+	}
+	sVersions := []string{}
+	err = json.Unmarshal(versions, &sVersions)
+	return sVersions, err
+}
+
 func getLocalVersionCacheEntry(to tool.Tool) string {
 	return "local/" + to.Data().Registry + "/" + to.Data().Name
 }
@@ -0,0 +1,106 @@
+/*
+Copyright 2018 Adobe
+All Rights Reserved.
+
+NOTICE: Adobe permits you to use, modify, and distribute this file in
+accordance with the terms of the Adobe license agreement accompanying
+it. If you have received this file from a source other than Adobe,
+then your use, modification, or distribution of it requires the prior
+written permission of Adobe.
+*/
+
+package cache_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/adobe/sledgehammer/mocks"
+	"github.com/adobe/sledgehammer/slh/cache"
+	"github.com/adobe/sledgehammer/slh/config"
+	"github.com/adobe/sledgehammer/slh/tool"
+	"github.com/adobe/sledgehammer/utils/db"
+	"github.com/adobe/sledgehammer/utils/test"
+)
+
+func TestCachedVersions(t *testing.T) {
+	defer func(ttl time.Duration) { cache.RemoteTimeToLive = ttl }(cache.RemoteTimeToLive)
+
+	cases := []struct {
+		name     string
+		fetch    bool
+		ttl      time.Duration
+		expected []string
+	}{
+		{
+			name: "Never fetched",
+		},
+		{
+			name:     "Fetched",
+			fetch:    true,
+			ttl:      time.Hour,
+			expected: []string{"1.0", "2.0"},
+		},
+		{
+			name:     "Expired versions are still returned",
+			fetch:    true,
+			ttl:      -time.Hour,
+			expected: []string{"1.0", "2.0"},
+		},
+	}
+
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			db := test.NewTestDB(t)
+			defer test.Close(db, t)
+			c := cache.New(config.Database{DB: db})
+			cache.RemoteTimeToLive = tt.ttl
+
+			mockCtrl := gomock.NewController(t)
+			defer mockCtrl.Finish()
+			toolMock := mocks.NewMockTool(mockCtrl)
+			toolMock.EXPECT().Data().Return(&tool.Data{Registry: "foo", Name: "bar"}).AnyTimes()
+			if tt.fetch {
+				// the versions are only fetched once, Cached never touches the remote repository
+				toolMock.EXPECT().Versions().Return([]string{"1.0", "2.0"}, nil)
+				_, err := c.Versions.Remote(toolMock)
+				assert.NoError(t, err)
+			}
+
+			versions, err := c.Versions.Cached(toolMock)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expected, versions)
+		})
+	}
+}
+
+func TestDeferredVersions(t *testing.T) {
+	database := test.NewTestDB(t)
+	defer test.Close(database, t)
+	batch := &db.Batch{}
+	c := cache.New(config.Database{DB: database, Batch: batch})
+
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+	toolMock := mocks.NewMockTool(mockCtrl)
+	toolMock.EXPECT().Data().Return(&tool.Data{Registry: "foo", Name: "bar"}).AnyTimes()
+	toolMock.EXPECT().Versions().Return([]string{"1.0", "2.0"}, nil)
+
+	versions, err := c.Versions.Remote(toolMock)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"1.0", "2.0"}, versions)
+
+	// the fetched versions are only written once the batch is applied
+	cached, err := c.Versions.Cached(toolMock)
+	assert.NoError(t, err)
+	assert.Nil(t, cached)
+	assert.Equal(t, 1, batch.Len())
+
+	assert.NoError(t, batch.Apply(database))
+	cached, err = c.Versions.Cached(toolMock)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"1.0", "2.0"}, cached)
+}
@@ -39,7 +39,7 @@ func RunAliasCommand(cfg *config.Config) *cobra.Command {
 
 // RunAlias will run the given alias and passes all arguments to it
 func RunAlias(cfg *config.Config, toolAlias string, arguments []string) error {
-	database, err := cfg.OpenDatabase()
+	database, err := cfg.OpenDatabaseReadOnly()
 	if database != nil {
 		defer cfg.CloseDatabase()
 	}
@@ -49,19 +49,32 @@ func RunAlias(cfg *config.Config, toolAlias string, arguments []string) error {
 
 	aliases := alias.New(config.Database{DB: database})
 
+	span := cfg.Profile.Span("resolve alias")
 	al, err := aliases.Get(toolAlias)
+	span()
 	if err != nil {
 		return err
 	}
 
 	cfg.CloseDatabase()
 
+	runCommand := aliasRunCmd(al, arguments)
+	return runCommand.Execute(cfg)
+}
+
+// aliasRunCmd will return the run of the given alias with its settings
+func aliasRunCmd(al *alias.Alias, arguments []string) RunCmd {
 	runCommand := RunCmd{
 		arguments: arguments,
 		registry:  al.Registry,
 		tool:      al.Tool,
 		version:   al.Version,
+		env:       al.Env,
+		network:   al.Network,
+		alias:     al.Name,
 	}
-
-	return runCommand.Execute(cfg)
+	if al.Limits != nil {
+		runCommand.limits = *al.Limits
+	}
+	return runCommand
 }
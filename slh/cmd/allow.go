@@ -0,0 +1,138 @@
+/*
+Copyright 2018 Adobe
+All Rights Reserved.
+
+NOTICE: Adobe permits you to use, modify, and distribute this file in
+accordance with the terms of the Adobe license agreement accompanying
+it. If you have received this file from a source other than Adobe,
+then your use, modification, or distribution of it requires the prior
+written permission of Adobe.
+*/
+
+package cmd
+
+import (
+	"bufio"
+	"fmt"
+	"strings"
+
+	"github.com/adobe/sledgehammer/slh/config"
+	"github.com/adobe/sledgehammer/slh/forward"
+	"github.com/adobe/sledgehammer/slh/out"
+	"github.com/adobe/sledgehammer/slh/permission"
+	"github.com/adobe/sledgehammer/slh/tool"
+	"github.com/adobe/sledgehammer/utils"
+	"github.com/spf13/cobra"
+)
+
+type allowCommand struct {
+	revoke bool
+}
+
+func AllowCommand(cfg *config.Config) *cobra.Command {
+	allowCmd := allowCommand{}
+	allowCommand := &cobra.Command{
+		Use:   "allow <tool>",
+		Short: "Approve the permissions a tool requests",
+		Long:  "Will approve the elevated access to the host the given tool requests, e.g. the docker daemon, host paths or forwarded credentials like the ssh agent",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			registry, name := utils.GetRegistryAndTool(args[0])
+			err := Allow(cfg, registry, name, allowCmd.revoke)
+			if err != nil {
+				cmd.SilenceUsage = true
+			}
+			return err
+		},
+	}
+
+	allowCommand.Flags().BoolVar(&allowCmd.revoke, "revoke", false, "Revoke the permissions instead, the tool will ask again on its next run")
+
+	return allowCommand
+}
+
+// Allow will approve the permissions the given tool requests, or revoke them
+func Allow(cfg *config.Config, registry string, name string, revoke bool) error {
+	database, err := cfg.OpenDatabase()
+	if database != nil {
+		defer cfg.CloseDatabase()
+	}
+	if err != nil {
+		return err
+	}
+
+	to, err := tool.New(config.Database{DB: database}).Get(registry, name)
+	if err != nil {
+		return err
+	}
+	registry, name = to.Data().Registry, to.Data().Name
+
+	requested, err := requestedPermissions(to)
+	if err != nil {
+		return err
+	}
+	approvals := permission.New(config.Database{DB: database})
+	if revoke {
+		err = approvals.Revoke(registry, name)
+	} else {
+		err = approvals.Approve(registry, name, requested)
+	}
+	if err != nil {
+		return err
+	}
+
+	approved, err := approvals.Approved(registry, name)
+	if err != nil {
+		return err
+	}
+	table := out.NewTable("Allowed", "Tool", "Registry", "Permission", "Description")
+	for _, p := range approved {
+		table.Add(name, registry, p, permission.Describe([]string{p}))
+	}
+	cfg.Output.Set(table)
+	return nil
+}
+
+// requestedPermissions will return the permissions the tool requests, the credentials it forwards need to be valid
+func requestedPermissions(to tool.Tool) ([]string, error) {
+	if err := forward.Validate(tool.Forwards(to)); err != nil {
+		return nil, err
+	}
+	return tool.RequestedPermissions(to)
+}
+
+// checkPermissions will make sure that the user approved all permissions the tool requests.
+// Permissions that have not been approved, e.g. because an update of the registry widened them, are asked for on the terminal unless allow is set.
+func checkPermissions(cfg *config.Config, db config.Database, to tool.Tool, allow bool) error {
+	requested, err := requestedPermissions(to)
+	if err != nil || len(requested) == 0 {
+		return err
+	}
+	registry, name := to.Data().Registry, to.Data().Name
+	approvals := permission.New(db)
+	approved, err := approvals.Approved(registry, name)
+	if err != nil {
+		return err
+	}
+	missing := permission.Missing(requested, approved)
+	if len(missing) == 0 {
+		return nil
+	}
+	if allow {
+		fmt.Fprintf(cfg.IO.Err, "Approving %s for the tool %s/%s\n", permission.Describe(missing), registry, name)
+	} else if !askApproval(cfg.IO, registry, name, missing) {
+		return &permission.MissingError{Registry: registry, Tool: name, Permissions: missing}
+	}
+	return approvals.Approve(registry, name, requested)
+}
+
+// askApproval will ask the user on the terminal if the tool may get the given permissions
+func askApproval(io *config.IO, registry string, name string, permissions []string) bool {
+	if !config.IsTerminal(io.In) {
+		return false
+	}
+	fmt.Fprintf(io.Err, "The tool %s/%s requests %s. Allow? [y/N] ", registry, name, permission.Describe(permissions))
+	answer, _ := bufio.NewReader(io.In).ReadString('\n')
+	answer = strings.ToLower(strings.TrimSpace(answer))
+	return answer == "y" || answer == "yes"
+}
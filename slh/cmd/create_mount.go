@@ -14,7 +14,6 @@ package cmd
 import (
 	"errors"
 	"os"
-	"path/filepath"
 
 	"github.com/docker/docker/pkg/homedir"
 
@@ -29,26 +28,65 @@ var (
 	ErrorInvalidPath = errors.New("Could not create given path, please make sure it exists and is a directory")
 )
 
+type createMountCommand struct {
+	target   string
+	readOnly bool
+	kind     string
+	registry string
+	tool     string
+}
+
 func CreateMountCommand(cfg *config.Config) *cobra.Command {
+	createMountCmd := createMountCommand{}
 	createMountCommand := &cobra.Command{
-		Use:     "mount <path>",
-		Short:   "Create a mount",
-		Long:    "Will create a given mount (paths) to Sledgehammer",
+		Use:   "mount <path>",
+		Short: "Create a mount",
+		Long: `Will create a given mount (paths) to Sledgehammer.
+For volumes the argument is the name of the volume, for tmpfs mounts it is the path in the container.`,
 		Aliases: []string{"mo", "mounts"},
 		Args:    cobra.ExactArgs(1),
 		RunE: func(cmd *cobra.Command, args []string) error {
-			err := CreateMount(cfg, args[0])
+			err := CreateMount(cfg, createMountCmd.mount(args[0]))
 			if err != nil {
 				cmd.SilenceUsage = true
 			}
 			return err
 		},
 	}
+
+	createMountCommand.Flags().StringVar(&createMountCmd.target, "target", "", "The path in the container, binds are mounted at the same path as on the host by default")
+	createMountCommand.Flags().BoolVar(&createMountCmd.readOnly, "read-only", false, "Mount read-only, tools can not modify the files")
+	createMountCommand.Flags().StringVar(&createMountCmd.kind, "type", mount.TypeBind, "The type of the mount, one of bind, volume or tmpfs")
+	createMountCommand.Flags().StringVar(&createMountCmd.registry, "registry", "", "Only use the mount for tools of the given registry")
+	createMountCommand.Flags().StringVar(&createMountCmd.tool, "tool", "", "Only use the mount for the given tool, optionally with its registry (e.g. default/terraform)")
+
 	return createMountCommand
 }
 
+// mount will create the mount based on the argument and the flags
+func (c *createMountCommand) mount(arg string) mount.Mount {
+	m := mount.Mount{
+		Type:     c.kind,
+		Source:   arg,
+		Target:   c.target,
+		ReadOnly: c.readOnly,
+		Registry: c.registry,
+	}
+	if len(c.tool) > 0 {
+		registry, tool := utils.GetRegistryAndTool(c.tool)
+		if len(registry) > 0 {
+			m.Registry = registry
+		}
+		m.Tool = tool
+	}
+	if m.Type == mount.TypeTmpfs && len(m.Target) == 0 {
+		m.Source, m.Target = "", arg
+	}
+	return m
+}
+
 // CreateMount will create a new mount if possible
-func CreateMount(cfg *config.Config, path string) error {
+func CreateMount(cfg *config.Config, mo mount.Mount) error {
 	database, err := cfg.OpenDatabase()
 	if database != nil {
 		defer cfg.CloseDatabase()
@@ -56,19 +94,16 @@ func CreateMount(cfg *config.Config, path string) error {
 	if err != nil {
 		return err
 	}
-	path = utils.ImportPath(path)
-	absPath, err := filepath.Abs(path)
-	if err != nil {
-		return err
-	}
-
-	if _, err := os.Stat(absPath); err != nil {
-		return ErrorInvalidPath
+	if mo.Type == mount.TypeBind {
+		mo.Source = utils.ImportPath(mo.Source)
+		if _, err := os.Stat(mo.Source); err != nil {
+			return ErrorInvalidPath
+		}
 	}
 
 	m := mount.New(config.Database{DB: database})
 
-	err = m.Add(path)
+	err = m.Add(mo)
 	if err != nil {
 		return err
 	}
@@ -78,6 +113,6 @@ func CreateMount(cfg *config.Config, path string) error {
 func addDefaultMount(cfg *config.Config) error {
 	home := homedir.Get()
 	home = utils.ImportPath(home)
-	return CreateMount(cfg, home)
+	return CreateMount(cfg, mount.Bind(home))
 
 }
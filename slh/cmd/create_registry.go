@@ -77,6 +77,10 @@ func (cmd *createRegistryCommand) CreateRegistry(cfg *config.Config, arguments [
 	if !found {
 		return ErrorRegistryTypeNotSupported
 	}
+	// only registries from a file can be created without the network
+	if cfg.Offline && cmd.Type != registry.RegTypeFile && cmd.Type != registry.RegTypeLocal {
+		return ErrorOffline
+	}
 
 	reg, err := factory.Create(registry.Data{
 		Name: cmd.Name,
@@ -55,6 +55,15 @@ func TestCreateRegistry(t *testing.T) {
 				},
 			},
 		},
+		{
+			Name: "Git registry while offline",
+			Steps: []*test.Step{
+				{
+					Cmd: fmt.Sprintf("create registry git https://github.com/adobe/sledgehammer-registry.git --offline"),
+					Has: []string{cmd.ErrorOffline.Error()},
+				},
+			},
+		},
 		{
 			Name: "Invalid registry foobar",
 			Steps: []*test.Step{
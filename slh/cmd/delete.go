@@ -27,6 +27,8 @@ func DeleteCommand(cfg *config.Config) *cobra.Command {
 	// add list commands
 	deleteCommand.AddCommand(DeleteMountCommand(cfg))
 	deleteCommand.AddCommand(DeleteRegistryCommand(cfg))
+	deleteCommand.AddCommand(DeleteSettingCommand(cfg))
+	deleteCommand.AddCommand(DeleteCachesCommand(cfg))
 
 	return deleteCommand
 }
@@ -0,0 +1,62 @@
+/*
+Copyright 2018 Adobe
+All Rights Reserved.
+
+NOTICE: Adobe permits you to use, modify, and distribute this file in
+accordance with the terms of the Adobe license agreement accompanying
+it. If you have received this file from a source other than Adobe,
+then your use, modification, or distribution of it requires the prior
+written permission of Adobe.
+*/
+
+package cmd
+
+import (
+	"github.com/adobe/sledgehammer/slh/config"
+	"github.com/adobe/sledgehammer/slh/out"
+	"github.com/adobe/sledgehammer/slh/tool"
+	"github.com/adobe/sledgehammer/utils"
+	"github.com/spf13/cobra"
+)
+
+func DeleteCachesCommand(cfg *config.Config) *cobra.Command {
+	deleteCachesCommand := &cobra.Command{
+		Use:     "caches [tool]",
+		Short:   "Clears caches",
+		Long:    "Will remove the cache volumes of the given tool, or of all tools if no tool is given. The tools start with empty caches the next time they run",
+		Aliases: []string{"cache", "ca"},
+		Args:    cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			registry, name := "", ""
+			if len(args) > 0 {
+				registry, name = utils.GetRegistryAndTool(args[0])
+			}
+			err := DeleteCaches(cfg, registry, name)
+			if err != nil {
+				cmd.SilenceUsage = true
+			}
+			return err
+		},
+	}
+	return deleteCachesCommand
+}
+
+// DeleteCaches will remove the caches of the given tool, or of all tools if the tool is empty
+func DeleteCaches(cfg *config.Config, registry string, name string) error {
+	caches, err := tool.Caches(cfg.Docker, "", registry, name, false)
+	if err != nil {
+		return err
+	}
+
+	table := out.NewTable("Caches", "Tool", "Registry", "Path", "Volume", "Status")
+	for _, c := range caches {
+		status := "removed"
+		if err := tool.RemoveCache(cfg.Docker, c); err != nil {
+			status = err.Error()
+		}
+		table.Add(c.Tool, c.Registry, c.Path, c.Volume, status)
+	}
+
+	cfg.Output.Set(table)
+	return nil
+}
@@ -21,26 +21,44 @@ import (
 	"github.com/spf13/cobra"
 )
 
+type deleteMountCommand struct {
+	registry string
+	tool     string
+}
+
 func DeleteMountCommand(cfg *config.Config) *cobra.Command {
+	deleteMountCmd := deleteMountCommand{}
 	deleteMountCommand := &cobra.Command{
 		Use:     "mount <path>",
 		Short:   "Deletes a mount",
-		Long:    "Will delete the given mount <path> from Sledgehammer",
+		Long:    "Will delete the given mount <path> from Sledgehammer, the path can either be the source or the target of the mount",
 		Aliases: []string{"mo", "mounts"},
 		Args:    cobra.ExactArgs(1),
 		RunE: func(cmd *cobra.Command, args []string) error {
-			err := DeleteMount(cfg, args[0])
+			registry, tool := deleteMountCmd.registry, ""
+			if len(deleteMountCmd.tool) > 0 {
+				var r string
+				r, tool = utils.GetRegistryAndTool(deleteMountCmd.tool)
+				if len(r) > 0 {
+					registry = r
+				}
+			}
+			err := DeleteMount(cfg, registry, tool, args[0])
 			if err != nil {
 				cmd.SilenceUsage = true
 			}
 			return err
 		},
 	}
+
+	deleteMountCommand.Flags().StringVar(&deleteMountCmd.registry, "registry", "", "Delete the mount that is only used for tools of the given registry")
+	deleteMountCommand.Flags().StringVar(&deleteMountCmd.tool, "tool", "", "Delete the mount that is only used for the given tool, optionally with its registry (e.g. default/terraform)")
+
 	return deleteMountCommand
 }
 
-// DeleteMount will list all mounts that are registered with Sledgehammer on this system
-func DeleteMount(cfg *config.Config, path string) error {
+// DeleteMount will delete the mount with the given path as source or target that is restricted to the given registry and tool
+func DeleteMount(cfg *config.Config, registry string, tool string, path string) error {
 	database, err := cfg.OpenDatabase()
 	if database != nil {
 		defer cfg.CloseDatabase()
@@ -49,15 +67,15 @@ func DeleteMount(cfg *config.Config, path string) error {
 		return err
 	}
 
-	path = utils.ImportPath(path)
-	absPath, err := filepath.Abs(path)
+	absPath, err := filepath.Abs(utils.ImportPath(path))
 	if err != nil {
 		return err
 	}
 
 	m := mount.New(config.Database{DB: database})
 
-	err = m.Remove(absPath)
+	// volumes and tmpfs mounts are not paths on the host, so the path is also removed as given
+	err = m.Remove(registry, tool, absPath, path)
 	if err != nil {
 		return err
 	}
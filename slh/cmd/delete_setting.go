@@ -0,0 +1,55 @@
+/*
+Copyright 2018 Adobe
+All Rights Reserved.
+
+NOTICE: Adobe permits you to use, modify, and distribute this file in
+accordance with the terms of the Adobe license agreement accompanying
+it. If you have received this file from a source other than Adobe,
+then your use, modification, or distribution of it requires the prior
+written permission of Adobe.
+*/
+
+package cmd
+
+import (
+	"github.com/adobe/sledgehammer/slh/config"
+	"github.com/adobe/sledgehammer/slh/settings"
+	"github.com/spf13/cobra"
+)
+
+func DeleteSettingCommand(cfg *config.Config) *cobra.Command {
+	deleteSettingCommand := &cobra.Command{
+		Use:     "setting <key>",
+		Short:   "Deletes a setting",
+		Long:    "Will reset the given setting to the default of Sledgehammer",
+		Aliases: []string{"settings", "se"},
+		Args:    cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			err := DeleteSetting(cfg, args[0])
+			if err != nil {
+				cmd.SilenceUsage = true
+			}
+			return err
+		},
+	}
+	return deleteSettingCommand
+}
+
+// DeleteSetting will reset the given setting to its default
+func DeleteSetting(cfg *config.Config, key string) error {
+	database, err := cfg.OpenDatabase()
+	if database != nil {
+		defer cfg.CloseDatabase()
+	}
+	if err != nil {
+		return err
+	}
+
+	s := settings.New(config.Database{DB: database})
+
+	err = s.Remove(key)
+	if err != nil {
+		return err
+	}
+	return GetSettings(cfg.WithDatabase(database))
+}
@@ -55,7 +55,12 @@ func DescribeRegistry(cfg *config.Config, name string) error {
 		return err
 	}
 
-	lastUpdated, err := c.Registry.LastUpdate(reg)
+	// only update the registry if we are allowed to access the network
+	lastUpdate := c.Registry.LastUpdate
+	if cfg.Offline {
+		lastUpdate = c.Registry.Cached
+	}
+	lastUpdated, err := lastUpdate(reg)
 	if err != nil {
 		return err
 	}
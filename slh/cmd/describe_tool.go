@@ -68,7 +68,12 @@ func DescribeTool(cfg *config.Config, name string) error {
 	if err != nil {
 		localVersions = []string{err.Error()}
 	}
-	remoteVersions, err := caches.Versions.Remote(to)
+	// only fetch the remote versions if we are allowed to access the network
+	remote := caches.Versions.Remote
+	if cfg.Offline {
+		remote = caches.Versions.Cached
+	}
+	remoteVersions, err := remote(to)
 	if err != nil {
 		remoteVersions = []string{err.Error()}
 	}
@@ -85,9 +90,69 @@ func DescribeTool(cfg *config.Config, name string) error {
 	ct.Add(out.NewValue("Daemon", to.Data().Daemon != nil))
 	if to.Data().Daemon != nil {
 		ct.Add(out.NewValue("Daemon Entry", to.Data().Daemon.Entry))
+		if to.Data().Daemon.Ready != nil {
+			ct.Add(out.NewValue("Daemon Ready", to.Data().Daemon.Ready.String()))
+		}
+	}
+	network, err := resolveNetwork(database, to, "")
+	if err != nil {
+		return err
+	}
+	ct.Add(out.NewValue("Network", network))
+	limits, err := resolveLimits(database, to, tool.Limits{})
+	if err != nil {
+		return err
+	}
+	if !limits.Empty() {
+		ct.Add(out.NewValue("Limits", limits.String()))
+	}
+	security, err := resolveSecurity(database, to)
+	if err != nil {
+		return err
+	}
+	ct.Add(out.NewValue("Security", security))
+	permissions, err := tool.RequestedPermissions(to)
+	if err != nil {
+		return err
+	}
+	if len(permissions) > 0 {
+		ct.Add(out.NewValue("Permissions", permissions))
+	}
+	if rt := to.Data().Runtime; rt != nil {
+		if len(rt.User) > 0 {
+			ct.Add(out.NewValue("User", rt.User))
+		}
+		if len(rt.WorkDir) > 0 {
+			ct.Add(out.NewValue("Working Dir", rt.WorkDir))
+		}
+		if len(rt.Ports) > 0 {
+			ct.Add(out.NewValue("Ports", rt.Ports))
+		}
+		if len(rt.Volumes) > 0 {
+			ct.Add(out.NewValue("Volumes", rt.Volumes))
+		}
+		if len(rt.EnvAllow) > 0 {
+			ct.Add(out.NewValue("Env Allow", rt.EnvAllow))
+		}
+		if len(rt.EnvDeny) > 0 {
+			ct.Add(out.NewValue("Env Deny", rt.EnvDeny))
+		}
 	}
 	ct.Add(out.NewEmpty())
 
+	env, _, err := resolveEnvironment(database, to, []string{})
+	if err != nil {
+		return err
+	}
+	if len(env) > 0 {
+		table := out.NewTable("Environment", "Variable", "Value", "Source")
+		for _, v := range env {
+			table.Add(v.Name, v.Redact(), v.Source)
+		}
+		ct.Add(table)
+		ct.Add(out.NewNewLine())
+	}
+
 	mergedVersions := version.Merge(localVersions, remoteVersions)
 	if len(mergedVersions) > 0 {
 		table := out.NewTable("Versions", "Version", "Local")
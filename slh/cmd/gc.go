@@ -0,0 +1,69 @@
+/*
+Copyright 2018 Adobe
+All Rights Reserved.
+
+NOTICE: Adobe permits you to use, modify, and distribute this file in
+accordance with the terms of the Adobe license agreement accompanying
+it. If you have received this file from a source other than Adobe,
+then your use, modification, or distribution of it requires the prior
+written permission of Adobe.
+*/
+package cmd
+
+import (
+	"github.com/adobe/sledgehammer/slh/cache"
+	"github.com/adobe/sledgehammer/slh/config"
+	"github.com/adobe/sledgehammer/slh/tool"
+	"github.com/spf13/cobra"
+)
+
+func GCCommand(cfg *config.Config) *cobra.Command {
+	gcCommand := &cobra.Command{
+		Use:   "gc",
+		Short: "Remove orphaned containers",
+		Long:  "Will remove all containers created by Sledgehammer that nobody is going to remove anymore, e.g. because Sledgehammer has been killed",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			err := GC(cfg)
+			if err != nil {
+				cmd.SilenceUsage = true
+			}
+			return err
+		},
+	}
+	return gcCommand
+}
+
+// GC will remove all orphaned containers and show them
+func GC(cfg *config.Config) error {
+	database, err := cfg.OpenDatabase()
+	if database != nil {
+		defer cfg.CloseDatabase()
+	}
+	if err != nil {
+		return err
+	}
+
+	c := cache.New(config.Database{DB: database})
+
+	daemons, err := c.Container.DaemonIDs()
+	if err != nil {
+		return err
+	}
+	installation, err := cfg.Installation()
+	if err != nil {
+		return err
+	}
+	removed, err := tool.Collect(cfg.Docker, installation, daemons)
+	if err != nil {
+		return err
+	}
+
+	table := containerTable("Removed")
+	for _, co := range removed {
+		table.Add(co.Tool, co.Registry, co.Version, co.Alias, shortID(co.ID), co.State, co.Daemon, true)
+	}
+
+	cfg.Output.Set(table)
+	return nil
+}
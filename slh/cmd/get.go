@@ -29,6 +29,10 @@ func GetCommand(cfg *config.Config) *cobra.Command {
 	getCommand.AddCommand(GetRegistryCommand(cfg))
 	getCommand.AddCommand(GetToolCommand(cfg))
 	getCommand.AddCommand(GetKitCommand(cfg))
+	getCommand.AddCommand(GetSettingsCommand(cfg))
+	getCommand.AddCommand(GetDaemonsCommand(cfg))
+	getCommand.AddCommand(GetContainersCommand(cfg))
+	getCommand.AddCommand(GetCachesCommand(cfg))
 
 	return getCommand
 }
@@ -0,0 +1,70 @@
+/*
+Copyright 2018 Adobe
+All Rights Reserved.
+
+NOTICE: Adobe permits you to use, modify, and distribute this file in
+accordance with the terms of the Adobe license agreement accompanying
+it. If you have received this file from a source other than Adobe,
+then your use, modification, or distribution of it requires the prior
+written permission of Adobe.
+*/
+
+package cmd
+
+import (
+	"github.com/adobe/sledgehammer/slh/config"
+	"github.com/adobe/sledgehammer/slh/out"
+	"github.com/adobe/sledgehammer/slh/tool"
+	"github.com/adobe/sledgehammer/utils"
+	"github.com/spf13/cobra"
+)
+
+func GetCachesCommand(cfg *config.Config) *cobra.Command {
+	getCachesCommand := &cobra.Command{
+		Use:     "caches [tool]",
+		Short:   "Get all caches",
+		Long:    "Will get the cache volumes of all tools or of the given tool together with their sizes",
+		Aliases: []string{"cache", "ca"},
+		Args:    cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			registry, name := "", ""
+			if len(args) > 0 {
+				registry, name = utils.GetRegistryAndTool(args[0])
+			}
+			err := GetCaches(cfg, registry, name)
+			if err != nil {
+				cmd.SilenceUsage = true
+			}
+			return err
+		},
+	}
+	return getCachesCommand
+}
+
+// GetCaches will get the caches of the given tool, or of all tools if the tool is empty
+func GetCaches(cfg *config.Config, registry string, name string) error {
+	installation, err := cfg.Installation()
+	if err != nil {
+		return err
+	}
+	caches, err := tool.Caches(cfg.Docker, installation, registry, name, true)
+	if err != nil {
+		return err
+	}
+
+	table := out.NewTable("Caches", "Tool", "Registry", "Path", "Volume", "Size")
+	for _, c := range caches {
+		table.Add(c.Tool, c.Registry, c.Path, c.Volume, cacheSize(c))
+	}
+
+	cfg.Output.Set(table)
+	return nil
+}
+
+// cacheSize will format the size of the cache, it might not be known
+func cacheSize(c tool.Cache) string {
+	if c.Size < 0 {
+		return "unknown"
+	}
+	return out.ByteSize(c.Size)
+}
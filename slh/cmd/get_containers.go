@@ -0,0 +1,70 @@
+/*
+Copyright 2018 Adobe
+All Rights Reserved.
+
+NOTICE: Adobe permits you to use, modify, and distribute this file in
+accordance with the terms of the Adobe license agreement accompanying
+it. If you have received this file from a source other than Adobe,
+then your use, modification, or distribution of it requires the prior
+written permission of Adobe.
+*/
+package cmd
+
+import (
+	"github.com/adobe/sledgehammer/slh/cache"
+	"github.com/adobe/sledgehammer/slh/config"
+	"github.com/adobe/sledgehammer/slh/out"
+	"github.com/adobe/sledgehammer/slh/tool"
+	"github.com/spf13/cobra"
+)
+
+func GetContainersCommand(cfg *config.Config) *cobra.Command {
+	getContainersCommand := &cobra.Command{
+		Use:     "containers",
+		Short:   "Get all containers",
+		Long:    "Will get all containers that have been created by Sledgehammer",
+		Aliases: []string{"container", "co"},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return GetContainers(cfg)
+		},
+	}
+	return getContainersCommand
+}
+
+// GetContainers will get all containers created by Sledgehammer and show if they are orphaned
+func GetContainers(cfg *config.Config) error {
+	database, err := cfg.OpenDatabase()
+	if database != nil {
+		defer cfg.CloseDatabase()
+	}
+	if err != nil {
+		return err
+	}
+
+	c := cache.New(config.Database{DB: database})
+
+	daemons, err := c.Container.DaemonIDs()
+	if err != nil {
+		return err
+	}
+	installation, err := cfg.Installation()
+	if err != nil {
+		return err
+	}
+	containers, err := tool.Containers(cfg.Docker)
+	if err != nil {
+		return err
+	}
+
+	table := containerTable("Containers")
+	for _, co := range containers {
+		table.Add(co.Tool, co.Registry, co.Version, co.Alias, shortID(co.ID), co.State, co.Daemon, co.Orphaned(installation, daemons))
+	}
+
+	cfg.Output.Set(table)
+	return nil
+}
+
+func containerTable(name string) *out.Table {
+	return out.NewTable(name, "Tool", "Registry", "Version", "Alias", "Container", "State", "Daemon", "Orphaned")
+}
@@ -0,0 +1,76 @@
+/*
+Copyright 2018 Adobe
+All Rights Reserved.
+
+NOTICE: Adobe permits you to use, modify, and distribute this file in
+accordance with the terms of the Adobe license agreement accompanying
+it. If you have received this file from a source other than Adobe,
+then your use, modification, or distribution of it requires the prior
+written permission of Adobe.
+*/
+package cmd
+
+import (
+	"time"
+
+	"github.com/adobe/sledgehammer/slh/cache"
+	"github.com/adobe/sledgehammer/slh/config"
+	"github.com/adobe/sledgehammer/slh/out"
+	"github.com/spf13/cobra"
+)
+
+func GetDaemonsCommand(cfg *config.Config) *cobra.Command {
+	getDaemonsCommand := &cobra.Command{
+		Use:     "daemons",
+		Short:   "Get all daemons",
+		Long:    "Will get all daemon containers of tools that have been started by Sledgehammer",
+		Aliases: []string{"daemon", "da"},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return GetDaemons(cfg)
+		},
+	}
+	return getDaemonsCommand
+}
+
+// GetDaemons will get all daemons together with the state of their containers
+func GetDaemons(cfg *config.Config) error {
+	database, err := cfg.OpenDatabase()
+	if database != nil {
+		defer cfg.CloseDatabase()
+	}
+	if err != nil {
+		return err
+	}
+
+	c := cache.New(config.Database{DB: database})
+
+	daemons, err := c.Container.CurrentDaemons()
+	if err != nil {
+		return err
+	}
+
+	table := out.NewTable("Daemons", "Tool", "Alias", "Registry", "Version", "Container", "State", "Idle Timeout")
+	for _, d := range daemons {
+		state := "missing"
+		container, err := cfg.Docker.Docker.InspectContainer(d.ID)
+		if err == nil {
+			state = container.State.StateString()
+		}
+		idle := "expired"
+		if !d.Idle() {
+			idle = time.Until(d.IdleUntil).Round(time.Second).String()
+		}
+		table.Add(d.Tool, d.Alias, d.Registry, d.Version, shortID(d.ID), state, idle)
+	}
+
+	cfg.Output.Set(table)
+	return nil
+}
+
+// shortID will return the short form of a container id like the docker cli
+func shortID(id string) string {
+	if len(id) > 12 {
+		return id[:12]
+	}
+	return id
+}
@@ -0,0 +1,60 @@
+/*
+Copyright 2018 Adobe
+All Rights Reserved.
+
+NOTICE: Adobe permits you to use, modify, and distribute this file in
+accordance with the terms of the Adobe license agreement accompanying
+it. If you have received this file from a source other than Adobe,
+then your use, modification, or distribution of it requires the prior
+written permission of Adobe.
+*/
+package cmd_test
+
+import (
+	"testing"
+
+	"github.com/adobe/sledgehammer/slh/cache"
+	"github.com/adobe/sledgehammer/utils/test"
+)
+
+func TestGetDaemons(t *testing.T) {
+	cases := []*test.TestCase{
+		{
+			Name: "No daemons",
+			Steps: []*test.Step{
+				{
+					Cmd: "get daemons",
+					Has: []string{"Daemons", "Tool", "Idle Timeout"},
+				},
+			},
+		},
+		{
+			Name: "Stop unknown daemon",
+			Steps: []*test.Step{
+				{
+					Cmd: "stop daemon foo",
+					Has: []string{cache.ErrorNoDaemon.Error()},
+				},
+			},
+		},
+		{
+			Name: "Restart unknown daemon",
+			Steps: []*test.Step{
+				{
+					Cmd: "restart daemon foo",
+					Has: []string{cache.ErrorNoDaemon.Error()},
+				},
+			},
+		},
+		{
+			Name: "Logs of unknown daemon",
+			Steps: []*test.Step{
+				{
+					Cmd: "logs foo",
+					Has: []string{cache.ErrorNoDaemon.Error()},
+				},
+			},
+		},
+	}
+	test.DoTest(t, cases)
+}
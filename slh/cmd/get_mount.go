@@ -47,9 +47,13 @@ func GetMounts(cfg *config.Config) error {
 	if err != nil {
 		return err
 	}
-	table := out.NewTable("Mounts", "Mounts")
+	table := out.NewTable("Mounts", "Mounts", "Target", "Type", "Mode", "Registry", "Tool")
 	for _, m := range mounts {
-		table.Add(m)
+		mode := "rw"
+		if m.ReadOnly {
+			mode = "ro"
+		}
+		table.Add(m.Source, m.ContainerTarget(), m.Type, mode, m.Registry, m.Tool)
 	}
 
 	cfg.Output.Set(table)
@@ -0,0 +1,66 @@
+/*
+Copyright 2018 Adobe
+All Rights Reserved.
+
+NOTICE: Adobe permits you to use, modify, and distribute this file in
+accordance with the terms of the Adobe license agreement accompanying
+it. If you have received this file from a source other than Adobe,
+then your use, modification, or distribution of it requires the prior
+written permission of Adobe.
+*/
+
+package cmd
+
+import (
+	"sort"
+
+	"github.com/adobe/sledgehammer/slh/config"
+	"github.com/adobe/sledgehammer/slh/out"
+	"github.com/adobe/sledgehammer/slh/settings"
+	"github.com/spf13/cobra"
+)
+
+func GetSettingsCommand(cfg *config.Config) *cobra.Command {
+	getSettingsCommand := &cobra.Command{
+		Use:     "settings",
+		Short:   "Get all settings",
+		Long:    "Will get all global settings of Sledgehammer and their current values",
+		Aliases: []string{"setting", "se"},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return GetSettings(cfg)
+		},
+	}
+	return getSettingsCommand
+}
+
+// GetSettings will get all settings together with their values
+func GetSettings(cfg *config.Config) error {
+	database, err := cfg.OpenDatabase()
+	if database != nil {
+		defer cfg.CloseDatabase()
+	}
+	if err != nil {
+		return err
+	}
+
+	s := settings.New(config.Database{DB: database})
+
+	_, values, err := s.List()
+	if err != nil {
+		return err
+	}
+
+	keys := []string{}
+	for key := range settings.Keys {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	table := out.NewTable("Settings", "Key", "Value", "Description")
+	for _, key := range keys {
+		table.Add(key, values[key], settings.Keys[key])
+	}
+
+	cfg.Output.Set(table)
+	return nil
+}
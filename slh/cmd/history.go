@@ -0,0 +1,124 @@
+/*
+Copyright 2018 Adobe
+All Rights Reserved.
+
+NOTICE: Adobe permits you to use, modify, and distribute this file in
+accordance with the terms of the Adobe license agreement accompanying
+it. If you have received this file from a source other than Adobe,
+then your use, modification, or distribution of it requires the prior
+written permission of Adobe.
+*/
+
+package cmd
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/adobe/sledgehammer/slh/config"
+	"github.com/adobe/sledgehammer/slh/history"
+	"github.com/adobe/sledgehammer/slh/out"
+	"github.com/adobe/sledgehammer/utils"
+	"github.com/spf13/cobra"
+)
+
+type historyCommand struct {
+	tool  string
+	alias string
+	since string
+	limit int
+}
+
+func HistoryCommand(cfg *config.Config) *cobra.Command {
+	historyCmd := historyCommand{}
+	historyCommand := &cobra.Command{
+		Use:   "history",
+		Short: "Show the executions of tools",
+		Long:  "Will show the recorded executions of tools, the newest first",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			filter, err := historyCmd.filter(time.Now())
+			if err == nil {
+				err = History(cfg, filter)
+			}
+			if err != nil {
+				cmd.SilenceUsage = true
+			}
+			return err
+		},
+	}
+
+	historyCommand.Flags().StringVar(&historyCmd.tool, "tool", "", "Only show executions of the given tool, optionally with its registry (e.g. default/terraform)")
+	historyCommand.Flags().StringVar(&historyCmd.alias, "alias", "", "Only show executions of the given alias")
+	historyCommand.Flags().StringVar(&historyCmd.since, "since", "", "Only show executions since the given duration (e.g. 24h) or date (e.g. 2018-10-01)")
+	historyCommand.Flags().IntVar(&historyCmd.limit, "limit", 50, "The maximum number of executions to show, 0 shows all")
+
+	return historyCommand
+}
+
+// filter will create the filter for the history based on the flags
+func (h *historyCommand) filter(now time.Time) (history.Filter, error) {
+	filter := history.Filter{
+		Alias: h.alias,
+		Limit: h.limit,
+	}
+	if len(h.tool) > 0 {
+		filter.Registry, filter.Tool = utils.GetRegistryAndTool(h.tool)
+	}
+	if len(h.since) > 0 {
+		since, err := parseSince(h.since, now)
+		if err != nil {
+			return filter, err
+		}
+		filter.Since = since
+	}
+	return filter, nil
+}
+
+// parseSince will parse either a duration relative to now or a date
+func parseSince(value string, now time.Time) (time.Time, error) {
+	if d, err := time.ParseDuration(value); err == nil {
+		return now.Add(-d), nil
+	}
+	if t, err := time.ParseInLocation("2006-01-02", value, time.Local); err == nil {
+		return t, nil
+	}
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+	return time.Time{}, fmt.Errorf("The time %s is neither a duration nor a date", value)
+}
+
+// History will show the executions selected by the filter
+func History(cfg *config.Config, filter history.Filter) error {
+	database, err := cfg.OpenDatabaseReadOnly()
+	if database != nil {
+		defer cfg.CloseDatabase()
+	}
+	if err != nil {
+		return err
+	}
+
+	records, err := history.New(cfg.Database()).List(filter)
+	if err != nil {
+		return err
+	}
+
+	table := out.NewTable("History", "Time", "Alias", "Tool", "Registry", "Version", "Digest", "Directory", "Arguments", "Duration", "Exit Code")
+	for _, r := range records {
+		table.Add(
+			r.Time.Format(time.RFC3339),
+			r.Alias,
+			r.Tool,
+			r.Registry,
+			r.Version,
+			r.Digest,
+			r.Dir,
+			r.Args,
+			r.Duration.Round(time.Millisecond).String(),
+			r.ExitCode,
+		)
+	}
+	cfg.Output.Set(table)
+	return nil
+}
@@ -0,0 +1,69 @@
+/*
+Copyright 2018 Adobe
+All Rights Reserved.
+
+NOTICE: Adobe permits you to use, modify, and distribute this file in
+accordance with the terms of the Adobe license agreement accompanying
+it. If you have received this file from a source other than Adobe,
+then your use, modification, or distribution of it requires the prior
+written permission of Adobe.
+*/
+
+package cmd_test
+
+import (
+	"fmt"
+	"path/filepath"
+	"testing"
+
+	"github.com/adobe/sledgehammer/utils/test"
+)
+
+func TestHistory(t *testing.T) {
+	pathToCreate := test.NewTmpDir(t)
+	test.PrepareLocalRegistries(pathToCreate)
+	defer test.DeleteTmpDir(pathToCreate, t)
+
+	cases := []*test.TestCase{
+		{
+			Name: "Empty history",
+			Steps: []*test.Step{
+				{
+					Cmd: "history -o json",
+					Has: []string{`"history": []`},
+				},
+			},
+		},
+		{
+			Name: "Invalid time",
+			Steps: []*test.Step{
+				{
+					Cmd: "history --since yesterday",
+					Has: []string{"The time yesterday is neither a duration nor a date"},
+				},
+			},
+		},
+		{
+			Name: "Executions are recorded",
+			Steps: []*test.Step{
+				{
+					Cmd: fmt.Sprintf("create registry file %s", filepath.Join(pathToCreate, "real.json")),
+					Has: []string{"Name", "Type", "Maintainer", "real", "file"},
+				},
+				{
+					Cmd: "run real",
+					Has: []string{"Hello from the real tool"},
+				},
+				{
+					Cmd: "history --tool real --since 1h -o json",
+					Has: []string{`"tool": "real"`, `"registry": "real"`, `"exit_code": 0`},
+				},
+				{
+					Cmd: "history --tool foo -o json",
+					Has: []string{`"history": []`},
+				},
+			},
+		},
+	}
+	test.DoTest(t, cases)
+}
@@ -36,6 +36,11 @@ type installCommand struct {
 	version  string
 	force    bool
 	isKit    bool
+	env      []string
+	network  string
+	limits   tool.Limits
+	pull     bool
+	allow    bool
 }
 
 func InstallCommand(cfg *config.Config) *cobra.Command {
@@ -59,15 +64,53 @@ func InstallCommand(cfg *config.Config) *cobra.Command {
 	installCommand.Flags().StringVar(&installCmd.version, "version", "", "The version constraint that should be used (e.g. '^2' to stay on major version 2).")
 	installCommand.Flags().BoolVar(&installCmd.force, "force", false, "True if the installation should be forced. Will overwrite previous installed tools.")
 	installCommand.Flags().BoolVar(&installCmd.isKit, "kit", false, "True if the type is a kit that should be installed")
+	installCommand.Flags().BoolVar(&installCmd.pull, "pull", false, "True if the images of the installed tools should be pulled right away")
+	installCommand.Flags().BoolVar(&installCmd.allow, "allow", false, "Approve the permissions the tool requests without asking, e.g. access to the docker daemon or forwarded credentials")
+	installCommand.Flags().StringVar(&installCmd.network, "network", "", "The network mode of the tool: host, bridge, none or the name of a docker network. Takes precedence over the network of the tool and the global setting")
+	installCommand.Flags().StringVar(&installCmd.limits.CPUs, "cpus", "", "The number of cpus the tool can use, e.g. 1.5. Takes precedence over the limit of the tool and the global setting")
+	installCommand.Flags().StringVar(&installCmd.limits.Memory, "memory", "", "The memory the tool can use, e.g. 2g. Takes precedence over the limit of the tool and the global setting")
+	installCommand.Flags().Int64Var(&installCmd.limits.Pids, "pids", 0, "The number of processes the tool can start. Takes precedence over the limit of the tool and the global setting")
+	installCommand.Flags().StringVar(&installCmd.limits.Timeout, "timeout", "", "The time after which the tool is stopped, e.g. 30m. Takes precedence over the limit of the tool and the global setting")
+	installCommand.Flags().StringSliceVar(&installCmd.env, "env", []string{}, "Environment variables for the tool, either NAME (globs allowed) to pass the variable from the host or NAME=VALUE to set it")
 
 	return installCommand
 }
 
 func (cmd *installCommand) Execute(cfg *config.Config) error {
+	var err error
 	if cmd.isKit {
-		return cmd.InstallKit(cfg)
+		err = cmd.InstallKit(cfg)
+	} else {
+		err = cmd.InstallTool(cfg)
 	}
-	return cmd.InstallTool(cfg)
+	if err != nil || !cmd.pull {
+		return err
+	}
+	return cmd.pullInstalled(cfg)
+}
+
+// pullInstalled will pull the images of the tools that have just been installed
+func (cmd *installCommand) pullInstalled(cfg *config.Config) error {
+	targets := []alias.Alias{{
+		Name:     cmd.alias,
+		Registry: cmd.registry,
+		Tool:     cmd.tool,
+		Version:  cmd.version,
+	}}
+	if cmd.isKit {
+		database, err := cfg.OpenDatabase()
+		if database != nil {
+			defer cfg.CloseDatabase()
+		}
+		if err != nil {
+			return err
+		}
+		targets, err = kitTargets(database, cmd.registry, cmd.tool, cmd.alias)
+		if err != nil {
+			return err
+		}
+	}
+	return Pull(cfg, targets, PullWorkers)
 }
 
 func (cmd *installCommand) InstallKit(cfg *config.Config) error {
@@ -109,6 +152,10 @@ func (cmd *installCommand) InstallKit(cfg *config.Config) error {
 						registry: cmd.registry,
 						tool:     t.Name,
 						version:  t.Version,
+						env:      cmd.env,
+						network:  cmd.network,
+						limits:   cmd.limits,
+						allow:    cmd.allow,
 					}
 					err = c.InstallTool(subCfg)
 					if err != nil {
@@ -127,6 +174,13 @@ func (cmd *installCommand) InstallTool(cfg *config.Config) error {
 	if len(cmd.alias) == 0 {
 		cmd.alias = utils.DecorateExecutable(cmd.tool)
 	}
+	if err := cmd.limits.Validate(); err != nil {
+		return err
+	}
+	var limits *tool.Limits
+	if !cmd.limits.Empty() {
+		limits = &cmd.limits
+	}
 
 	database, err := cfg.OpenDatabase()
 	if err != nil {
@@ -143,6 +197,10 @@ func (cmd *installCommand) InstallTool(cfg *config.Config) error {
 	if err != nil {
 		return err
 	}
+	// the permissions are shown and approved once, runs only ask again if they widen
+	if err := checkPermissions(cfg, config.Database{DB: database}, to, cmd.allow); err != nil {
+		return err
+	}
 	hasAlias, err := aliases.Has(cmd.alias)
 	if err != nil {
 		return err
@@ -173,6 +231,9 @@ func (cmd *installCommand) InstallTool(cfg *config.Config) error {
 		Registry: to.Data().Registry,
 		Tool:     to.Data().Name,
 		Version:  cmd.version,
+		Env:      cmd.env,
+		Network:  cmd.network,
+		Limits:   limits,
 	})
 	if err != nil {
 		return err
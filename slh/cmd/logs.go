@@ -0,0 +1,86 @@
+/*
+Copyright 2018 Adobe
+All Rights Reserved.
+
+NOTICE: Adobe permits you to use, modify, and distribute this file in
+accordance with the terms of the Adobe license agreement accompanying
+it. If you have received this file from a source other than Adobe,
+then your use, modification, or distribution of it requires the prior
+written permission of Adobe.
+*/
+package cmd
+
+import (
+	"github.com/adobe/sledgehammer/slh/cache"
+	"github.com/adobe/sledgehammer/slh/config"
+	"github.com/adobe/sledgehammer/slh/out"
+	"github.com/adobe/sledgehammer/utils"
+	docker "github.com/fsouza/go-dockerclient"
+	"github.com/spf13/cobra"
+)
+
+type logsCmd struct {
+	registry string
+	tool     string
+	follow   bool
+	tail     string
+}
+
+func LogsCommand(cfg *config.Config) *cobra.Command {
+	logsCmd := logsCmd{}
+	logsCommand := &cobra.Command{
+		Use:   "logs <tool>",
+		Short: "Show the logs of a daemon",
+		Long:  "Will show the logs of the daemon containers of the given tool",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			logsCmd.registry, logsCmd.tool = utils.GetRegistryAndTool(args[0])
+			err := logsCmd.Logs(cfg)
+			if err != nil {
+				cmd.SilenceUsage = true
+			}
+			return err
+		},
+	}
+
+	logsCommand.Flags().BoolVarP(&logsCmd.follow, "follow", "f", false, "Will follow the log output")
+	logsCommand.Flags().StringVar(&logsCmd.tail, "tail", "all", "Number of lines to show from the end of the logs")
+
+	return logsCommand
+}
+
+// Logs will write the logs of the daemons of the tool to the output
+func (l *logsCmd) Logs(cfg *config.Config) error {
+	database, err := cfg.OpenDatabase()
+	if err != nil {
+		return err
+	}
+
+	c := cache.New(config.Database{DB: database})
+
+	daemons, err := c.Container.Find(l.registry, l.tool)
+	// the database should not be locked while following the logs
+	cfg.CloseDatabase()
+	if err != nil {
+		return err
+	}
+
+	for _, d := range daemons {
+		err = cfg.Docker.Docker.Logs(docker.LogsOptions{
+			Container:    d.ID,
+			OutputStream: cfg.IO.Out,
+			ErrorStream:  cfg.IO.Err,
+			Stdout:       true,
+			Stderr:       true,
+			Follow:       l.follow,
+			Tail:         l.tail,
+			// daemons are started with a tty
+			RawTerminal: true,
+		})
+		if err != nil {
+			return err
+		}
+	}
+	cfg.Output.Set(out.NewEmpty())
+	return nil
+}
@@ -0,0 +1,185 @@
+/*
+Copyright 2018 Adobe
+All Rights Reserved.
+
+NOTICE: Adobe permits you to use, modify, and distribute this file in
+accordance with the terms of the Adobe license agreement accompanying
+it. If you have received this file from a source other than Adobe,
+then your use, modification, or distribution of it requires the prior
+written permission of Adobe.
+*/
+
+package cmd
+
+import (
+	"errors"
+	"sort"
+	"strings"
+
+	"github.com/adobe/sledgehammer/slh/alias"
+	"github.com/adobe/sledgehammer/slh/cache"
+	"github.com/adobe/sledgehammer/slh/config"
+	"github.com/adobe/sledgehammer/slh/out"
+	"github.com/adobe/sledgehammer/slh/tool"
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+var (
+	// ErrorNoPrunePolicy will be thrown if prune is called without any policy
+	ErrorNoPrunePolicy = errors.New("At least one of --keep, --unselectable or --uninstalled is required")
+)
+
+type pruneCommand struct {
+	policy tool.PrunePolicy
+	dryRun bool
+}
+
+func PruneCommand(cfg *config.Config) *cobra.Command {
+	pruneCmd := pruneCommand{}
+	pruneCommand := &cobra.Command{
+		Use:   "prune",
+		Short: "Remove outdated images of tools",
+		Long:  "Will remove the local images of tools that are outdated according to the given policies",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			err := Prune(cfg, pruneCmd.policy, pruneCmd.dryRun)
+			if err != nil {
+				cmd.SilenceUsage = true
+			}
+			return err
+		},
+	}
+
+	pruneCommand.Flags().IntVar(&pruneCmd.policy.Keep, "keep", 0, "Keep only the given number of newest versions of each tool")
+	pruneCommand.Flags().BoolVar(&pruneCmd.policy.Unselectable, "unselectable", false, "Remove the versions of installed tools that do not match the version constraint of any alias")
+	pruneCommand.Flags().BoolVar(&pruneCmd.policy.Uninstalled, "uninstalled", false, "Remove all versions of tools that are not installed")
+	pruneCommand.Flags().BoolVar(&pruneCmd.dryRun, "dry-run", false, "Only show the images that would be removed")
+
+	return pruneCommand
+}
+
+// Prune will remove all images of tools that are outdated according to the policy.
+// Tools that share an image repository are pruned together, an image is kept if one of the tools keeps it.
+func Prune(cfg *config.Config, policy tool.PrunePolicy, dryRun bool) error {
+	if policy.Empty() {
+		return ErrorNoPrunePolicy
+	}
+
+	database, err := cfg.OpenDatabase()
+	if database != nil {
+		defer cfg.CloseDatabase()
+	}
+	if err != nil {
+		return err
+	}
+
+	tools := tool.New(config.Database{DB: database})
+	aliases := alias.New(config.Database{DB: database})
+	caches := cache.New(config.Database{DB: database})
+
+	_, toolMap, err := tools.List()
+	if err != nil {
+		return err
+	}
+	als, err := aliases.List()
+	if err != nil {
+		return err
+	}
+
+	// group the tools and the constraints of their aliases by image repository
+	repositories := map[string][]tool.Tool{}
+	for _, tls := range toolMap {
+		for _, to := range tls {
+			repository := tool.FullImage(to, "")
+			repositories[repository] = append(repositories[repository], to)
+		}
+	}
+	constraints := map[string][]string{}
+	for _, al := range als {
+		to, err := tools.Get(al.Registry, al.Tool)
+		if err != nil {
+			logrus.WithField("alias", al.Name).Warnln("Could not find the tool of the alias: ", err.Error())
+			continue
+		}
+		repository := tool.FullImage(to, "")
+		constraints[repository] = append(constraints[repository], al.Version)
+	}
+
+	names := []string{}
+	for repository := range repositories {
+		names = append(names, repository)
+	}
+	sort.Strings(names)
+
+	prunable := []tool.Image{}
+	for _, repository := range names {
+		images, err := tool.Images(cfg.Docker, repositories[repository][0])
+		if err != nil {
+			return err
+		}
+		prunable = append(prunable, tool.Prunable(images, constraints[repository], policy)...)
+	}
+
+	table := out.NewTable("Pruned", "Image", "ID", "Size", "Status")
+	freed := int64(0)
+	if dryRun {
+		for _, image := range prunable {
+			table.Add(image.Name(), shortImageID(image.ID), out.ByteSize(image.Size), "would be removed")
+		}
+		freed = releasedSize(prunable)
+	} else {
+		for _, image := range prunable {
+			size, err := tool.RemoveImage(cfg.Docker, image)
+			if err != nil {
+				table.Add(image.Name(), shortImageID(image.ID), out.ByteSize(image.Size), err.Error())
+				cfg.Output.ExitCode = 1
+				continue
+			}
+			freed += size
+			table.Add(image.Name(), shortImageID(image.ID), out.ByteSize(image.Size), "removed")
+		}
+		// the removed versions must not be selected anymore
+		for _, repository := range names {
+			for _, to := range repositories[repository] {
+				caches.Versions.ClearLocal(to)
+			}
+		}
+	}
+
+	ct := out.NewContainer("prune")
+	ct.Add(table)
+	ct.Add(out.NewEmpty())
+	ct.Add(out.NewValue("Freed", out.ByteSize(freed)))
+	cfg.Output.Set(ct)
+	return nil
+}
+
+// releasedSize will return the size of all images that would be removed completely, because all of their tags are removed
+func releasedSize(images []tool.Image) int64 {
+	removed := map[string]bool{}
+	for _, image := range images {
+		removed[image.Name()] = true
+	}
+	size := int64(0)
+	counted := map[string]bool{}
+	for _, image := range images {
+		if counted[image.ID] {
+			continue
+		}
+		all := true
+		for _, tag := range image.Tags {
+			all = all && removed[tag]
+		}
+		if all {
+			size += image.Size
+			counted[image.ID] = true
+		}
+	}
+	return size
+}
+
+// shortImageID will return the short form of an image id as shown by docker
+func shortImageID(id string) string {
+	return shortID(strings.TrimPrefix(id, "sha256:"))
+}
@@ -0,0 +1,61 @@
+/*
+Copyright 2018 Adobe
+All Rights Reserved.
+
+NOTICE: Adobe permits you to use, modify, and distribute this file in
+accordance with the terms of the Adobe license agreement accompanying
+it. If you have received this file from a source other than Adobe,
+then your use, modification, or distribution of it requires the prior
+written permission of Adobe.
+*/
+
+package cmd_test
+
+import (
+	"fmt"
+	"path/filepath"
+	"testing"
+
+	"github.com/adobe/sledgehammer/slh/cmd"
+	"github.com/adobe/sledgehammer/utils/test"
+)
+
+func TestPrune(t *testing.T) {
+	pathToCreate := test.NewTmpDir(t)
+	test.PrepareLocalRegistries(pathToCreate)
+	defer test.DeleteTmpDir(pathToCreate, t)
+
+	cases := []*test.TestCase{
+		{
+			Name: "No policy",
+			Steps: []*test.Step{
+				{
+					Cmd: "prune",
+					Has: []string{cmd.ErrorNoPrunePolicy.Error()},
+				},
+			},
+		},
+		{
+			Name: "Dry run",
+			Steps: []*test.Step{
+				{
+					Cmd: fmt.Sprintf("create registry file %s", filepath.Join(pathToCreate, "real.json")),
+					Has: []string{"Name", "Type", "Maintainer", "real", "file"},
+				},
+				{
+					Cmd: "run alpine-test-version --version 3.7",
+					Has: []string{"3.7."},
+				},
+				{
+					Cmd: "prune --uninstalled --dry-run",
+					Has: []string{"alpine:3.7", "would be removed", "Freed"},
+				},
+				{
+					Cmd: "run alpine-test-version --version 3.7 --offline",
+					Has: []string{"3.7."},
+				},
+			},
+		},
+	}
+	test.DoTest(t, cases)
+}
@@ -0,0 +1,283 @@
+/*
+Copyright 2018 Adobe
+All Rights Reserved.
+
+NOTICE: Adobe permits you to use, modify, and distribute this file in
+accordance with the terms of the Adobe license agreement accompanying
+it. If you have received this file from a source other than Adobe,
+then your use, modification, or distribution of it requires the prior
+written permission of Adobe.
+*/
+
+package cmd
+
+import (
+	"errors"
+	"sync"
+
+	"github.com/adobe/sledgehammer/slh/alias"
+	"github.com/adobe/sledgehammer/slh/cache"
+	"github.com/adobe/sledgehammer/slh/config"
+	"github.com/adobe/sledgehammer/slh/kit"
+	"github.com/adobe/sledgehammer/slh/out"
+	"github.com/adobe/sledgehammer/slh/registry"
+	"github.com/adobe/sledgehammer/slh/tool"
+	"github.com/adobe/sledgehammer/slh/version"
+	"github.com/adobe/sledgehammer/utils"
+	bolt "github.com/coreos/bbolt"
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+var (
+	// PullWorkers is the default number of images that are pulled concurrently
+	PullWorkers = 4
+	// ErrorPullTarget will be thrown if not exactly one of a tool, a kit or --installed is given
+	ErrorPullTarget = errors.New("Either a tool, a kit or --installed is required")
+)
+
+const (
+	pullStatusPulled   = "pulled"
+	pullStatusUpToDate = "up to date"
+)
+
+type pullCommand struct {
+	registry  string
+	name      string
+	version   string
+	isKit     bool
+	installed bool
+	workers   int
+}
+
+func PullCommand(cfg *config.Config) *cobra.Command {
+	pullCmd := pullCommand{}
+	pullCommand := &cobra.Command{
+		Use:   "pull [tool|kit]",
+		Short: "Pull the images of tools",
+		Long:  "Will pull the images of a tool, the tools of a kit or all installed tools, so that their first run does not wait for the pull",
+		Args:  cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if len(args) == 1 {
+				pullCmd.registry, pullCmd.name = utils.GetRegistryAndTool(args[0])
+			}
+			err := pullCmd.Execute(cfg)
+			if err != nil {
+				cmd.SilenceUsage = true
+			}
+			return err
+		},
+	}
+
+	pullCommand.Flags().StringVar(&pullCmd.version, "version", "", "The version constraint that should be pulled (e.g. '^2' to pull the latest major version 2).")
+	pullCommand.Flags().BoolVar(&pullCmd.isKit, "kit", false, "True if the tools of a kit should be pulled")
+	pullCommand.Flags().BoolVar(&pullCmd.installed, "installed", false, "True if the images of all installed tools should be pulled")
+	pullCommand.Flags().IntVar(&pullCmd.workers, "workers", PullWorkers, "The number of images that are pulled concurrently")
+
+	return pullCommand
+}
+
+// Execute will pull the images of the tools selected by the command
+func (p *pullCommand) Execute(cfg *config.Config) error {
+	if (len(p.name) == 0) != p.installed {
+		return ErrorPullTarget
+	}
+
+	database, err := cfg.OpenDatabase()
+	if database != nil {
+		defer cfg.CloseDatabase()
+	}
+	if err != nil {
+		return err
+	}
+
+	var targets []alias.Alias
+	switch {
+	case p.installed:
+		targets, err = alias.New(config.Database{DB: database}).List()
+	case p.isKit:
+		targets, err = kitTargets(database, p.registry, p.name, "")
+	default:
+		targets = []alias.Alias{{Registry: p.registry, Tool: p.name, Version: p.version}}
+	}
+	if err != nil {
+		return err
+	}
+	return Pull(cfg, targets, p.workers)
+}
+
+// kitTargets will return the tools of the given kit as aliases, prefixed like they would be installed
+func kitTargets(db *bolt.DB, registryName string, name string, prefix string) ([]alias.Alias, error) {
+	regs, err := registry.New(config.Database{DB: db}).List()
+	if err != nil {
+		return nil, err
+	}
+	for _, reg := range regs {
+		if len(registryName) > 0 && registryName != reg.Data().Name {
+			continue
+		}
+		kits, err := reg.Kits()
+		if err != nil {
+			return nil, err
+		}
+		for _, k := range kits {
+			if k.Name != name {
+				continue
+			}
+			targets := []alias.Alias{}
+			for _, t := range k.Tools {
+				aliasName := t.Alias
+				if len(aliasName) == 0 {
+					aliasName = t.Name
+				}
+				targets = append(targets, alias.Alias{
+					Name:     prefix + aliasName,
+					Registry: reg.Data().Name,
+					Tool:     t.Name,
+					Version:  t.Version,
+				})
+			}
+			return targets, nil
+		}
+	}
+	return nil, kit.ErrorKitNotFound
+}
+
+// pullResult is the outcome of pulling the image for a single alias
+type pullResult struct {
+	alias   alias.Alias
+	tool    tool.Tool
+	version string
+	status  string
+	err     error
+}
+
+func (r *pullResult) image() string {
+	if r.tool == nil {
+		return ""
+	}
+	return tool.FullImage(r.tool, r.version)
+}
+
+// Pull will pull the images of the given aliases with at most the given number of concurrent workers.
+// The version constraint of each alias is resolved against the remote versions, images that are already present are skipped.
+func Pull(cfg *config.Config, targets []alias.Alias, workers int) error {
+	if cfg.Offline {
+		return ErrorOffline
+	}
+	if workers < 1 {
+		workers = 1
+	}
+
+	database, err := cfg.OpenDatabase()
+	if database != nil {
+		defer cfg.CloseDatabase()
+	}
+	if err != nil {
+		return err
+	}
+
+	tools := tool.New(config.Database{DB: database})
+	caches := cache.New(config.Database{DB: database})
+
+	results := make([]*pullResult, len(targets))
+	parallel(len(targets), workers, func(i int) {
+		results[i] = resolvePull(cfg.Docker, tools, caches, targets[i])
+	})
+
+	// every image is pulled only once, even if multiple aliases use it
+	images := []string{}
+	pulls := map[string]*pullResult{}
+	for _, r := range results {
+		if r.err != nil || len(r.status) > 0 {
+			continue
+		}
+		if _, found := pulls[r.image()]; !found {
+			images = append(images, r.image())
+			pulls[r.image()] = r
+			caches.Versions.Clear(r.tool)
+		}
+	}
+
+	// close the database during the pulls, otherwise all other invocations would be blocked
+	cfg.CloseDatabase()
+
+	errs := make([]error, len(images))
+	parallel(len(images), workers, func(i int) {
+		r := pulls[images[i]]
+		errs[i] = tool.Pull(cfg.Docker, r.tool, r.version, DefaultTimeout, cfg.Output)
+	})
+	pullErrors := map[string]error{}
+	for i, image := range images {
+		pullErrors[image] = errs[i]
+	}
+
+	table := out.NewTable("Pulled", "Alias", "Tool", "Registry", "Version", "Image", "Status")
+	for _, r := range results {
+		if len(r.status) == 0 && r.err == nil {
+			r.err = pullErrors[r.image()]
+			r.status = pullStatusPulled
+		}
+		status := r.status
+		if r.err != nil {
+			status = r.err.Error()
+			cfg.Output.ExitCode = 1
+		}
+		table.Add(r.alias.Name, r.alias.Tool, r.alias.Registry, r.version, r.image(), status)
+	}
+	cfg.Output.Set(table)
+	return nil
+}
+
+// resolvePull will select the version of the given alias that should be pulled
+func resolvePull(client config.Docker, tools *tool.Tools, caches *cache.Cache, target alias.Alias) *pullResult {
+	result := &pullResult{alias: target, version: target.Version}
+	to, err := tools.Get(target.Registry, target.Tool)
+	if err != nil {
+		result.err = err
+		return result
+	}
+	result.tool = to
+	result.alias.Registry = to.Data().Registry
+
+	remoteVersions, err := caches.Versions.Remote(to)
+	if err != nil {
+		result.err = err
+		return result
+	}
+	result.version = version.Select(remoteVersions, target.Version)
+	if len(result.version) == 0 {
+		result.version = target.Version
+		result.err = ErrorNoVersionFound
+		return result
+	}
+
+	localVersions, err := caches.Versions.Local(to, client)
+	if err != nil {
+		logrus.WithField("tool", to.Data().Name).Warnln("Could not fetch local versions: ", err.Error())
+	}
+	if version.Has(localVersions, result.version) {
+		result.status = pullStatusUpToDate
+	}
+	return result
+}
+
+// parallel will call the given function for each index with at most the given number of concurrent workers
+func parallel(n int, workers int, fn func(i int)) {
+	indexes := make(chan int)
+	wg := sync.WaitGroup{}
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range indexes {
+				fn(i)
+			}
+		}()
+	}
+	for i := 0; i < n; i++ {
+		indexes <- i
+	}
+	close(indexes)
+	wg.Wait()
+}
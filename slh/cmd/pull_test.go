@@ -0,0 +1,128 @@
+/*
+Copyright 2018 Adobe
+All Rights Reserved.
+
+NOTICE: Adobe permits you to use, modify, and distribute this file in
+accordance with the terms of the Adobe license agreement accompanying
+it. If you have received this file from a source other than Adobe,
+then your use, modification, or distribution of it requires the prior
+written permission of Adobe.
+*/
+
+package cmd_test
+
+import (
+	"fmt"
+	"path/filepath"
+	"testing"
+
+	"github.com/adobe/sledgehammer/slh/cmd"
+	"github.com/adobe/sledgehammer/slh/kit"
+	"github.com/adobe/sledgehammer/utils/test"
+)
+
+func TestPull(t *testing.T) {
+	pathToCreate := test.NewTmpDir(t)
+	test.PrepareLocalRegistries(pathToCreate)
+	defer test.DeleteTmpDir(pathToCreate, t)
+
+	cases := []*test.TestCase{
+		{
+			Name: "No target",
+			Steps: []*test.Step{
+				{
+					Cmd: "pull",
+					Has: []string{cmd.ErrorPullTarget.Error()},
+				},
+			},
+		},
+		{
+			Name: "Tool and installed tools",
+			Steps: []*test.Step{
+				{
+					Cmd: "pull real --installed",
+					Has: []string{cmd.ErrorPullTarget.Error()},
+				},
+			},
+		},
+		{
+			Name: "Kit not found",
+			Steps: []*test.Step{
+				{
+					Cmd: "pull foo --kit",
+					Has: []string{kit.ErrorKitNotFound.Error()},
+				},
+			},
+		},
+		{
+			Name: "Offline",
+			Steps: []*test.Step{
+				{
+					Cmd: "pull real --offline",
+					Has: []string{cmd.ErrorOffline.Error()},
+				},
+			},
+		},
+		{
+			Name: "Tool not available",
+			Steps: []*test.Step{
+				{
+					Cmd: "pull foobar",
+					Has: []string{"foobar", cmd.ErrorToolNotFound.Error()},
+				},
+			},
+		},
+		{
+			Name: "Pull a tool",
+			Steps: []*test.Step{
+				{
+					Cmd: fmt.Sprintf("create registry file %s", filepath.Join(pathToCreate, "real.json")),
+					Has: []string{"Name", "Type", "Maintainer", "real", "file"},
+				},
+				{
+					Cmd: "pull alpine-test-version --version 3.7 -o json",
+					Has: []string{`"tool": "alpine-test-version"`, `"image": "alpine:3.7`},
+				},
+			},
+		},
+		{
+			Name: "Pull installed tools",
+			Steps: []*test.Step{
+				{
+					Cmd: fmt.Sprintf("create registry file %s", filepath.Join(pathToCreate, "real.json")),
+					Has: []string{"Name", "Type", "Maintainer", "real", "file"},
+				},
+				{
+					Cmd: "install real --alias real-alias --version 3.7 -o json",
+					Has: []string{"success"},
+				},
+				{
+					Cmd: "pull --installed",
+					Has: []string{"real-alias", "alpine:3.7"},
+				},
+				{
+					Cmd: "reset real-alias -o json",
+					Has: []string{"success"},
+				},
+			},
+		},
+		{
+			Name: "Install and pull",
+			Steps: []*test.Step{
+				{
+					Cmd: fmt.Sprintf("create registry file %s", filepath.Join(pathToCreate, "real.json")),
+					Has: []string{"Name", "Type", "Maintainer", "real", "file"},
+				},
+				{
+					Cmd: "install real --alias real-alias --version 3.7 --pull",
+					Has: []string{"real-alias", "alpine:3.7"},
+				},
+				{
+					Cmd: "reset real-alias -o json",
+					Has: []string{"success"},
+				},
+			},
+		},
+	}
+	test.DoTest(t, cases)
+}
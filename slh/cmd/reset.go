@@ -16,7 +16,6 @@ import (
 	"github.com/adobe/sledgehammer/slh/cache"
 	"github.com/adobe/sledgehammer/slh/config"
 	"github.com/adobe/sledgehammer/slh/out"
-	"github.com/fsouza/go-dockerclient"
 	"github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
 )
@@ -86,15 +85,13 @@ func (cmd *resetCmd) ResetAll(cfg *config.Config) error {
 		}
 	}
 
-	for tool, container := range daemons {
-		logrus.WithField("id", container).WithField("image", tool).Debugln("Removing container for image")
-		cfg.Docker.Docker.RemoveContainer(docker.RemoveContainerOptions{
-			Force: true,
-			ID:    container,
-		})
+	for _, daemon := range daemons {
+		logrus.WithField("id", daemon.ID).WithField("image", daemon.Image).Debugln("Removing container for image")
+		err = c.Container.Stop(cfg.Docker, daemon)
 		if err != nil {
 			return err
 		}
+		removeForwarding(cfg, daemon)
 	}
 	cfg.Output.Set(out.NewSuccess())
 	return nil
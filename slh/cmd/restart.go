@@ -0,0 +1,28 @@
+/*
+Copyright 2018 Adobe
+All Rights Reserved.
+
+NOTICE: Adobe permits you to use, modify, and distribute this file in
+accordance with the terms of the Adobe license agreement accompanying
+it. If you have received this file from a source other than Adobe,
+then your use, modification, or distribution of it requires the prior
+written permission of Adobe.
+*/
+package cmd
+
+import (
+	"github.com/adobe/sledgehammer/slh/config"
+	"github.com/spf13/cobra"
+)
+
+func RestartCommand(cfg *config.Config) *cobra.Command {
+	restartCommand := &cobra.Command{
+		Use:   "restart",
+		Short: "Restart a resource",
+		Long:  "Will restart a running ressource of Sledgehammer",
+	}
+
+	restartCommand.AddCommand(RestartDaemonCommand(cfg))
+
+	return restartCommand
+}
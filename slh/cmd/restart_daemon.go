@@ -0,0 +1,97 @@
+/*
+Copyright 2018 Adobe
+All Rights Reserved.
+
+NOTICE: Adobe permits you to use, modify, and distribute this file in
+accordance with the terms of the Adobe license agreement accompanying
+it. If you have received this file from a source other than Adobe,
+then your use, modification, or distribution of it requires the prior
+written permission of Adobe.
+*/
+package cmd
+
+import (
+	"github.com/adobe/sledgehammer/slh/alias"
+	"github.com/adobe/sledgehammer/slh/cache"
+	"github.com/adobe/sledgehammer/slh/config"
+	"github.com/adobe/sledgehammer/slh/tool"
+	"github.com/adobe/sledgehammer/utils"
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+func RestartDaemonCommand(cfg *config.Config) *cobra.Command {
+	restartDaemonCommand := &cobra.Command{
+		Use:     "daemon <tool>",
+		Short:   "Restarts the daemon of a tool",
+		Long:    "Will stop all daemon containers of the given tool and start them again with the same version",
+		Aliases: []string{"daemons", "da"},
+		Args:    cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			registry, tool := utils.GetRegistryAndTool(args[0])
+			err := RestartDaemon(cfg, registry, tool)
+			if err != nil {
+				cmd.SilenceUsage = true
+			}
+			return err
+		},
+	}
+	return restartDaemonCommand
+}
+
+// RestartDaemon will restart all daemons of the given tool
+func RestartDaemon(cfg *config.Config, registry string, name string) error {
+	database, err := cfg.OpenDatabase()
+	if database != nil {
+		defer cfg.CloseDatabase()
+	}
+	if err != nil {
+		return err
+	}
+
+	c := cache.New(config.Database{DB: database})
+	tools := tool.New(config.Database{DB: database})
+	aliases := alias.New(config.Database{DB: database})
+
+	daemons, err := c.Container.Find(registry, name)
+	if err != nil {
+		return err
+	}
+	for _, d := range daemons {
+		to, err := tools.Get(d.Registry, d.Tool)
+		if err != nil {
+			return err
+		}
+		if err := checkPermissions(cfg, config.Database{DB: database}, to, false); err != nil {
+			return err
+		}
+		err = c.Container.Stop(cfg.Docker, d)
+		if err != nil {
+			return err
+		}
+		removeForwarding(cfg, d)
+		// the daemon is started with the same options as by the next run of the tool or its alias
+		r := RunCmd{}
+		if len(d.Alias) > 0 {
+			al, err := aliases.Get(d.Alias)
+			if err == alias.ErrorNotFound {
+				logrus.WithField("alias", d.Alias).Infoln("Alias has been removed, the daemon is not started again")
+				continue
+			}
+			if err != nil {
+				return err
+			}
+			r = aliasRunCmd(al, nil)
+		}
+		opt, _, err := r.executionOptions(cfg, database, to)
+		if err != nil {
+			return err
+		}
+		opt.Version = d.Version
+		_, err = c.Container.Get(opt)
+		if err != nil {
+			return err
+		}
+	}
+	return GetDaemons(cfg.WithDatabase(database))
+}
@@ -16,10 +16,14 @@ import (
 	"io/ioutil"
 	"os"
 	"path/filepath"
+	"strconv"
+	"time"
 
 	"github.com/adobe/sledgehammer/utils"
 
 	"github.com/adobe/sledgehammer/slh/config"
+	"github.com/adobe/sledgehammer/slh/profile"
+	"github.com/adobe/sledgehammer/slh/settings"
 	"github.com/adobe/sledgehammer/slh/version"
 	"github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
@@ -36,7 +40,13 @@ Use dockerized tools like they are installed on the  system.`,
 	}
 
 	var logLevel string
+	var profiling bool
 	rootCommand.PersistentPreRunE = func(cmd *cobra.Command, args []string) error {
+		if err := configureProfile(cfg, profiling); err != nil {
+			return err
+		}
+		defer cfg.Profile.Span("initialize")()
+
 		if err := validateOutputType(cfg.OutputType); err != nil {
 			return err
 		}
@@ -49,6 +59,20 @@ Use dockerized tools like they are installed on the  system.`,
 		if err := setConfigDir(cfg); err != nil {
 			return err
 		}
+		if err := configureOffline(cfg, cmd.Flags().Changed("offline")); err != nil {
+			return err
+		}
+		if cfg.Offline {
+			// the default registry needs to be cloned, the first run that is online initializes Sledgehammer
+			initialized, err := utils.Initialized(cfg)
+			if err != nil {
+				return err
+			}
+			if !initialized {
+				fmt.Fprintln(cfg.IO.Err, "Sledgehammer is offline, the default registry will be added with the first run that is online")
+			}
+			return nil
+		}
 		shouldInit, err := utils.ShouldInitialize(cfg)
 		if err != nil {
 			return err
@@ -67,6 +91,8 @@ Use dockerized tools like they are installed on the  system.`,
 	rootCommand.PersistentFlags().StringVarP(&cfg.OutputType, "output", "o", "text", "Define the output, currently supported is none|text|json")
 	rootCommand.PersistentFlags().StringVar(&logLevel, "log-level", "none", "Set the log level (debug|info|warning|error|fatal|panic)")
 	rootCommand.PersistentFlags().StringVar(&cfg.ConfigDir, "confdir", cfg.ConfigDir, "Location of configuration directory. Default is bindir/.slh")
+	rootCommand.PersistentFlags().BoolVar(&profiling, "profile", false, "Print a timing breakdown of the invocation to stderr. Can also be set with "+ProfileEnv)
+	rootCommand.PersistentFlags().BoolVar(&cfg.Offline, "offline", false, "Never access the network, tools are only run from local images. Can also be set with "+OfflineEnv)
 
 	// add all other commands
 	rootCommand.AddCommand(GetCommand(cfg))
@@ -78,6 +104,15 @@ Use dockerized tools like they are installed on the  system.`,
 	rootCommand.AddCommand(RunCommand(cfg))
 	rootCommand.AddCommand(RunAliasCommand(cfg))
 	rootCommand.AddCommand(UpdateCommand(cfg))
+	rootCommand.AddCommand(SetCommand(cfg))
+	rootCommand.AddCommand(StopCommand(cfg))
+	rootCommand.AddCommand(RestartCommand(cfg))
+	rootCommand.AddCommand(LogsCommand(cfg))
+	rootCommand.AddCommand(GCCommand(cfg))
+	rootCommand.AddCommand(PullCommand(cfg))
+	rootCommand.AddCommand(PruneCommand(cfg))
+	rootCommand.AddCommand(HistoryCommand(cfg))
+	rootCommand.AddCommand(AllowCommand(cfg))
 
 	rootCommand.SetOutput(cfg.IO.Out)
 
@@ -94,7 +129,13 @@ Use dockerized tools like they are installed on the  system.`,
 // Execute will execute the root command
 func Execute(cfg *config.Config) {
 	cmd := CreateRootCommand(cfg)
-	if err := cmd.Execute(); err != nil {
+	err := cmd.Execute()
+	// the breakdown is written to stderr, so it never mixes with the output of a tool
+	cfg.Profile.Write(cfg.IO.Err, cfg.OutputType == "json")
+	if coded, ok := err.(exitCoder); ok {
+		os.Exit(coded.ExitCode())
+	}
+	if err != nil {
 		os.Exit(1)
 	}
 	if cfg.Output != nil {
@@ -102,6 +143,11 @@ func Execute(cfg *config.Config) {
 	}
 }
 
+// exitCoder is implemented by errors that Sledgehammer exits with a specific code for, e.g. if a tool reached its timeout
+type exitCoder interface {
+	ExitCode() int
+}
+
 func configureOutput(cfg *config.Config) error {
 	if cfg.Output == nil {
 		cfg.Output = config.NewOutput(cfg)
@@ -109,6 +155,53 @@ func configureOutput(cfg *config.Config) error {
 	return nil
 }
 
+// configureProfile will enable the timing breakdown if requested by the flag or the environment variable
+func configureProfile(cfg *config.Config, flag bool) error {
+	if cfg.Profile != nil {
+		return nil
+	}
+	enabled := flag
+	if value, found := os.LookupEnv(ProfileEnv); found && !flag {
+		var err error
+		enabled, err = strconv.ParseBool(value)
+		if err != nil {
+			return fmt.Errorf("The profile setting %s is not a boolean", value)
+		}
+	}
+	if enabled {
+		cfg.Profile = profile.New()
+		cfg.Profile.Add("startup", cfg.Profile.Start, time.Now())
+	}
+	return nil
+}
+
+// configureOffline will decide if Sledgehammer runs in offline mode.
+// The flag takes precedence over the environment variable, which takes precedence over the setting.
+func configureOffline(cfg *config.Config, flagSet bool) error {
+	if flagSet {
+		return nil
+	}
+	value, found := os.LookupEnv(OfflineEnv)
+	if !found {
+		db, err := cfg.OpenDatabaseReadOnly()
+		if err != nil {
+			return err
+		}
+		defer cfg.CloseDatabase()
+		value, found, err = settings.New(config.Database{DB: db}).Get(settings.Offline)
+		if err != nil || !found {
+			return err
+		}
+	}
+	offline, err := strconv.ParseBool(value)
+	if err != nil {
+		return fmt.Errorf("The offline mode %s is not a boolean", value)
+	}
+	cfg.Offline = offline
+	logrus.WithField("offline", offline).Info("Configured offline mode")
+	return nil
+}
+
 func validateOutputType(output string) error {
 	validOutputs := []string{"none", "text", "json"}
 	match := false
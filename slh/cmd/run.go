@@ -13,17 +13,28 @@ package cmd
 
 import (
 	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
 	"time"
 
+	"github.com/docker/docker/pkg/homedir"
 	"github.com/fsouza/go-dockerclient"
 
 	"github.com/adobe/sledgehammer/slh/cache"
 
 	"github.com/adobe/sledgehammer/slh/config"
+	"github.com/adobe/sledgehammer/slh/environment"
+	"github.com/adobe/sledgehammer/slh/forward"
+	"github.com/adobe/sledgehammer/slh/history"
 	"github.com/adobe/sledgehammer/slh/mount"
+	"github.com/adobe/sledgehammer/slh/out"
+	"github.com/adobe/sledgehammer/slh/settings"
 	"github.com/adobe/sledgehammer/slh/tool"
 	"github.com/adobe/sledgehammer/slh/version"
 	"github.com/adobe/sledgehammer/utils"
+	"github.com/adobe/sledgehammer/utils/db"
 	bolt "github.com/coreos/bbolt"
 	"github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
@@ -34,14 +45,52 @@ var (
 	ErrorNoVersionFound = errors.New("Could not find a version to run")
 	// DefaultTimeout is the timeout used to pull an image
 	DefaultTimeout = 300 * time.Second
+	// IdentityDir is the directory below the configuration directory the passwd and group files of the user are written to
+	IdentityDir = "identity"
+	// ForwardDir is the directory below the configuration directory the forwarded credentials are written to while a tool uses them
+	ForwardDir = "forward"
+	// ErrorOffline will be thrown if something is requested that needs the network while Sledgehammer is offline
+	ErrorOffline = errors.New("Sledgehammer is offline, but network access is required")
+	// OfflineEnv is the environment variable that enables the offline mode
+	OfflineEnv = "SLH_OFFLINE"
+	// ProfileEnv is the environment variable that enables the timing breakdown
+	ProfileEnv = "SLH_PROFILE"
+	// MountsEnv is the environment variable with additional mounts for a single run, separated like the PATH
+	MountsEnv = "SLH_MOUNTS"
+	// MountWorkDirEnv is the environment variable that mounts the working directory for a single run
+	MountWorkDirEnv = "SLH_MOUNT_WORKDIR"
 )
 
+// OfflineError will be thrown if no local image matches the version of a tool while Sledgehammer is offline
+type OfflineError struct {
+	Tool    string
+	Version string
+	Remote  string
+}
+
+func (e *OfflineError) Error() string {
+	msg := fmt.Sprintf("No local image of tool '%s' matches the version '%s' and Sledgehammer is offline", e.Tool, e.Version)
+	if len(e.Remote) > 0 {
+		msg += fmt.Sprintf(", the last known remote version is %s", e.Remote)
+	}
+	return msg
+}
+
 type RunCmd struct {
 	registry  string
 	tool      string
 	version   string
 	arguments []string
 	update    bool
+	env       []string
+	alias     string
+	network   string
+	limits    tool.Limits
+	output    *out.Output
+	offline   bool
+	// mountWorkDir will mount the working directory for this run, only used if mountWorkDirSet is true
+	mountWorkDir    bool
+	mountWorkDirSet bool
 }
 
 func RunCommand(cfg *config.Config) *cobra.Command {
@@ -53,6 +102,7 @@ func RunCommand(cfg *config.Config) *cobra.Command {
 		Args:  cobra.MinimumNArgs(1),
 		RunE: func(cmd *cobra.Command, args []string) error {
 			runCmd.registry, runCmd.tool = utils.GetRegistryAndTool(args[0])
+			runCmd.mountWorkDirSet = cmd.Flags().Changed("mount-workdir")
 			err := runCmd.Execute(cfg)
 			if err != nil {
 				cmd.SilenceUsage = true
@@ -64,6 +114,8 @@ func RunCommand(cfg *config.Config) *cobra.Command {
 	runCommand.Flags().BoolVar(&runCmd.update, "update", false, "Will force the download of a newer remote image if available before running the tool")
 	runCommand.Flags().StringVar(&runCmd.version, "version", "", "The version constraint that the tool should fulfill")
 	runCommand.Flags().StringSliceVarP(&runCmd.arguments, "arguments", "a", []string{}, "The arguments to pass to the tool")
+	runCommand.Flags().StringVar(&runCmd.network, "network", "", "The network mode of the container: host, bridge, none or the name of a docker network")
+	runCommand.Flags().BoolVar(&runCmd.mountWorkDir, "mount-workdir", false, "Mount the working directory for this run if it is not part of a mount. Can also be set with "+MountWorkDirEnv)
 
 	runCommand.Hidden = true
 
@@ -74,42 +126,77 @@ func RunCommand(cfg *config.Config) *cobra.Command {
 func (r *RunCmd) Execute(cfg *config.Config) error {
 
 	var exitCode int
-
-	database, err := cfg.OpenDatabase()
+	start := time.Now()
+	r.output = cfg.Output
+	r.offline = cfg.Offline
+
+	// parallel invocations only read, the writes to the cache are deferred until the database is closed
+	span := cfg.Profile.Span("open database")
+	database, err := cfg.OpenDatabaseReadOnly()
+	span()
+	if database != nil {
+		defer cfg.CloseDatabase()
+	}
 	if err != nil {
 		return err
 	}
 
 	// get tool
 	tools := tool.New(config.Database{DB: database})
-	mounts := mount.New(config.Database{DB: database})
-	caches := cache.New(config.Database{DB: database})
+	caches := cache.New(cfg.Database())
 
+	span = cfg.Profile.Span("resolve tool")
 	to, err := tools.Get(r.registry, r.tool)
 	if err != nil {
 		return err
 	}
-	mos, err := mounts.List()
+	if err := checkPermissions(cfg, cfg.Database(), to, false); err != nil {
+		return err
+	}
+	executionOptions, forwarding, err := r.executionOptions(cfg, database, to)
 	if err != nil {
 		return err
 	}
+	if len(forwarding.Dir) > 0 && to.Data().Daemon == nil {
+		defer os.RemoveAll(forwarding.Dir)
+	}
+	span()
 
 	pullDone := make(chan error, 1)
 	closeDB := make(chan bool, 1)
 
-	version, err := r.selectVersion(cfg.Docker, database, to, pullDone, closeDB)
+	span = cfg.Profile.Span("select version")
+	version, err := r.selectVersion(cfg.Docker, cfg.Database(), to, pullDone, closeDB)
+	span()
 	if err != nil {
 		return err
 	}
+	executionOptions.Version = version
 
-	containerID, err := caches.Container.Get(to, version, cfg, mos)
+	span = cfg.Profile.Span("prepare daemon")
+	containerID, err := caches.Container.Get(executionOptions)
+	span()
 	if err != nil {
 		return err
 	}
+	span = cfg.Profile.Span("reap daemons")
+	reaped, err := caches.Container.Reap(cfg.Docker, executionOptions)
+	if err != nil {
+		logrus.Warnln("Could not reap idle daemons: ", err.Error())
+	}
+	for _, d := range reaped {
+		removeForwarding(cfg, d)
+	}
+	span()
+	gcDone := r.collect(cfg.Docker, executionOptions.Installation, caches, containerID)
 	<-closeDB
 	logrus.Info("Closing database")
 	// close db
-	cfg.CloseDatabase()
+	span = cfg.Profile.Span("close database")
+	if err := cfg.CloseDatabase(); err != nil {
+		logrus.Warnln("Could not update the cache: ", err.Error())
+	}
+	span()
 
 	if len(version) == 0 {
 		return ErrorNoVersionFound
@@ -120,18 +207,15 @@ func (r *RunCmd) Execute(cfg *config.Config) error {
 		arguments = []string{}
 	}
 
-	executionOptions := &tool.ExecutionOptions{
-		IO:        cfg.IO,
-		Docker:    &cfg.Docker,
-		Tool:      to,
-		Version:   version,
-		Arguments: r.arguments,
-		Mounts:    mos,
-	}
-
+	// a tool that exceeded its limits has been executed, it is recorded with the exit code of the error
+	var limitErr error
+	span = cfg.Profile.Span("execute")
 	if containerID == "" {
 		logrus.Info("Starting and executing tool")
 		exitCode, err = tool.StartAndExecute(executionOptions)
+		if _, ok := err.(exitCoder); ok {
+			limitErr, err = err, nil
+		}
 		if err != nil {
 			return err
 		}
@@ -139,6 +223,9 @@ func (r *RunCmd) Execute(cfg *config.Config) error {
 		logrus.Debug("Tool has been started as daemon, executing")
 		exitCode, err = tool.Execute(containerID, executionOptions)
 		logrus.Debugln("executing done...")
+		if _, ok := err.(exitCoder); ok {
+			limitErr, err = err, nil
+		}
 		if err != nil {
 			if _, ok := err.(*docker.NoSuchContainer); ok {
 				// container not found, clear cache for this entry and start daemon
@@ -149,12 +236,12 @@ func (r *RunCmd) Execute(cfg *config.Config) error {
 				}
 				defer cfg.CloseDatabase()
 				caches := cache.New(config.Database{DB: database})
-				caches.Container.Clear(to, version)
-				containerID, err := caches.Container.Get(to, version, cfg, mos)
+				caches.Container.Clear(to, version, r.alias)
+				containerID, err := caches.Container.Get(executionOptions)
 				if err != nil {
 					return err
 				}
-				database.Close()
+				cfg.CloseDatabase()
 				exitCode, err = tool.Execute(containerID, executionOptions)
 				if err != nil {
 					return err
@@ -165,23 +252,103 @@ func (r *RunCmd) Execute(cfg *config.Config) error {
 		}
 	}
 
+	span()
+
 	// Wait until potential pulls are done, otherwise the pull will stop midexecution -> race condition
+	span = cfg.Profile.Span("wait for background tasks")
 	err = <-pullDone
 	logrus.Info("Potential pull done")
+	<-gcDone
+	span()
+
+	span = cfg.Profile.Span("record history")
+	r.record(cfg, to, history.Record{
+		Time:     start,
+		Alias:    r.alias,
+		Registry: to.Data().Registry,
+		Tool:     to.Data().Name,
+		Version:  version,
+		Args:     len(arguments),
+		Duration: time.Since(start),
+		ExitCode: exitCode,
+	})
+	span()
 
 	cfg.Output.ExitCode = exitCode
+	if limitErr != nil {
+		return limitErr
+	}
 	return err
 }
 
+// record will add the execution to the history.
+// Errors are only logged, the tool has been executed already.
+func (r *RunCmd) record(cfg *config.Config, to tool.Tool, record history.Record) {
+	digest, err := tool.Digest(cfg.Docker, to, record.Version)
+	if err != nil {
+		logrus.Warnln("Could not inspect the image of the tool: ", err.Error())
+	}
+	record.Digest = digest
+	record.Dir, _ = os.Getwd()
+
+	// the tool has been executed, do not keep the user waiting for a parallel invocation that holds the database
+	database, err := db.TryOpen(cfg.ConfigDir)
+	if _, ok := err.(*db.LockedError); ok {
+		logrus.Infoln("Database is busy, the execution is not recorded")
+		return
+	}
+	if err != nil {
+		logrus.Warnln("Could not record the execution: ", err.Error())
+		return
+	}
+	defer database.Close()
+	if err := history.New(config.Database{DB: database}).Add(record); err != nil {
+		logrus.Warnln("Could not record the execution: ", err.Error())
+	}
+}
+
+// collect will remove orphaned containers in the background if the last garbage collection is due.
+// The returned channel will be closed as soon as the garbage collection is done.
+func (r *RunCmd) collect(client config.Docker, installation string, caches *cache.Cache, containerID string) chan bool {
+	done := make(chan bool)
+	due, err := caches.Container.CollectDue()
+	if err != nil || !due {
+		close(done)
+		return done
+	}
+	daemons, err := caches.Container.DaemonIDs()
+	if err != nil {
+		close(done)
+		return done
+	}
+	// the daemon of the tool might just have been started, it is only cached when the database is closed
+	if len(containerID) > 0 {
+		daemons[containerID] = true
+	}
+	go func() {
+		defer close(done)
+		removed, err := tool.Collect(client, installation, daemons)
+		if err != nil {
+			logrus.Warnln("Could not remove orphaned containers: ", err.Error())
+		}
+		logrus.WithField("containers", len(removed)).Info("Removed orphaned containers")
+	}()
+	return done
+}
+
 // selectVersion will select the version that should be used to run the tool.
 // It will take the constraint into consideration and will pull the image if needed.
-func (r *RunCmd) selectVersion(client config.Docker, db *bolt.DB, to tool.Tool, doneChan chan error, closeDBChan chan bool) (string, error) {
+func (r *RunCmd) selectVersion(client config.Docker, db config.Database, to tool.Tool, doneChan chan error, closeDBChan chan bool) (string, error) {
+
+	if r.offline {
+		return r.selectOfflineVersion(client, db, to, doneChan, closeDBChan)
+	}
 
 	// create channels to fetch the image locally and from the registry
 	localVersionChannel := make(chan func() (string, error))
 	repositoryVersionChannel := make(chan func() (string, error))
 
-	caches := cache.New(config.Database{DB: db})
+	caches := cache.New(db)
 
 	// Fetch local versions
 	go r.fetchLocalVersions(localVersionChannel, db, client, to)
@@ -204,7 +371,7 @@ func (r *RunCmd) selectVersion(client config.Docker, db *bolt.DB, to tool.Tool,
 		if version.ShouldPull(localVersion, repositoryVersion) {
 			caches.Versions.Clear(to)
 			closeDBChan <- true
-			err = tool.Pull(client, to, repositoryVersion, DefaultTimeout)
+			err = tool.Pull(client, to, repositoryVersion, DefaultTimeout, r.output)
 			if err != nil {
 				doneChan <- err
 				closeDBChan <- true
@@ -223,7 +390,8 @@ func (r *RunCmd) selectVersion(client config.Docker, db *bolt.DB, to tool.Tool,
 		closeDBChan <- true
 		return "", err
 	}
-	pull := func(doneChan chan error) (string, error) {
+	// a pull in the background must not render its progress, the tool owns the terminal meanwhile
+	pull := func(doneChan chan error, output *out.Output) (string, error) {
 		logrus.Infoln("Fetching remote versions")
 		repositoryVersion, err := (<-repositoryVersionChannel)()
 		dbClosed := false
@@ -232,7 +400,7 @@ func (r *RunCmd) selectVersion(client config.Docker, db *bolt.DB, to tool.Tool,
 			caches.Versions.Clear(to)
 			closeDBChan <- true
 			dbClosed = true
-			err = tool.Pull(client, to, repositoryVersion, DefaultTimeout)
+			err = tool.Pull(client, to, repositoryVersion, DefaultTimeout, output)
 			if err != nil {
 				doneChan <- err
 				return "", err
@@ -246,17 +414,53 @@ func (r *RunCmd) selectVersion(client config.Docker, db *bolt.DB, to tool.Tool,
 	}
 	if len(localVersion) > 0 {
 		logrus.WithField("version", localVersion).Infoln("Found local image")
-		go pull(doneChan)
+		go pull(doneChan, nil)
+		return localVersion, nil
+	}
+	return pull(doneChan, r.output)
+}
+
+// selectOfflineVersion will select the version only from the local images.
+// Nothing will be fetched or pulled, the cached remote versions are only used to give a hint.
+func (r *RunCmd) selectOfflineVersion(client config.Docker, db config.Database, to tool.Tool, doneChan chan error, closeDBChan chan bool) (string, error) {
+	doneChan <- nil
+	if r.update {
+		closeDBChan <- true
+		return "", ErrorOffline
+	}
+	caches := cache.New(db)
+	versions, err := caches.Versions.Local(to, client)
+	if err != nil {
+		closeDBChan <- true
+		return "", err
+	}
+	logrus.WithField("localversions", versions).Infoln("Found local versions, running offline")
+	localVersion := version.Select(versions, r.version)
+	if len(localVersion) > 0 {
+		closeDBChan <- true
 		return localVersion, nil
 	}
-	return pull(doneChan)
+	remoteVersions, err := caches.Versions.Cached(to)
+	closeDBChan <- true
+	if err != nil {
+		return "", err
+	}
+	constraint := r.version
+	if len(constraint) == 0 {
+		constraint = version.DefaultConstraint
+	}
+	return "", &OfflineError{
+		Tool:    to.Data().Name,
+		Version: constraint,
+		Remote:  version.Select(remoteVersions, r.version),
+	}
 }
 
 // fetchLocalVersions will fetch the local version asynchronously
-func (r *RunCmd) fetchLocalVersions(c chan func() (string, error), db *bolt.DB, client config.Docker, to tool.Tool) {
+func (r *RunCmd) fetchLocalVersions(c chan func() (string, error), db config.Database, client config.Docker, to tool.Tool) {
 	c <- (func() (string, error) {
 		// get local versions
-		caches := cache.New(config.Database{DB: db})
+		caches := cache.New(db)
 		versions, err := caches.Versions.Local(to, client)
 		logrus.WithField("localversions", versions).Infoln("Found local versions")
 		if err != nil {
@@ -267,9 +471,9 @@ func (r *RunCmd) fetchLocalVersions(c chan func() (string, error), db *bolt.DB,
 }
 
 // FetchRepositoryVersions will fetch the remote versions for the tool
-func (r *RunCmd) FetchRepositoryVersions(c chan func() (string, error), db *bolt.DB, to tool.Tool) {
+func (r *RunCmd) FetchRepositoryVersions(c chan func() (string, error), db config.Database, to tool.Tool) {
 	c <- (func() (string, error) {
-		caches := cache.New(config.Database{DB: db})
+		caches := cache.New(db)
 		versions, err := caches.Versions.Remote(to)
 		logrus.WithField("versions", versions).Infoln("Found remote versions")
 		if err != nil {
@@ -278,3 +482,190 @@ func (r *RunCmd) FetchRepositoryVersions(c chan func() (string, error), db *bolt
 		return version.Select(versions, r.version), nil
 	})
 }
+
+// transientMounts will return the mounts that are only used for this run, they are never stored.
+// The working directory is mounted if requested by the flag, the environment variable or the setting, in this order.
+func (r *RunCmd) transientMounts(db *bolt.DB) ([]string, error) {
+	workDir := r.mountWorkDir
+	if !r.mountWorkDirSet {
+		value, found := os.LookupEnv(MountWorkDirEnv)
+		if !found {
+			var err error
+			value, found, err = settings.New(config.Database{DB: db}).Get(settings.MountWorkDir)
+			if err != nil {
+				return nil, err
+			}
+		}
+		if found {
+			enabled, err := strconv.ParseBool(value)
+			if err != nil {
+				return nil, fmt.Errorf("The working directory mount %s is not a boolean", value)
+			}
+			workDir = enabled
+		}
+	}
+	return mount.Transient(os.Getenv(MountsEnv), workDir)
+}
+
+// executionOptions will resolve how the tool is executed, except for its version.
+// The daemon of a tool is restarted with the same options, so its configuration matches the next run.
+// The returned forwarding has to be removed once a tool that is not a daemon exits.
+func (r *RunCmd) executionOptions(cfg *config.Config, database *bolt.DB, to tool.Tool) (*tool.ExecutionOptions, forward.Forwarding, error) {
+	mounts := mount.New(config.Database{DB: database})
+	mos, err := mounts.List()
+	if err != nil {
+		return nil, forward.Forwarding{}, err
+	}
+	installation, err := cfg.Installation()
+	if err != nil {
+		return nil, forward.Forwarding{}, err
+	}
+	env, policies, err := resolveEnvironment(database, to, r.env)
+	if err != nil {
+		return nil, forward.Forwarding{}, err
+	}
+	transient, err := r.transientMounts(database)
+	if err != nil {
+		return nil, forward.Forwarding{}, err
+	}
+	network, err := resolveNetwork(database, to, r.network)
+	if err != nil {
+		return nil, forward.Forwarding{}, err
+	}
+	limits, err := resolveLimits(database, to, r.limits)
+	if err != nil {
+		return nil, forward.Forwarding{}, err
+	}
+	security, err := resolveSecurity(database, to)
+	if err != nil {
+		return nil, forward.Forwarding{}, err
+	}
+	// the credentials are written last, nothing can fail afterwards that would leave them behind
+	forwarding, err := r.forwarding(cfg, to)
+	if err != nil {
+		return nil, forward.Forwarding{}, err
+	}
+	mos = append(mount.Select(mos, to.Data().Registry, to.Data().Name), forwarding.Mounts...)
+	env = environment.Override(env, forwarding.Env, environment.SourceForward)
+	return &tool.ExecutionOptions{
+		IO:              cfg.IO,
+		Docker:          &cfg.Docker,
+		Tool:            to,
+		Arguments:       r.arguments,
+		Mounts:          mos,
+		TransientMounts: transient,
+		Env:             environment.Strings(env),
+		Policies:        policies,
+		Network:         network,
+		Limits:          limits,
+		IdentityDir:     filepath.Join(cfg.ConfigDir, IdentityDir),
+		Security:        security,
+		Installation:    installation,
+		Alias:           r.alias,
+		Profile:         cfg.Profile,
+	}, forwarding, nil
+}
+
+// forwarding will prepare the forwarding of the credentials the tool requests, the user approved them with the permissions of the tool.
+// The credentials of a tool are written to a directory of the run that is removed once the tool exits,
+// a daemon keeps them in a directory of the tool that is removed when the daemon stops.
+func (r *RunCmd) forwarding(cfg *config.Config, to tool.Tool) (forward.Forwarding, error) {
+	requested := tool.Forwards(to)
+	if len(requested) == 0 {
+		return forward.Forwarding{}, nil
+	}
+	dir := filepath.Join(cfg.ConfigDir, ForwardDir, "runs", strconv.Itoa(os.Getpid()))
+	if to.Data().Daemon != nil {
+		dir = daemonForwardDir(cfg, to.Data().Registry, to.Data().Name, r.alias)
+	}
+	return forward.Prepare(requested, homedir.Get(), dir)
+}
+
+// daemonForwardDir will return the directory with the forwarded credentials of the daemon of the tool, every alias has its own daemon
+func daemonForwardDir(cfg *config.Config, registry string, name string, alias string) string {
+	if len(alias) > 0 {
+		name += "@" + alias
+	}
+	return filepath.Join(cfg.ConfigDir, ForwardDir, "daemons", registry, name)
+}
+
+// removeForwarding will remove the credentials that have been forwarded into the stopped daemon.
+// Errors are only logged, the daemon has been stopped already.
+func removeForwarding(cfg *config.Config, d cache.Daemon) {
+	if err := os.RemoveAll(daemonForwardDir(cfg, d.Registry, d.Tool, d.Alias)); err != nil {
+		logrus.WithField("tool", d.Tool).Warnln("Could not remove the forwarded credentials of the daemon: ", err.Error())
+	}
+}
+
+// resolveNetwork will resolve the network mode of the container, the network of the alias takes precedence over the tool and the global setting
+func resolveNetwork(db *bolt.DB, to tool.Tool, network string) (string, error) {
+	global, _, err := settings.New(config.Database{DB: db}).Get(settings.Network)
+	if err != nil {
+		return "", err
+	}
+	return tool.Network(to, network, global), nil
+}
+
+// resolveSecurity will resolve the security profile of the tool, it is lowered to the most permissive profile the user allows.
+// Tools only run privileged if the user raised the maximum.
+func resolveSecurity(db *bolt.DB, to tool.Tool) (string, error) {
+	if err := tool.ValidateSecurity(tool.SecurityProfile(to)); err != nil {
+		return "", err
+	}
+	max, found, err := settings.New(config.Database{DB: db}).Get(settings.SecurityMax)
+	if err != nil {
+		return "", err
+	}
+	if !found {
+		max = tool.SecurityDefault
+	}
+	if err := tool.ValidateSecurity(max); err != nil {
+		return "", err
+	}
+	return tool.Security(to, max), nil
+}
+
+// resolveLimits will resolve the limits of the tool, each limit of the alias takes precedence over the tool and the global settings
+func resolveLimits(db *bolt.DB, to tool.Tool, limits tool.Limits) (tool.Limits, error) {
+	s := settings.New(config.Database{DB: db})
+	global := tool.Limits{}
+	for key, value := range map[string]*string{
+		settings.LimitCPUs:    &global.CPUs,
+		settings.LimitMemory:  &global.Memory,
+		settings.LimitTimeout: &global.Timeout,
+	} {
+		v, _, err := s.Get(key)
+		if err != nil {
+			return global, err
+		}
+		*value = v
+	}
+	pids, found, err := s.Get(settings.LimitPids)
+	if err != nil {
+		return global, err
+	}
+	if found {
+		global.Pids, err = strconv.ParseInt(pids, 10, 64)
+		if err != nil {
+			return global, &tool.InvalidRuntimeError{Setting: "pids", Value: pids}
+		}
+	}
+	resolved := tool.ResourceLimits(to, limits, global)
+	return resolved, resolved.Validate()
+}
+
+// resolveEnvironment will resolve the environment variables that are passed to the tool together with the policies that decided them.
+// The alias is the most specific policy, followed by the global deny rules, the tool and the global allow rules.
+func resolveEnvironment(db *bolt.DB, to tool.Tool, aliasEnv []string) ([]environment.Variable, []environment.Policy, error) {
+	s := settings.New(config.Database{DB: db})
+	allow, err := s.Values(settings.EnvAllow, environment.DefaultAllow)
+	if err != nil {
+		return nil, nil, err
+	}
+	deny, err := s.Values(settings.EnvDeny, environment.DefaultDeny)
+	if err != nil {
+		return nil, nil, err
+	}
+	policies := environment.Layers(environment.FromAlias(aliasEnv), tool.EnvironmentPolicy(to), allow, deny)
+	return environment.Resolve(os.Environ(), tool.EnvironmentDefaults(to), policies...), policies, nil
+}
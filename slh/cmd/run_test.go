@@ -61,6 +61,19 @@ func TestRun(t *testing.T) {
 				},
 			},
 		},
+		{
+			Name: "Offline without a local image",
+			Steps: []*test.Step{
+				{
+					Cmd: fmt.Sprintf("create registry file %s", filepath.Join(pathToCreate, "real.json")),
+					Has: []string{"Name", "Type", "Maintainer", "real", "file"},
+				},
+				{
+					Cmd: fmt.Sprintf("run alpine-test-version --version 0.1 --offline"),
+					Has: []string{"No local image of tool 'alpine-test-version' matches the version '0.1'"},
+				},
+			},
+		},
 		{
 			Name: "Success with update",
 			Steps: []*test.Step{
@@ -0,0 +1,54 @@
+/*
+Copyright 2018 Adobe
+All Rights Reserved.
+
+NOTICE: Adobe permits you to use, modify, and distribute this file in
+accordance with the terms of the Adobe license agreement accompanying
+it. If you have received this file from a source other than Adobe,
+then your use, modification, or distribution of it requires the prior
+written permission of Adobe.
+*/
+
+package cmd
+
+import (
+	"github.com/adobe/sledgehammer/slh/config"
+	"github.com/adobe/sledgehammer/slh/settings"
+	"github.com/spf13/cobra"
+)
+
+func SetCommand(cfg *config.Config) *cobra.Command {
+	setCommand := &cobra.Command{
+		Use:   "set <key> <value>",
+		Short: "Set a setting",
+		Long:  "Will set the given global setting of Sledgehammer. Use 'slh get settings' to see all available settings",
+		Args:  cobra.ExactArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			err := SetSetting(cfg, args[0], args[1])
+			if err != nil {
+				cmd.SilenceUsage = true
+			}
+			return err
+		},
+	}
+	return setCommand
+}
+
+// SetSetting will set the given setting to the given value
+func SetSetting(cfg *config.Config, key string, value string) error {
+	database, err := cfg.OpenDatabase()
+	if database != nil {
+		defer cfg.CloseDatabase()
+	}
+	if err != nil {
+		return err
+	}
+
+	s := settings.New(config.Database{DB: database})
+
+	err = s.Set(key, value)
+	if err != nil {
+		return err
+	}
+	return GetSettings(cfg.WithDatabase(database))
+}
@@ -0,0 +1,51 @@
+/*
+Copyright 2018 Adobe
+All Rights Reserved.
+
+NOTICE: Adobe permits you to use, modify, and distribute this file in
+accordance with the terms of the Adobe license agreement accompanying
+it. If you have received this file from a source other than Adobe,
+then your use, modification, or distribution of it requires the prior
+written permission of Adobe.
+*/
+
+package cmd_test
+
+import (
+	"testing"
+
+	"github.com/adobe/sledgehammer/slh/settings"
+	"github.com/adobe/sledgehammer/utils/test"
+)
+
+func TestSet(t *testing.T) {
+	cases := []*test.TestCase{
+		{
+			Name: "Set a setting",
+			Steps: []*test.Step{
+				{
+					Cmd: "set env.allow LANG,LC_*",
+					Has: []string{"Settings", "env.allow", "LANG,LC_*"},
+				},
+				{
+					Cmd: "get settings",
+					Has: []string{"env.allow", "LANG,LC_*"},
+				},
+				{
+					Cmd: "delete setting env.allow",
+					Not: []string{"LANG,LC_*"},
+				},
+			},
+		},
+		{
+			Name: "Set an unknown setting",
+			Steps: []*test.Step{
+				{
+					Cmd: "set foo bar",
+					Has: []string{settings.ErrorUnknownKey.Error()},
+				},
+			},
+		},
+	}
+	test.DoTest(t, cases)
+}
@@ -0,0 +1,28 @@
+/*
+Copyright 2018 Adobe
+All Rights Reserved.
+
+NOTICE: Adobe permits you to use, modify, and distribute this file in
+accordance with the terms of the Adobe license agreement accompanying
+it. If you have received this file from a source other than Adobe,
+then your use, modification, or distribution of it requires the prior
+written permission of Adobe.
+*/
+package cmd
+
+import (
+	"github.com/adobe/sledgehammer/slh/config"
+	"github.com/spf13/cobra"
+)
+
+func StopCommand(cfg *config.Config) *cobra.Command {
+	stopCommand := &cobra.Command{
+		Use:   "stop",
+		Short: "Stop a resource",
+		Long:  "Will stop a running ressource of Sledgehammer",
+	}
+
+	stopCommand.AddCommand(StopDaemonCommand(cfg))
+
+	return stopCommand
+}
@@ -0,0 +1,63 @@
+/*
+Copyright 2018 Adobe
+All Rights Reserved.
+
+NOTICE: Adobe permits you to use, modify, and distribute this file in
+accordance with the terms of the Adobe license agreement accompanying
+it. If you have received this file from a source other than Adobe,
+then your use, modification, or distribution of it requires the prior
+written permission of Adobe.
+*/
+package cmd
+
+import (
+	"github.com/adobe/sledgehammer/slh/cache"
+	"github.com/adobe/sledgehammer/slh/config"
+	"github.com/adobe/sledgehammer/utils"
+	"github.com/spf13/cobra"
+)
+
+func StopDaemonCommand(cfg *config.Config) *cobra.Command {
+	stopDaemonCommand := &cobra.Command{
+		Use:     "daemon <tool>",
+		Short:   "Stops the daemon of a tool",
+		Long:    "Will stop all daemon containers of the given tool",
+		Aliases: []string{"daemons", "da"},
+		Args:    cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			registry, tool := utils.GetRegistryAndTool(args[0])
+			err := StopDaemon(cfg, registry, tool)
+			if err != nil {
+				cmd.SilenceUsage = true
+			}
+			return err
+		},
+	}
+	return stopDaemonCommand
+}
+
+// StopDaemon will stop all daemons of the given tool
+func StopDaemon(cfg *config.Config, registry string, tool string) error {
+	database, err := cfg.OpenDatabase()
+	if database != nil {
+		defer cfg.CloseDatabase()
+	}
+	if err != nil {
+		return err
+	}
+
+	c := cache.New(config.Database{DB: database})
+
+	daemons, err := c.Container.Find(registry, tool)
+	if err != nil {
+		return err
+	}
+	for _, d := range daemons {
+		err = c.Container.Stop(cfg.Docker, d)
+		if err != nil {
+			return err
+		}
+		removeForwarding(cfg, d)
+	}
+	return GetDaemons(cfg.WithDatabase(database))
+}
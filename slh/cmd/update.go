@@ -49,6 +49,10 @@ func UpdateCommand(cfg *config.Config) *cobra.Command {
 // Execute will update all registries with the current tools
 func (u *updateCommand) Execute(cfg *config.Config) error {
 
+	if cfg.Offline {
+		return ErrorOffline
+	}
+
 	db, err := cfg.OpenDatabase()
 	if db != nil {
 		defer cfg.CloseDatabase()
@@ -16,6 +16,7 @@ import (
 	"path/filepath"
 	"testing"
 
+	"github.com/adobe/sledgehammer/slh/cmd"
 	"github.com/adobe/sledgehammer/slh/config"
 
 	"github.com/adobe/sledgehammer/utils/test"
@@ -51,6 +52,31 @@ func TestUpdate(t *testing.T) {
 				},
 			},
 		},
+		{
+			Name: "Update while offline",
+			Steps: []*test.Step{
+				{
+					Cmd: fmt.Sprintf("update --offline"),
+					Has: []string{cmd.ErrorOffline.Error()},
+				},
+			},
+		},
+		{
+			Name: "Update while offline by setting",
+			Steps: []*test.Step{
+				{
+					Cmd: fmt.Sprintf("set offline true"),
+				},
+				{
+					Cmd: fmt.Sprintf("update"),
+					Has: []string{cmd.ErrorOffline.Error()},
+				},
+				{
+					Cmd: fmt.Sprintf("update --offline=false -o json"),
+					Has: []string{"success"},
+				},
+			},
+		},
 	}
 	test.DoTest(t, cases)
 }
@@ -12,9 +12,18 @@ written permission of Adobe.
 package config
 
 import (
+	"crypto/rand"
+	"fmt"
 	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"golang.org/x/crypto/ssh/terminal"
 
 	"github.com/adobe/sledgehammer/slh/out"
+	"github.com/adobe/sledgehammer/slh/profile"
 	"github.com/adobe/sledgehammer/utils/db"
 
 	"github.com/coreos/bbolt"
@@ -22,10 +31,25 @@ import (
 	"github.com/adobe/sledgehammer/utils/docker"
 )
 
+// InstallationFile is the file in the configuration directory that keeps the id of the installation
+var InstallationFile = "installation"
+
 // Database is a simple struct that contains a bolt database.
 // Used if only a db is required instead of the whole config.
 type Database struct {
 	DB *bolt.DB
+	// Batch collects the writes if the database has been opened read-only, nil if writes are applied immediately
+	Batch *db.Batch
+}
+
+// Update will run the given write transaction, or defer it until the database is closed if it has been opened read-only.
+// Deferred writes are not visible to reads before they are applied.
+func (d Database) Update(write func(*bolt.Tx) error) error {
+	if d.Batch != nil {
+		d.Batch.Add(write)
+		return nil
+	}
+	return d.DB.Update(write)
 }
 
 // Docker is a simple struct that contains the docker client
@@ -39,12 +63,17 @@ type Docker struct {
 type Config struct {
 	db          *bolt.DB
 	ownsDB      bool
+	writes      *db.Batch
 	IO          *IO
 	OutputType  string
 	ConfigDir   string
 	Output      *out.Output
 	ExitCode    int
 	Initialized bool
+	// Offline is true if Sledgehammer must not access the network
+	Offline bool
+	// Profile records the timing of the phases of the invocation, nil if profiling is disabled
+	Profile *profile.Profile
 	Docker
 }
 
@@ -55,8 +84,14 @@ type IO struct {
 	In  io.Reader
 }
 
-// OpenDatabase will open a connection to the database if not done yet and will return it
+// OpenDatabase will open a connection to the database if not done yet and will return it.
+// A database that has been opened read-only will be reopened for writing.
 func (c *Config) OpenDatabase() (*bolt.DB, error) {
+	if c.db != nil && c.ownsDB && c.db.IsReadOnly() {
+		if err := c.CloseDatabase(); err != nil {
+			return nil, err
+		}
+	}
 	if c.db == nil {
 		database, err := db.Open(c.ConfigDir)
 		if database != nil {
@@ -68,16 +103,97 @@ func (c *Config) OpenDatabase() (*bolt.DB, error) {
 	return c.db, nil
 }
 
-// CloseDatabase will close the database if it is owned by the config
+// OpenDatabaseReadOnly will open a shared connection to the database if not done yet and will return it.
+// Parallel invocations can read at the same time, writes have to go through Database() and are deferred until the database is closed.
+func (c *Config) OpenDatabaseReadOnly() (*bolt.DB, error) {
+	if c.db == nil {
+		database, err := db.OpenReadOnly(c.ConfigDir)
+		if database != nil {
+			c.db = database
+			c.ownsDB = true
+			if database.IsReadOnly() {
+				c.writes = &db.Batch{}
+			}
+		}
+		return c.db, err
+	}
+	return c.db, nil
+}
+
+// Database will return the opened database together with the deferred writes if it has been opened read-only
+func (c *Config) Database() Database {
+	return Database{DB: c.db, Batch: c.writes}
+}
+
+// CloseDatabase will close the database if it is owned by the config.
+// Deferred writes are applied afterwards in a single transaction, the exclusive lock is only held for this transaction.
 func (c *Config) CloseDatabase() error {
 	if c.db != nil && c.ownsDB {
 		err := c.db.Close()
 		c.db = nil
-		return err
+		if err != nil {
+			return err
+		}
+		return c.flush()
 	}
 	return nil
 }
 
+// flush will apply the deferred writes, writes that are deferred afterwards are dropped
+func (c *Config) flush() error {
+	writes := c.writes
+	c.writes = nil
+	if writes == nil || writes.Len() == 0 {
+		return nil
+	}
+	database, err := db.Open(c.ConfigDir)
+	if err != nil {
+		return err
+	}
+	defer database.Close()
+	return writes.Apply(database)
+}
+
+// Installation will return the id of this installation of Sledgehammer, a random id that is kept in the configuration directory together with the name of the host.
+// A configuration directory that is shared between hosts, e.g. a home directory on a network share, is a separate installation on each host.
+func (c *Config) Installation() (string, error) {
+	host, err := os.Hostname()
+	if err != nil {
+		return "", err
+	}
+	file := filepath.Join(c.ConfigDir, InstallationFile)
+	id, err := ioutil.ReadFile(file)
+	if os.IsNotExist(err) {
+		id, err = newInstallation(file)
+	}
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(string(id)) + "@" + host, nil
+}
+
+// newInstallation will write a new random id to the given file, the id of a parallel invocation that has been faster wins
+func newInstallation(file string) ([]byte, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return nil, err
+	}
+	// random uuid, version 4
+	b[6] = (b[6] & 0x0f) | 0x40
+	b[8] = (b[8] & 0x3f) | 0x80
+	id := []byte(fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:]))
+	f, err := os.OpenFile(file, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0644)
+	if os.IsExist(err) {
+		return ioutil.ReadFile(file)
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	_, err = f.Write(id)
+	return id, err
+}
+
 // WithDatabase will create a new config with the given database and returns a new config
 func (c *Config) WithDatabase(database *bolt.DB) *Config {
 	return &Config{
@@ -87,7 +203,10 @@ func (c *Config) WithDatabase(database *bolt.DB) *Config {
 		IO:         c.IO,
 		Output:     c.Output,
 		OutputType: c.OutputType,
+		Offline:    c.Offline,
+		Profile:    c.Profile,
 		db:         database,
+		writes:     c.writes,
 	}
 }
 
@@ -96,19 +215,32 @@ func NewOutput(cfg *Config) *out.Output {
 	o := &out.Output{
 		Writer: cfg.IO.Out,
 	}
+	// the progress of pulls is written to stderr, stdout might be the output of a tool
 	switch cfg.OutputType {
 	default:
 		o.RenderFunc = o.RenderTable
 		o.ProgressFunc = o.TextProgressFunc
+		o.PullFunc = out.NewPullBars(cfg.IO.Err, IsTerminal(cfg.IO.Err)).Event
 	case "text":
 		o.RenderFunc = o.RenderTable
 		o.ProgressFunc = o.TextProgressFunc
+		o.PullFunc = out.NewPullBars(cfg.IO.Err, IsTerminal(cfg.IO.Err)).Event
 	case "table":
 		o.RenderFunc = o.RenderTable
 		o.ProgressFunc = o.NoProgressFunc
 	case "json":
 		o.RenderFunc = o.RenderJSON
 		o.ProgressFunc = o.NoProgressFunc
+		o.PullFunc = out.JSONPullFunc(cfg.IO.Err)
+	case "none":
+		o.RenderFunc = o.RenderTable
+		o.ProgressFunc = o.NoProgressFunc
 	}
 	return o
 }
+
+// IsTerminal will return true if the given stream is connected to a terminal
+func IsTerminal(stream interface{}) bool {
+	f, ok := stream.(*os.File)
+	return ok && terminal.IsTerminal(int(f.Fd()))
+}
@@ -0,0 +1,207 @@
+/*
+Copyright 2018 Adobe
+All Rights Reserved.
+
+NOTICE: Adobe permits you to use, modify, and distribute this file in
+accordance with the terms of the Adobe license agreement accompanying
+it. If you have received this file from a source other than Adobe,
+then your use, modification, or distribution of it requires the prior
+written permission of Adobe.
+*/
+
+package environment
+
+import (
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/adobe/sledgehammer/utils"
+	"github.com/sirupsen/logrus"
+)
+
+var (
+	// DefaultAllow are the host variables that are passed to tools if nothing else is configured
+	DefaultAllow = []string{"*"}
+	// DefaultDeny are the host variables that are never passed to tools if nothing else is configured
+	DefaultDeny = []string{"*TOKEN*", "*SECRET*", "*PASSWORD*", "*PASSWD*", "JAVA_HOME"}
+	// Redacted is shown instead of the value of a host variable
+	Redacted = "<redacted>"
+)
+
+const (
+	// SourceHost marks variables that are forwarded from the host
+	SourceHost = "host"
+	// SourceTool marks variables that are defaults of the tool
+	SourceTool = "tool"
+	// SourceAlias marks variables that are set by the alias
+	SourceAlias = "alias"
+	// SourceGlobal marks variables that are set by the global settings
+	SourceGlobal = "global"
+	// SourceForward marks variables that point to credentials forwarded into the tool
+	SourceForward = "forward"
+)
+
+// Policy is a single layer of rules that decide which host variables are passed to a tool.
+// Deny rules take precedence over allow rules of the same layer.
+type Policy struct {
+	Source string
+	Allow  []string
+	Deny   []string
+	Values map[string]string
+}
+
+// Variable is a single environment variable that will be passed to a tool
+type Variable struct {
+	Name   string
+	Value  string
+	Source string
+}
+
+// String will return the variable in the form of NAME=VALUE
+func (v Variable) String() string {
+	return v.Name + "=" + v.Value
+}
+
+// Redact will return the value of the variable or a placeholder if it is a host variable
+func (v Variable) Redact() string {
+	if v.Source == SourceHost {
+		return Redacted
+	}
+	return v.Value
+}
+
+// FromAlias will create a policy from the environment settings of an alias.
+// Entries in the form of NAME=VALUE set a value, all other entries allow the host variable.
+func FromAlias(envs []string) Policy {
+	p := Policy{
+		Source: SourceAlias,
+		Values: map[string]string{},
+	}
+	for _, env := range envs {
+		parts := strings.SplitN(env, "=", 2)
+		if len(parts) == 2 {
+			p.Values[parts[0]] = parts[1]
+		} else {
+			p.Allow = append(p.Allow, env)
+		}
+	}
+	return p
+}
+
+// Layers will return the policies of the alias, the tool and the global settings in the order they are evaluated.
+// The global deny rules are evaluated before the tool, a tool can never receive a variable the user denied. Only the alias of the user can override them.
+func Layers(alias Policy, tool Policy, allow []string, deny []string) []Policy {
+	return []Policy{
+		alias,
+		{Source: SourceGlobal, Deny: deny},
+		tool,
+		{Source: SourceGlobal, Allow: allow},
+	}
+}
+
+// decide will return if the policy has an opinion about the variable and if it is allowed
+func (p *Policy) decide(name string) (bool, bool) {
+	if matches(p.Deny, name) {
+		return true, false
+	}
+	if matches(p.Allow, name) {
+		return true, true
+	}
+	return false, false
+}
+
+// Resolve will evaluate the given policies against the host variables.
+// Policies have to be ordered from the most specific to the least specific one, the first one with an opinion wins.
+// Defaults are only used if the variable is not passed from the host.
+func Resolve(host []string, defaults map[string]string, policies ...Policy) []Variable {
+	allowed := []string{}
+	for _, env := range host {
+		name := strings.SplitN(env, "=", 2)[0]
+		if len(name) == 0 {
+			continue
+		}
+		for _, p := range policies {
+			if decided, allow := p.decide(name); decided {
+				if allow {
+					allowed = append(allowed, env)
+				} else {
+					logrus.WithField("var", name).WithField("source", p.Source).Debugln("Variable denied by policy")
+				}
+				break
+			}
+		}
+	}
+
+	variables := map[string]Variable{}
+	for _, env := range utils.PrepareEnvironment(allowed) {
+		parts := strings.SplitN(env, "=", 2)
+		if len(parts) == 2 {
+			variables[parts[0]] = Variable{Name: parts[0], Value: parts[1], Source: SourceHost}
+		}
+	}
+	for name, value := range defaults {
+		if _, found := variables[name]; !found {
+			variables[name] = Variable{Name: name, Value: value, Source: SourceTool}
+		}
+	}
+	// the most specific values are applied last
+	for i := len(policies) - 1; i >= 0; i-- {
+		for name, value := range policies[i].Values {
+			variables[name] = Variable{Name: name, Value: value, Source: policies[i].Source}
+		}
+	}
+
+	names := []string{}
+	for name := range variables {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	resolved := []Variable{}
+	for _, name := range names {
+		v := variables[name]
+		logrus.WithFields(logrus.Fields{
+			"var":    v.Name,
+			"value":  v.Redact(),
+			"source": v.Source,
+		}).Debugln("Resolved variable")
+		resolved = append(resolved, v)
+	}
+	return resolved
+}
+
+// Override will set the given values, they replace resolved variables with the same name
+func Override(variables []Variable, values map[string]string, source string) []Variable {
+	overridden := []Variable{}
+	for _, v := range variables {
+		if _, found := values[v.Name]; !found {
+			overridden = append(overridden, v)
+		}
+	}
+	for name, value := range values {
+		overridden = append(overridden, Variable{Name: name, Value: value, Source: source})
+	}
+	sort.Slice(overridden, func(i, j int) bool {
+		return overridden[i].Name < overridden[j].Name
+	})
+	return overridden
+}
+
+// Strings will return the given variables in the form of NAME=VALUE
+func Strings(variables []Variable) []string {
+	envs := []string{}
+	for _, v := range variables {
+		envs = append(envs, v.String())
+	}
+	return envs
+}
+
+// matches will return true if the name matches one of the patterns, ignoring the case
+func matches(patterns []string, name string) bool {
+	for _, pattern := range patterns {
+		if matched, err := path.Match(strings.ToUpper(pattern), strings.ToUpper(name)); err == nil && matched {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,133 @@
+/*
+Copyright 2018 Adobe
+All Rights Reserved.
+
+NOTICE: Adobe permits you to use, modify, and distribute this file in
+accordance with the terms of the Adobe license agreement accompanying
+it. If you have received this file from a source other than Adobe,
+then your use, modification, or distribution of it requires the prior
+written permission of Adobe.
+*/
+
+package environment_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/adobe/sledgehammer/slh/environment"
+)
+
+func TestResolve(t *testing.T) {
+	global := environment.Policy{
+		Source: environment.SourceGlobal,
+		Allow:  environment.DefaultAllow,
+		Deny:   environment.DefaultDeny,
+	}
+	cases := []struct {
+		name     string
+		host     []string
+		defaults map[string]string
+		policies []environment.Policy
+		expected []string
+	}{
+		{
+			name:     "No policies",
+			host:     []string{"FOO=bar"},
+			expected: []string{},
+		},
+		{
+			name:     "Default global policy",
+			host:     []string{"FOO=bar", "GITHUB_TOKEN=secret", "JAVA_HOME=/usr/lib/jvm", "PATH=/bin"},
+			policies: []environment.Policy{global},
+			expected: []string{"FOO=bar", "SLH_HOST_PATH=/bin"},
+		},
+		{
+			name:     "Globally denied variables ignore the case",
+			host:     []string{"FOO=bar", "github_token=secret", "Db_Password=secret"},
+			policies: []environment.Policy{global},
+			expected: []string{"FOO=bar"},
+		},
+		{
+			name:     "Tool cannot allow a globally denied variable",
+			host:     []string{"FOO=bar", "GITHUB_TOKEN=secret"},
+			policies: environment.Layers(environment.FromAlias(nil), environment.Policy{Source: environment.SourceTool, Allow: []string{"*"}}, environment.DefaultAllow, environment.DefaultDeny),
+			expected: []string{"FOO=bar"},
+		},
+		{
+			name:     "Alias allows a globally denied variable",
+			host:     []string{"FOO=bar", "GITHUB_TOKEN=secret"},
+			policies: environment.Layers(environment.FromAlias([]string{"GITHUB_TOKEN"}), environment.Policy{Source: environment.SourceTool}, environment.DefaultAllow, environment.DefaultDeny),
+			expected: []string{"FOO=bar", "GITHUB_TOKEN=secret"},
+		},
+		{
+			name:     "Tool allows a variable the global allow rules do not pass",
+			host:     []string{"FOO=bar", "LANG=C"},
+			policies: environment.Layers(environment.FromAlias(nil), environment.Policy{Source: environment.SourceTool, Allow: []string{"FOO"}}, []string{"LANG"}, environment.DefaultDeny),
+			expected: []string{"FOO=bar", "LANG=C"},
+		},
+		{
+			name: "Tool denies with globs",
+			host: []string{"FOO=bar", "AWS_PROFILE=dev", "AWS_REGION=eu"},
+			policies: []environment.Policy{
+				{Source: environment.SourceTool, Deny: []string{"AWS_*"}},
+				global,
+			},
+			expected: []string{"FOO=bar"},
+		},
+		{
+			name:     "Tool defaults do not override the host",
+			host:     []string{"FOO=bar"},
+			defaults: map[string]string{"FOO": "default", "BAR": "default"},
+			policies: []environment.Policy{global},
+			expected: []string{"BAR=default", "FOO=bar"},
+		},
+		{
+			name:     "Alias additions",
+			host:     []string{"FOO=bar", "MY_TOKEN=secret"},
+			defaults: map[string]string{"BAR": "default"},
+			policies: []environment.Policy{
+				environment.FromAlias([]string{"MY_*", "BAR=alias"}),
+				global,
+			},
+			expected: []string{"BAR=alias", "FOO=bar", "MY_TOKEN=secret"},
+		},
+		{
+			name: "Restrictive global policy",
+			host: []string{"FOO=bar", "LANG=C", "LC_ALL=C"},
+			policies: []environment.Policy{
+				{Source: environment.SourceGlobal, Allow: []string{"LANG", "LC_*"}},
+			},
+			expected: []string{"LANG=C", "LC_ALL=C"},
+		},
+	}
+
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			resolved := environment.Resolve(tt.host, tt.defaults, tt.policies...)
+			assert.Equal(t, tt.expected, environment.Strings(resolved))
+		})
+	}
+}
+
+func TestRedact(t *testing.T) {
+	resolved := environment.Resolve(
+		[]string{"FOO=secret"},
+		map[string]string{"BAR": "default"},
+		environment.Policy{Source: environment.SourceGlobal, Allow: []string{"*"}},
+	)
+	assert.Equal(t, "default", resolved[0].Redact())
+	assert.Equal(t, environment.Redacted, resolved[1].Redact())
+}
+
+func TestOverride(t *testing.T) {
+	resolved := environment.Resolve(
+		[]string{"HOME=/Users/foo", "LANG=C"},
+		nil,
+		environment.Policy{Source: environment.SourceGlobal, Allow: []string{"*"}},
+	)
+	overridden := environment.Override(resolved, map[string]string{"HOME": "/home/slh", "DOCKER_CONFIG": "/run/slh/docker"}, environment.SourceForward)
+	assert.Equal(t, []string{"DOCKER_CONFIG=/run/slh/docker", "HOME=/home/slh", "LANG=C"}, environment.Strings(overridden))
+	assert.Equal(t, environment.SourceForward, overridden[1].Source)
+}
@@ -0,0 +1,66 @@
+/*
+Copyright 2018 Adobe
+All Rights Reserved.
+
+NOTICE: Adobe permits you to use, modify, and distribute this file in
+accordance with the terms of the Adobe license agreement accompanying
+it. If you have received this file from a source other than Adobe,
+then your use, modification, or distribution of it requires the prior
+written permission of Adobe.
+*/
+
+package forward
+
+import (
+	"fmt"
+	"strings"
+)
+
+var (
+	// KindSSH forwards the ssh agent and the known hosts of the user
+	KindSSH = "ssh"
+	// KindGit forwards the git configuration of the user
+	KindGit = "git"
+	// KindDocker forwards the docker credentials of the user, either of all registries or only of the one after a colon, e.g. docker:ghcr.io
+	KindDocker = "docker"
+
+	descriptions = map[string]string{
+		KindSSH:    "your ssh agent and known hosts",
+		KindGit:    "your git configuration",
+		KindDocker: "your docker credentials",
+	}
+)
+
+// InvalidKindError will be thrown if a tool requests something that cannot be forwarded
+type InvalidKindError struct {
+	Kind string
+}
+
+func (e *InvalidKindError) Error() string {
+	return fmt.Sprintf("Cannot forward '%s', supported are ssh, git and docker[:registry]", e.Kind)
+}
+
+// Validate will check that all kinds can be forwarded
+func Validate(kinds []string) error {
+	for _, k := range kinds {
+		if k != KindSSH && k != KindGit && k != KindDocker && !(strings.HasPrefix(k, KindDocker+":") && len(k) > len(KindDocker)+1) {
+			return &InvalidKindError{Kind: k}
+		}
+	}
+	return nil
+}
+
+// Describe will describe the kinds in a way a user understands
+func Describe(kinds []string) string {
+	parts := []string{}
+	for _, k := range kinds {
+		if d, found := descriptions[k]; found {
+			parts = append(parts, d)
+		} else if strings.HasPrefix(k, KindDocker+":") {
+			parts = append(parts, "your docker credentials for "+strings.TrimPrefix(k, KindDocker+":"))
+		} else {
+			parts = append(parts, k)
+		}
+	}
+	return strings.Join(parts, ", ")
+}
@@ -0,0 +1,102 @@
+/*
+Copyright 2018 Adobe
+All Rights Reserved.
+
+NOTICE: Adobe permits you to use, modify, and distribute this file in
+accordance with the terms of the Adobe license agreement accompanying
+it. If you have received this file from a source other than Adobe,
+then your use, modification, or distribution of it requires the prior
+written permission of Adobe.
+*/
+
+package forward_test
+
+import (
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/docker/docker-credential-helpers/credentials"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/adobe/sledgehammer/slh/forward"
+	"github.com/adobe/sledgehammer/slh/mount"
+	"github.com/adobe/sledgehammer/utils/test"
+)
+
+func TestValidate(t *testing.T) {
+	assert.NoError(t, forward.Validate([]string{"ssh", "git", "docker", "docker:ghcr.io"}))
+	assert.Equal(t, &forward.InvalidKindError{Kind: "docker:"}, forward.Validate([]string{"docker:"}))
+	assert.Equal(t, &forward.InvalidKindError{Kind: "aws"}, forward.Validate([]string{"ssh", "aws"}))
+}
+
+func TestPrepare(t *testing.T) {
+	if runtime.GOOS != "linux" {
+		t.Skip("The ssh agent is only forwarded from the host on linux")
+	}
+	home := test.NewTmpDir(t)
+	defer test.DeleteTmpDir(home, t)
+	dir := filepath.Join(home, "forward")
+
+	gitconfig := filepath.Join(home, ".gitconfig")
+	if err := ioutil.WriteFile(gitconfig, []byte("[user]\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	previous, found := os.LookupEnv("SSH_AUTH_SOCK")
+	os.Setenv("SSH_AUTH_SOCK", "/tmp/agent.sock")
+	defer func() {
+		if found {
+			os.Setenv("SSH_AUTH_SOCK", previous)
+		} else {
+			os.Unsetenv("SSH_AUTH_SOCK")
+		}
+	}()
+
+	f, err := forward.Prepare([]string{forward.KindSSH, forward.KindGit}, home, dir)
+	assert.NoError(t, err)
+	// known hosts do not exist and are skipped
+	assert.Equal(t, []mount.Mount{
+		{Type: mount.TypeBind, Source: "/tmp/agent.sock", Target: forward.SSHSocket},
+		{Type: mount.TypeBind, Source: gitconfig, Target: "/home/slh/.gitconfig", ReadOnly: true},
+	}, f.Mounts)
+	assert.Equal(t, map[string]string{"SSH_AUTH_SOCK": forward.SSHSocket, "HOME": "/home/slh"}, f.Env)
+	// nothing has been generated that has to be removed
+	assert.Empty(t, f.Dir)
+
+	f, err = forward.Prepare([]string{"docker:ghcr.io"}, home, dir)
+	assert.NoError(t, err)
+	assert.Equal(t, []mount.Mount{{Type: mount.TypeBind, Source: dir, Target: forward.DockerConfig, ReadOnly: true}}, f.Mounts)
+	assert.Equal(t, dir, f.Dir)
+	info, err := os.Stat(filepath.Join(dir, "config.json"))
+	assert.NoError(t, err)
+	assert.Equal(t, os.FileMode(0600), info.Mode().Perm())
+
+	_, err = forward.Prepare([]string{"aws"}, home, dir)
+	assert.Equal(t, &forward.InvalidKindError{Kind: "aws"}, err)
+}
+
+func TestGenerateDockerConfig(t *testing.T) {
+	creds := map[string]*credentials.Credentials{
+		"ghcr.io":  {Username: "user", Secret: "pass"},
+		"quay.io":  {Username: "<token>", Secret: "token"},
+		"other.io": nil,
+	}
+	b, err := forward.GenerateDockerConfig([]string{"ghcr.io", "quay.io", "other.io", "missing.io"}, func(server string) (*credentials.Credentials, error) {
+		if c, found := creds[server]; found {
+			return c, nil
+		}
+		return nil, errors.New("No credentials found")
+	})
+	assert.NoError(t, err)
+
+	cfg := map[string]map[string]map[string]string{}
+	assert.NoError(t, json.Unmarshal(b, &cfg))
+	assert.Equal(t, map[string]map[string]string{
+		"ghcr.io": {"auth": "dXNlcjpwYXNz"},
+		"quay.io": {"identitytoken": "token"},
+	}, cfg["auths"])
+}
@@ -0,0 +1,156 @@
+/*
+Copyright 2018 Adobe
+All Rights Reserved.
+
+NOTICE: Adobe permits you to use, modify, and distribute this file in
+accordance with the terms of the Adobe license agreement accompanying
+it. If you have received this file from a source other than Adobe,
+then your use, modification, or distribution of it requires the prior
+written permission of Adobe.
+*/
+
+package forward
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	goruntime "runtime"
+	"strings"
+
+	"github.com/adobe/sledgehammer/slh/mount"
+	"github.com/adobe/sledgehammer/slh/tool"
+	secrets "github.com/adobe/sledgehammer/utils/docker"
+	"github.com/docker/docker-credential-helpers/credentials"
+	"github.com/sirupsen/logrus"
+)
+
+var (
+	// SSHSocket is the path of the forwarded ssh agent in the container
+	SSHSocket = "/run/slh/ssh-agent.sock"
+	// DockerConfig is the directory of the generated docker configuration in the container
+	DockerConfig = "/run/slh/docker"
+	// desktopSSHSocket is the ssh agent of the host that Docker Desktop for Mac offers to containers, the socket of the host cannot be mounted there
+	desktopSSHSocket = "/run/host-services/ssh-auth.sock"
+)
+
+// Forwarding contains the mounts and environment variables that forward the credentials of the user into a tool
+type Forwarding struct {
+	Mounts []mount.Mount
+	Env    map[string]string
+	// Dir is the directory with the generated docker configuration, it is empty if none has been generated
+	Dir string
+}
+
+// Prepare will prepare the forwarding of the given kinds.
+// Home is the home directory of the user, the docker configuration is generated in the given directory and only contains the requested credentials.
+// The configuration contains the credentials in plain text, the caller has to remove the directory once the tool does not need them anymore.
+// Credentials that do not exist on the host are skipped.
+func Prepare(kinds []string, home string, dir string) (Forwarding, error) {
+	f := Forwarding{
+		Mounts: []mount.Mount{},
+		Env:    map[string]string{},
+	}
+	dockerServers := []string{}
+	dockerAll := false
+	for _, k := range kinds {
+		switch {
+		case k == KindSSH:
+			f.ssh(home)
+		case k == KindGit:
+			f.file(filepath.Join(home, ".gitconfig"), ".gitconfig")
+		case k == KindDocker:
+			dockerAll = true
+		case strings.HasPrefix(k, KindDocker+":"):
+			dockerServers = append(dockerServers, strings.TrimPrefix(k, KindDocker+":"))
+		default:
+			return f, &InvalidKindError{Kind: k}
+		}
+	}
+	if dockerAll {
+		all, err := secrets.Servers()
+		if err != nil {
+			return f, err
+		}
+		dockerServers = all
+	}
+	if dockerAll || len(dockerServers) > 0 {
+		b, err := GenerateDockerConfig(dockerServers, secrets.GetCredentials)
+		if err != nil {
+			return f, err
+		}
+		if err := os.MkdirAll(dir, 0700); err != nil {
+			return f, err
+		}
+		if err := ioutil.WriteFile(filepath.Join(dir, "config.json"), b, 0600); err != nil {
+			return f, err
+		}
+		f.Mounts = append(f.Mounts, mount.Mount{Type: mount.TypeBind, Source: dir, Target: DockerConfig, ReadOnly: true})
+		f.Env["DOCKER_CONFIG"] = DockerConfig
+		f.Dir = dir
+	}
+	return f, nil
+}
+
+// ssh will forward the ssh agent and the known hosts
+func (f *Forwarding) ssh(home string) {
+	socket := os.Getenv("SSH_AUTH_SOCK")
+	switch {
+	case goruntime.GOOS == "darwin":
+		socket = desktopSSHSocket
+	case goruntime.GOOS == "windows":
+		logrus.Warn("The ssh agent cannot be forwarded on Windows")
+		socket = ""
+	case len(socket) == 0:
+		logrus.Warn("The ssh agent cannot be forwarded, SSH_AUTH_SOCK is not set")
+	}
+	if len(socket) > 0 {
+		f.Mounts = append(f.Mounts, mount.Mount{Type: mount.TypeBind, Source: socket, Target: SSHSocket})
+		f.Env["SSH_AUTH_SOCK"] = SSHSocket
+	}
+	f.file(filepath.Join(home, ".ssh", "known_hosts"), ".ssh/known_hosts")
+}
+
+// file will mount the file of the host read-only into the home directory of the tool, if it exists
+func (f *Forwarding) file(source string, target string) {
+	if _, err := os.Stat(source); err != nil {
+		logrus.WithField("file", source).Info("File does not exist, it is not forwarded")
+		return
+	}
+	f.Mounts = append(f.Mounts, mount.Mount{Type: mount.TypeBind, Source: source, Target: path.Join(tool.ContainerHome, target), ReadOnly: true})
+	f.Env["HOME"] = tool.ContainerHome
+}
+
+// dockerConfig is the part of the docker configuration that contains the credentials
+type dockerConfig struct {
+	Auths map[string]dockerAuth `json:"auths"`
+}
+
+type dockerAuth struct {
+	Auth          string `json:"auth,omitempty"`
+	IdentityToken string `json:"identitytoken,omitempty"`
+}
+
+// GenerateDockerConfig will generate a docker configuration that contains the credentials of the given servers in plain text.
+// Servers without credentials are skipped.
+func GenerateDockerConfig(servers []string, credentialsOf func(string) (*credentials.Credentials, error)) ([]byte, error) {
+	cfg := dockerConfig{
+		Auths: map[string]dockerAuth{},
+	}
+	for _, server := range servers {
+		creds, err := credentialsOf(server)
+		if err != nil || creds == nil {
+			logrus.WithField("server", server).WithError(err).Warn("No docker credentials found, they are not forwarded")
+			continue
+		}
+		if creds.Username == "<token>" {
+			cfg.Auths[server] = dockerAuth{IdentityToken: creds.Secret}
+			continue
+		}
+		cfg.Auths[server] = dockerAuth{Auth: base64.StdEncoding.EncodeToString([]byte(creds.Username + ":" + creds.Secret))}
+	}
+	return json.MarshalIndent(cfg, "", "  ")
+}
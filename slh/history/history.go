@@ -0,0 +1,160 @@
+/*
+Copyright 2018 Adobe
+All Rights Reserved.
+
+NOTICE: Adobe permits you to use, modify, and distribute this file in
+accordance with the terms of the Adobe license agreement accompanying
+it. If you have received this file from a source other than Adobe,
+then your use, modification, or distribution of it requires the prior
+written permission of Adobe.
+*/
+
+package history
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"strconv"
+	"time"
+
+	"github.com/adobe/sledgehammer/slh/config"
+	"github.com/adobe/sledgehammer/slh/settings"
+	bolt "github.com/coreos/bbolt"
+	"github.com/sirupsen/logrus"
+)
+
+var (
+	// BucketKey is the name of the bucket where the executions are recorded
+	BucketKey = "history"
+	// DefaultSize is the number of executions that are kept if the setting is not set
+	DefaultSize = 1000
+)
+
+// Record is a single execution of a tool
+type Record struct {
+	Time     time.Time     `json:"time"`
+	Alias    string        `json:"alias,omitempty"`
+	Registry string        `json:"registry"`
+	Tool     string        `json:"tool"`
+	Version  string        `json:"version"`
+	Digest   string        `json:"digest"`
+	Dir      string        `json:"dir"`
+	Args     int           `json:"args"`
+	Duration time.Duration `json:"duration"`
+	ExitCode int           `json:"exitCode"`
+}
+
+// Filter selects records of the history, empty fields match all records
+type Filter struct {
+	Registry string
+	Tool     string
+	Alias    string
+	Since    time.Time
+	// Limit is the maximum number of records, the newest are returned. 0 returns all records
+	Limit int
+}
+
+// Matches will check if the given record is selected by the filter
+func (f Filter) Matches(r Record) bool {
+	return (len(f.Registry) == 0 || f.Registry == r.Registry) &&
+		(len(f.Tool) == 0 || f.Tool == r.Tool) &&
+		(len(f.Alias) == 0 || f.Alias == r.Alias) &&
+		!r.Time.Before(f.Since)
+}
+
+// History is the struct that can be used to access the recorded executions
+type History struct {
+	config.Database
+}
+
+// New will create a new History struct based on the given bolt database.
+func New(db config.Database) *History {
+	return &History{
+		Database: db,
+	}
+}
+
+// Size will return the number of executions that are kept, 0 disables the history
+func (h *History) Size() int {
+	value, found, err := settings.New(h.Database).Get(settings.HistorySize)
+	if err != nil || !found {
+		return DefaultSize
+	}
+	size, err := strconv.Atoi(value)
+	if err != nil || size < 0 {
+		logrus.WithField("value", value).Warnln("Invalid history size, using the default")
+		return DefaultSize
+	}
+	return size
+}
+
+// Add will record the given execution and remove the oldest ones that exceed the size of the history
+func (h *History) Add(record Record) error {
+	size := h.Size()
+	logrus.WithField("tool", record.Tool).WithField("size", size).Debug("Recording execution")
+	return h.Database.Update(func(tx *bolt.Tx) error {
+		bucket, err := tx.CreateBucketIfNotExists([]byte(BucketKey))
+		if err != nil {
+			return err
+		}
+		if size > 0 {
+			id, err := bucket.NextSequence()
+			if err != nil {
+				return err
+			}
+			b, err := json.Marshal(record)
+			if err != nil {
+				return err
+			}
+			if err := bucket.Put(key(id), b); err != nil {
+				return err
+			}
+		}
+		// keys are ordered by time, so the oldest records are first
+		cursor := bucket.Cursor()
+		count := 0
+		for k, _ := cursor.First(); k != nil; k, _ = cursor.Next() {
+			count++
+		}
+		for k, _ := cursor.First(); k != nil && count > size; k, _ = cursor.First() {
+			if err := cursor.Delete(); err != nil {
+				return err
+			}
+			count--
+		}
+		return nil
+	})
+}
+
+// List will return the records selected by the filter, the newest first
+func (h *History) List(filter Filter) ([]Record, error) {
+	records := []Record{}
+	err := h.DB.View(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket([]byte(BucketKey))
+		if bucket == nil {
+			return nil
+		}
+		cursor := bucket.Cursor()
+		for k, v := cursor.Last(); k != nil; k, v = cursor.Prev() {
+			record := Record{}
+			if err := json.Unmarshal(v, &record); err != nil {
+				return err
+			}
+			if !filter.Matches(record) {
+				continue
+			}
+			records = append(records, record)
+			if filter.Limit > 0 && len(records) >= filter.Limit {
+				return nil
+			}
+		}
+		return nil
+	})
+	return records, err
+}
+
+func key(id uint64) []byte {
+	b := make([]byte, 8)
+	binary.BigEndian.PutUint64(b, id)
+	return b
+}
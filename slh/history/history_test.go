@@ -0,0 +1,116 @@
+/*
+Copyright 2018 Adobe
+All Rights Reserved.
+
+NOTICE: Adobe permits you to use, modify, and distribute this file in
+accordance with the terms of the Adobe license agreement accompanying
+it. If you have received this file from a source other than Adobe,
+then your use, modification, or distribution of it requires the prior
+written permission of Adobe.
+*/
+
+package history_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/adobe/sledgehammer/slh/config"
+	"github.com/adobe/sledgehammer/slh/history"
+	"github.com/adobe/sledgehammer/slh/settings"
+	"github.com/adobe/sledgehammer/utils/test"
+	"github.com/coreos/bbolt"
+)
+
+func TestHistory(t *testing.T) {
+	now := time.Date(2018, 10, 1, 12, 0, 0, 0, time.UTC)
+	records := []history.Record{
+		{Time: now.Add(-48 * time.Hour), Registry: "default", Tool: "terraform", Version: "0.11.7", Alias: "terraform"},
+		{Time: now.Add(-24 * time.Hour), Registry: "default", Tool: "node", Version: "10.0.0", Alias: "node"},
+		{Time: now.Add(-1 * time.Hour), Registry: "default", Tool: "terraform", Version: "0.11.8", Alias: "tf"},
+		{Time: now, Registry: "other", Tool: "terraform", Version: "0.11.8", ExitCode: 1},
+	}
+
+	cases := []struct {
+		name     string
+		size     string
+		stored   string
+		filter   history.Filter
+		expected []string
+	}{
+		{
+			name:     "All records, newest first",
+			expected: []string{"0.11.8", "0.11.8", "10.0.0", "0.11.7"},
+		},
+		{
+			name:     "Filter by tool",
+			filter:   history.Filter{Tool: "terraform", Registry: "default"},
+			expected: []string{"0.11.8", "0.11.7"},
+		},
+		{
+			name:     "Filter by alias",
+			filter:   history.Filter{Alias: "tf"},
+			expected: []string{"0.11.8"},
+		},
+		{
+			name:     "Filter by time",
+			filter:   history.Filter{Since: now.Add(-24 * time.Hour)},
+			expected: []string{"0.11.8", "0.11.8", "10.0.0"},
+		},
+		{
+			name:     "Limit",
+			filter:   history.Filter{Limit: 1},
+			expected: []string{"0.11.8"},
+		},
+		{
+			name:     "Retention",
+			size:     "2",
+			expected: []string{"0.11.8", "0.11.8"},
+		},
+		{
+			name:     "Disabled",
+			size:     "0",
+			expected: []string{},
+		},
+		{
+			name:     "Invalid size",
+			stored:   "many",
+			expected: []string{"0.11.8", "0.11.8", "10.0.0", "0.11.7"},
+		},
+	}
+
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			db := test.NewTestDB(t)
+			defer test.Close(db, t)
+			if len(tt.size) > 0 {
+				assert.NoError(t, settings.New(config.Database{DB: db}).Set(settings.HistorySize, tt.size))
+			}
+			if len(tt.stored) > 0 {
+				// values stored by older versions are not validated
+				assert.NoError(t, db.Update(func(tx *bolt.Tx) error {
+					bucket, err := tx.CreateBucketIfNotExists([]byte(settings.BucketKey))
+					if err != nil {
+						return err
+					}
+					return bucket.Put([]byte(settings.HistorySize), []byte(tt.stored))
+				}))
+			}
+
+			h := history.New(config.Database{DB: db})
+			for _, r := range records {
+				assert.NoError(t, h.Add(r))
+			}
+
+			list, err := h.List(tt.filter)
+			assert.NoError(t, err)
+			versions := []string{}
+			for _, r := range list {
+				versions = append(versions, r.Version)
+			}
+			assert.Equal(t, tt.expected, versions)
+		})
+	}
+}
@@ -0,0 +1,102 @@
+/*
+Copyright 2018 Adobe
+All Rights Reserved.
+
+NOTICE: Adobe permits you to use, modify, and distribute this file in
+accordance with the terms of the Adobe license agreement accompanying
+it. If you have received this file from a source other than Adobe,
+then your use, modification, or distribution of it requires the prior
+written permission of Adobe.
+*/
+
+package mount
+
+import (
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/adobe/sledgehammer/utils"
+	docker "github.com/fsouza/go-dockerclient"
+	"github.com/sirupsen/logrus"
+)
+
+// ContainerTarget will return the path of the mount inside of the container
+func (m Mount) ContainerTarget() string {
+	return target(m)
+}
+
+func target(m Mount) string {
+	if len(m.Target) == 0 && m.Type == TypeBind {
+		return utils.ContainerPath(filepath.ToSlash(m.Source))
+	}
+	return m.Target
+}
+
+// HostMounts will prepare all mounts that should be used in the container.
+// The transient mounts are only used for a single invocation, they are skipped if a bind already contains them.
+func HostMounts(mounts []Mount, transient []string) []docker.HostMount {
+	var hostMounts []docker.HostMount
+	for _, m := range withTransient(mounts, transient) {
+		hostMounts = append(hostMounts, docker.HostMount{
+			Source:   m.Source,
+			Target:   target(m),
+			Type:     m.Type,
+			ReadOnly: m.ReadOnly,
+			// MacOS only
+			// Consistency: "delegated",
+		})
+	}
+	return hostMounts
+}
+
+// WorkingDirectory will return the path of the current working directory inside of the container.
+// If no bind contains the working directory, it is not passed to the tool and an empty path is returned.
+func WorkingDirectory(mounts []Mount, transient []string) (string, error) {
+	workspace, err := os.Getwd()
+	if err != nil {
+		return "", err
+	}
+	// the bind with the longest source decides the path, it might be remapped to another target
+	var found *Mount
+	for _, m := range withTransient(mounts, transient) {
+		if m.Type == TypeBind && contains(m.Source, workspace) && (found == nil || len(m.Source) > len(found.Source)) {
+			mo := m
+			found = &mo
+		}
+	}
+	// If no mount can be found that contains the current working directory, then pass no working directory
+	if found == nil {
+		logrus.WithField("dir", workspace).Info("Working directory is not part of a mount, it is not passed to the tool")
+		return "", nil
+	}
+	if len(found.Target) == 0 {
+		return utils.ContainerPath(filepath.ToSlash(workspace)), nil
+	}
+	rel, err := filepath.Rel(found.Source, workspace)
+	if err != nil {
+		return "", err
+	}
+	return path.Join(found.Target, filepath.ToSlash(rel)), nil
+}
+
+// withTransient will add the transient paths as binds, if they are not already part of a bind
+func withTransient(mounts []Mount, transient []string) []Mount {
+	all := append([]Mount{}, mounts...)
+	for _, t := range transient {
+		included := false
+		for _, m := range all {
+			included = included || (m.Type == TypeBind && contains(m.Source, t))
+		}
+		if !included {
+			all = append(all, Bind(t))
+		}
+	}
+	return all
+}
+
+// contains will return true if the path is the given directory or lies below it
+func contains(dir string, path string) bool {
+	return path == dir || strings.HasPrefix(path, pathSeparatedPath(dir))
+}
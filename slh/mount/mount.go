@@ -13,7 +13,9 @@ package mount
 
 import (
 	"encoding/json"
+	"errors"
 	"os"
+	"path"
 	"strings"
 
 	"github.com/adobe/sledgehammer/slh/config"
@@ -26,8 +28,86 @@ var (
 	BucketKey = "mounts"
 	// EntryKey is the name of the entry where the list of mounts are stored
 	EntryKey = "mounts"
+
+	// TypeBind mounts a directory or file of the host
+	TypeBind = "bind"
+	// TypeVolume mounts a named docker volume
+	TypeVolume = "volume"
+	// TypeTmpfs mounts a temporary file system that is removed with the container
+	TypeTmpfs = "tmpfs"
+
+	// ErrorInvalidType will be thrown if the type of a mount is unknown
+	ErrorInvalidType = errors.New("The type of a mount has to be bind, volume or tmpfs")
+	// ErrorSourceRequired will be thrown if a bind or volume mount has no source
+	ErrorSourceRequired = errors.New("The mount requires a source")
+	// ErrorTargetRequired will be thrown if the target of a mount is not an absolute path in the container
+	ErrorTargetRequired = errors.New("The target of the mount has to be an absolute path in the container")
+	// ErrorTmpfsSource will be thrown if a tmpfs mount has a source
+	ErrorTmpfsSource = errors.New("A tmpfs mount has no source")
 )
 
+// Mount is a directory, file, volume or tmpfs that Sledgehammer mounts into the containers of tools
+type Mount struct {
+	Type string `json:"type"`
+	// Source is the path on the host for binds or the name of the volume, tmpfs mounts have no source
+	Source string `json:"source,omitempty"`
+	// Target is the path in the container, binds are mounted at the same path as on the host if it is empty
+	Target   string `json:"target,omitempty"`
+	ReadOnly bool   `json:"readOnly,omitempty"`
+	// Registry restricts the mount to the tools of the registry, empty for all registries
+	Registry string `json:"registry,omitempty"`
+	// Tool restricts the mount to the tool, empty for all tools
+	Tool string `json:"tool,omitempty"`
+}
+
+// Bind will return a writable mount of the given host path at the same path in the container for all tools
+func Bind(path string) Mount {
+	return Mount{
+		Type:   TypeBind,
+		Source: path,
+	}
+}
+
+// Validate will check if the mount can be passed to docker
+func (m Mount) Validate() error {
+	switch m.Type {
+	case TypeBind:
+		if len(m.Source) == 0 {
+			return ErrorSourceRequired
+		}
+	case TypeVolume:
+		if len(m.Source) == 0 {
+			return ErrorSourceRequired
+		}
+		if len(m.Target) == 0 {
+			return ErrorTargetRequired
+		}
+	case TypeTmpfs:
+		if len(m.Source) > 0 {
+			return ErrorTmpfsSource
+		}
+		if len(m.Target) == 0 {
+			return ErrorTargetRequired
+		}
+	default:
+		return ErrorInvalidType
+	}
+	if len(m.Target) > 0 && !path.IsAbs(m.Target) {
+		return ErrorTargetRequired
+	}
+	return nil
+}
+
+// Plain will return true if the mount is a writable bind at the same path for all tools, as mounts have been before they got options
+func (m Mount) Plain() bool {
+	return m.Type == TypeBind && len(m.Target) == 0 && !m.ReadOnly && len(m.Registry) == 0 && len(m.Tool) == 0
+}
+
+// Matches will return true if the mount is used for the given tool
+func (m Mount) Matches(registry string, tool string) bool {
+	return (len(m.Registry) == 0 || m.Registry == registry) && (len(m.Tool) == 0 || m.Tool == tool)
+}
+
 // Mounts is a struct that can be used to access the mounts on this system
 type Mounts struct {
 	config.Database
@@ -43,25 +123,40 @@ func New(db config.Database) Mounts {
 }
 
 // initDB will Create the mounts bucket in the database if it does not exist.
+// Mounts that have been stored as plain paths are migrated to mounts with options.
 func initDB(db *bolt.DB) {
 	db.Update(func(tx *bolt.Tx) error {
 		logrus.WithField("bucket", BucketKey).Debug("Trying to create the mount bucket")
-		tx.CreateBucketIfNotExists([]byte(BucketKey))
-		return nil
+		bucket, err := tx.CreateBucketIfNotExists([]byte(BucketKey))
+		if err != nil || bucket == nil {
+			return err
+		}
+		byteMounts := bucket.Get([]byte(EntryKey))
+		paths := []string{}
+		if byteMounts == nil || json.Unmarshal(byteMounts, &paths) != nil || len(paths) == 0 {
+			return nil
+		}
+		logrus.WithField("mounts", paths).Info("Migrating mounts")
+		mounts, err := decode(byteMounts)
+		if err != nil {
+			return err
+		}
+		return put(bucket, mounts)
 	})
 }
 
 // List will return the current mounts.
-func (m *Mounts) List() ([]string, error) {
+func (m *Mounts) List() ([]Mount, error) {
 	logrus.Debug("Listing all mounts")
-	mounts := []string{}
+	mounts := []Mount{}
 
 	err := m.DB.View(func(tx *bolt.Tx) error {
 		bucket := tx.Bucket([]byte(BucketKey))
 		if bucket != nil {
 			byteMounts := bucket.Get([]byte(EntryKey))
 			if byteMounts != nil {
-				err := json.Unmarshal(byteMounts, &mounts)
+				var err error
+				mounts, err = decode(byteMounts)
 				if err != nil {
 					return err
 				}
@@ -73,11 +168,17 @@ func (m *Mounts) List() ([]string, error) {
 	return mounts, err
 }
 
-// Add will add the given path as a mount to Sledgehammer.
-// Tools will then be able to access files under this path while they are running
-func (m *Mounts) Add(mount ...string) error {
+// Add will add the given mounts to Sledgehammer.
+// Tools will then be able to access files under the mounted paths while they are running.
+// A mount replaces the mount with the same target for the same tools, plain mounts that are part of a plain mount are dropped.
+func (m *Mounts) Add(mount ...Mount) error {
+	for _, mo := range mount {
+		if err := mo.Validate(); err != nil {
+			return err
+		}
+	}
 	logrus.WithField("mount", mount).Info("Adding new mount")
-	mounts := []string{}
+	mounts := []Mount{}
 	err := m.DB.Update(func(tx *bolt.Tx) error {
 
 		bucket, err := tx.CreateBucketIfNotExists([]byte(BucketKey))
@@ -87,76 +188,134 @@ func (m *Mounts) Add(mount ...string) error {
 		if bucket != nil {
 			byteMounts := bucket.Get([]byte(EntryKey))
 			if byteMounts != nil {
-				err := json.Unmarshal(byteMounts, &mounts)
+				mounts, err = decode(byteMounts)
 				if err != nil {
 					return err
 				}
 			}
 			for _, mo := range mount {
-				if !hasMount(mounts, mo) {
-					mounts = append(mounts, mo)
-
-					//  now remove all mounts that are included in the new mount
-					for i := len(mounts) - 1; i >= 0; i-- {
-						if mounts[i] != mo && strings.HasPrefix(pathSeparatedPath(mounts[i]), pathSeparatedPath(mo)) {
-							// do it in the background, otherwise we block the transaction
-							logrus.WithField("mount", m).WithField("sub", m).Debug("Removing sub since it is already included in mount")
-							mounts = append(mounts[:i], mounts[i+1:]...)
-						}
-					}
-
-					b, err := json.Marshal(mounts)
-					if err != nil {
-						return err
-					}
-					err = bucket.Put([]byte(EntryKey), b)
-					if err != nil {
-						return err
+				if mo.Plain() && hasMount(mounts, mo.Source) {
+					continue
+				}
+				for i := len(mounts) - 1; i >= 0; i-- {
+					// remove the mount with the same target and all plain mounts that are included in the new mount
+					sameTarget := target(mounts[i]) == target(mo) && mounts[i].Registry == mo.Registry && mounts[i].Tool == mo.Tool
+					included := mo.Plain() && mounts[i].Plain() && strings.HasPrefix(pathSeparatedPath(mounts[i].Source), pathSeparatedPath(mo.Source))
+					if sameTarget || included {
+						logrus.WithField("mount", mo.Source).WithField("sub", mounts[i].Source).Debug("Removing mount since it is replaced by the new mount")
+						mounts = append(mounts[:i], mounts[i+1:]...)
 					}
 				}
+				mounts = append(mounts, mo)
 			}
+			return put(bucket, mounts)
 		}
 		return nil
 	})
 	return err
 }
 
-// Remove will remove the given mounts from Sledgehammer if possible.
+// Remove will remove the mounts with the given source or target that are restricted to the given registry and tool.
 // It requires an exact match for the mount
-func (m *Mounts) Remove(mount string) error {
-	logrus.WithField("mount", mount).Debug("Removing mount")
-	mounts := []string{}
+func (m *Mounts) Remove(registry string, tool string, paths ...string) error {
 	return m.DB.Update(func(tx *bolt.Tx) error {
-		bucket := tx.Bucket([]byte(BucketKey))
+		bucket, err := tx.CreateBucketIfNotExists([]byte(BucketKey))
+		if err != nil {
+			return err
+		}
 		if bucket != nil {
+			mounts := []Mount{}
 			byteMounts := bucket.Get([]byte(EntryKey))
 			if byteMounts != nil {
-				err := json.Unmarshal(byteMounts, &mounts)
+				mounts, err = decode(byteMounts)
 				if err != nil {
 					return err
 				}
 			}
-			if hasMount(mounts, mount) {
-				for i := len(mounts) - 1; i >= 0; i-- {
-					if mounts[i] == mount {
+			for i := len(mounts) - 1; i >= 0; i-- {
+				if mounts[i].Registry != registry || mounts[i].Tool != tool {
+					continue
+				}
+				for _, p := range paths {
+					if mounts[i].Source == p || mounts[i].Target == p {
 						mounts = append(mounts[:i], mounts[i+1:]...)
+						break
 					}
 				}
-				b, err := json.Marshal(mounts)
-				if err != nil {
-					return err
-				}
-				logrus.WithField("mount", mount).Debug("Removed mount")
-				bucket.Put([]byte(EntryKey), b)
 			}
+			logrus.WithField("mount", paths).Debug("Removed mount")
+			return put(bucket, mounts)
 		}
 		return nil
 	})
 }
 
-func hasMount(mounts []string, mount string) bool {
+// Select will return the mounts that are used for the given tool.
+// If mounts have the same target, the mount of the tool takes precedence over the mount of the registry, which takes precedence over the mount for all tools.
+func Select(mounts []Mount, registry string, tool string) []Mount {
+	specificity := func(m Mount) int {
+		s := 0
+		if len(m.Tool) > 0 {
+			s += 2
+		}
+		if len(m.Registry) > 0 {
+			s++
+		}
+		return s
+	}
+	selected := []Mount{}
+	for _, m := range mounts {
+		if !m.Matches(registry, tool) {
+			continue
+		}
+		replaced := false
+		for i, s := range selected {
+			if target(s) == target(m) {
+				if specificity(m) > specificity(s) {
+					selected[i] = m
+				}
+				replaced = true
+			}
+		}
+		if !replaced {
+			selected = append(selected, m)
+		}
+	}
+	return selected
+}
+
+// decode will decode the stored mounts, mounts that have been stored as plain paths are converted to binds
+func decode(b []byte) ([]Mount, error) {
+	mounts := []Mount{}
+	if err := json.Unmarshal(b, &mounts); err == nil {
+		return mounts, nil
+	}
+	paths := []string{}
+	if err := json.Unmarshal(b, &paths); err != nil {
+		return nil, err
+	}
+	// the failed decoding might have added empty mounts already
+	mounts = []Mount{}
+	for _, p := range paths {
+		mounts = append(mounts, Bind(p))
+	}
+	return mounts, nil
+}
+
+func put(bucket *bolt.Bucket, mounts []Mount) error {
+	b, err := json.Marshal(mounts)
+	if err != nil {
+		return err
+	}
+	return bucket.Put([]byte(EntryKey), b)
+}
+
+func hasMount(mounts []Mount, mount string) bool {
 	for _, m := range mounts {
-		if m == mount || strings.HasPrefix(pathSeparatedPath(mount), pathSeparatedPath(m)) {
+		if !m.Plain() {
+			continue
+		}
+		if m.Source == mount || strings.HasPrefix(pathSeparatedPath(mount), pathSeparatedPath(m.Source)) {
 			logrus.WithField("toFind", mount).WithField("mounts", mounts).Debug("Found mount in mounts")
 			return true
 		}
@@ -12,8 +12,10 @@ written permission of Adobe.
 package mount_test
 
 import (
+	"encoding/json"
 	"testing"
 
+	"github.com/coreos/bbolt"
 	"github.com/docker/docker/pkg/homedir"
 	"github.com/stretchr/testify/assert"
 
@@ -61,7 +63,7 @@ func TestList(t *testing.T) {
 
 			if len(tt.previous) > 0 {
 				for _, m := range tt.previous {
-					mounts.Add(m)
+					mounts.Add(mount.Bind(m))
 				}
 			}
 
@@ -69,7 +71,7 @@ func TestList(t *testing.T) {
 			if err != nil {
 				t.Fatal(err)
 			}
-			assert.EqualValues(t, tt.expected, ms)
+			assert.EqualValues(t, binds(tt.expected...), ms)
 		})
 	}
 }
@@ -121,11 +123,11 @@ func TestAdd(t *testing.T) {
 
 			if len(tt.previous) > 0 {
 				for _, m := range tt.previous {
-					mounts.Add(m)
+					mounts.Add(mount.Bind(m))
 				}
 			}
 
-			err := mounts.Add(tt.adding...)
+			err := mounts.Add(binds(tt.adding...)...)
 			if err != nil {
 				t.Fatal(err)
 			}
@@ -134,7 +136,7 @@ func TestAdd(t *testing.T) {
 			if err != nil {
 				t.Fatal(err)
 			}
-			assert.Equal(t, tt.expected, ms)
+			assert.Equal(t, binds(tt.expected...), ms)
 		})
 	}
 }
@@ -187,16 +189,87 @@ func TestRemove(t *testing.T) {
 
 			if len(tt.previous) > 0 {
 				for _, m := range tt.previous {
-					mounts.Add(m)
+					mounts.Add(mount.Bind(m))
 				}
 			}
 
 			for _, m := range tt.removing {
-				err := mounts.Remove(m)
+				err := mounts.Remove("", "", m)
 				if err != nil {
 					t.Fatal(err)
 				}
 			}
+			ms, err := mounts.List()
+			if err != nil {
+				t.Fatal(err)
+			}
+			assert.Equal(t, binds(tt.expected...), ms)
+		})
+	}
+}
+
+func TestAddOptions(t *testing.T) {
+
+	src := mount.Mount{Type: mount.TypeBind, Source: "/tmp/src", Target: "/src", ReadOnly: true}
+	cache := mount.Mount{Type: mount.TypeVolume, Source: "cache", Target: "/root/.cache", Tool: "terraform"}
+
+	cases := []struct {
+		name     string
+		expected []mount.Mount
+		previous []mount.Mount
+		adding   mount.Mount
+		err      error
+	}{
+		{
+			name:     "Adding a read-only bind at another target",
+			expected: []mount.Mount{src},
+			adding:   src,
+		},
+		{
+			name:     "Bind within a plain mount is kept if it has options",
+			previous: []mount.Mount{mount.Bind("/tmp")},
+			expected: []mount.Mount{mount.Bind("/tmp"), src},
+			adding:   src,
+		},
+		{
+			name:     "Plain mount keeps binds with options",
+			previous: []mount.Mount{src},
+			expected: []mount.Mount{src, mount.Bind("/tmp")},
+			adding:   mount.Bind("/tmp"),
+		},
+		{
+			name:     "Mount with the same target replaces the previous mount",
+			previous: []mount.Mount{src},
+			expected: []mount.Mount{{Type: mount.TypeTmpfs, Target: "/src"}},
+			adding:   mount.Mount{Type: mount.TypeTmpfs, Target: "/src"},
+		},
+		{
+			name:     "Mount with the same target for another tool is kept",
+			previous: []mount.Mount{{Type: mount.TypeVolume, Source: "cache", Target: "/root/.cache"}},
+			expected: []mount.Mount{{Type: mount.TypeVolume, Source: "cache", Target: "/root/.cache"}, cache},
+			adding:   cache,
+		},
+		{
+			name:     "Invalid mount",
+			expected: []mount.Mount{},
+			adding:   mount.Mount{Type: mount.TypeVolume, Source: "cache"},
+			err:      mount.ErrorTargetRequired,
+		},
+	}
+
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			db := test.NewTestDB(t)
+			defer test.Close(db, t)
+
+			mounts := mount.New(config.Database{DB: db})
+			if err := mounts.Add(tt.previous...); err != nil {
+				t.Fatal(err)
+			}
+
+			err := mounts.Add(tt.adding)
+			assert.Equal(t, tt.err, err)
+
 			ms, err := mounts.List()
 			if err != nil {
 				t.Fatal(err)
@@ -205,3 +278,148 @@ func TestRemove(t *testing.T) {
 		})
 	}
 }
+
+func TestRemoveScoped(t *testing.T) {
+	db := test.NewTestDB(t)
+	defer test.Close(db, t)
+
+	all := mount.Mount{Type: mount.TypeVolume, Source: "cache", Target: "/root/.cache"}
+	scoped := mount.Mount{Type: mount.TypeVolume, Source: "cache", Target: "/root/.cache", Registry: "default", Tool: "terraform"}
+	tmp := mount.Mount{Type: mount.TypeTmpfs, Target: "/scratch"}
+
+	mounts := mount.New(config.Database{DB: db})
+	if err := mounts.Add(all, scoped, tmp); err != nil {
+		t.Fatal(err)
+	}
+
+	assert.NoError(t, mounts.Remove("default", "terraform", "cache"))
+	assert.NoError(t, mounts.Remove("", "", "/scratch"))
+
+	ms, err := mounts.List()
+	assert.NoError(t, err)
+	assert.Equal(t, []mount.Mount{all}, ms)
+}
+
+func TestMigration(t *testing.T) {
+	db := test.NewTestDB(t)
+	defer test.Close(db, t)
+
+	// mounts have been stored as a list of paths before they got options
+	err := db.Update(func(tx *bolt.Tx) error {
+		bucket, err := tx.CreateBucketIfNotExists([]byte(mount.BucketKey))
+		if err != nil {
+			return err
+		}
+		b, err := json.Marshal([]string{"/tmp", "/foo"})
+		if err != nil {
+			return err
+		}
+		return bucket.Put([]byte(mount.EntryKey), b)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	mounts := mount.New(config.Database{DB: db})
+	ms, err := mounts.List()
+	assert.NoError(t, err)
+	assert.Equal(t, binds("/tmp", "/foo"), ms)
+
+	var stored []mount.Mount
+	err = db.View(func(tx *bolt.Tx) error {
+		return json.Unmarshal(tx.Bucket([]byte(mount.BucketKey)).Get([]byte(mount.EntryKey)), &stored)
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, binds("/tmp", "/foo"), stored)
+}
+
+func TestValidate(t *testing.T) {
+	cases := []struct {
+		name  string
+		mount mount.Mount
+		err   error
+	}{
+		{
+			name:  "Plain bind",
+			mount: mount.Bind("/tmp"),
+		},
+		{
+			name:  "Bind at a relative target",
+			mount: mount.Mount{Type: mount.TypeBind, Source: "/tmp", Target: "tmp"},
+			err:   mount.ErrorTargetRequired,
+		},
+		{
+			name:  "Volume without source",
+			mount: mount.Mount{Type: mount.TypeVolume, Target: "/cache"},
+			err:   mount.ErrorSourceRequired,
+		},
+		{
+			name:  "Tmpfs",
+			mount: mount.Mount{Type: mount.TypeTmpfs, Target: "/scratch", ReadOnly: true},
+		},
+		{
+			name:  "Tmpfs with source",
+			mount: mount.Mount{Type: mount.TypeTmpfs, Source: "/tmp", Target: "/scratch"},
+			err:   mount.ErrorTmpfsSource,
+		},
+		{
+			name:  "Unknown type",
+			mount: mount.Mount{Type: "nfs", Source: "/tmp"},
+			err:   mount.ErrorInvalidType,
+		},
+	}
+
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.err, tt.mount.Validate())
+		})
+	}
+}
+
+func TestSelect(t *testing.T) {
+	all := mount.Mount{Type: mount.TypeVolume, Source: "cache", Target: "/root/.cache"}
+	registry := mount.Mount{Type: mount.TypeVolume, Source: "registry-cache", Target: "/root/.cache", Registry: "default"}
+	tool := mount.Mount{Type: mount.TypeVolume, Source: "tool-cache", Target: "/root/.cache", Tool: "terraform"}
+	other := mount.Mount{Type: mount.TypeTmpfs, Target: "/scratch", Registry: "other"}
+
+	cases := []struct {
+		name     string
+		registry string
+		tool     string
+		expected []mount.Mount
+	}{
+		{
+			name:     "Mount of the tool takes precedence",
+			registry: "default",
+			tool:     "terraform",
+			expected: []mount.Mount{mount.Bind("/tmp"), tool},
+		},
+		{
+			name:     "Mount of the registry takes precedence",
+			registry: "default",
+			tool:     "kubectl",
+			expected: []mount.Mount{mount.Bind("/tmp"), registry},
+		},
+		{
+			name:     "Mount of another registry",
+			registry: "other",
+			tool:     "kubectl",
+			expected: []mount.Mount{mount.Bind("/tmp"), all, other},
+		},
+	}
+
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			selected := mount.Select([]mount.Mount{mount.Bind("/tmp"), tool, all, registry, other}, tt.registry, tt.tool)
+			assert.Equal(t, tt.expected, selected)
+		})
+	}
+}
+
+func binds(paths ...string) []mount.Mount {
+	mounts := []mount.Mount{}
+	for _, p := range paths {
+		mounts = append(mounts, mount.Bind(p))
+	}
+	return mounts
+}
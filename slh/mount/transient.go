@@ -0,0 +1,59 @@
+/*
+Copyright 2018 Adobe
+All Rights Reserved.
+
+NOTICE: Adobe permits you to use, modify, and distribute this file in
+accordance with the terms of the Adobe license agreement accompanying
+it. If you have received this file from a source other than Adobe,
+then your use, modification, or distribution of it requires the prior
+written permission of Adobe.
+*/
+
+package mount
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/adobe/sledgehammer/utils"
+	"github.com/sirupsen/logrus"
+)
+
+// NotFoundError will be thrown if a transient mount does not exist on the host
+type NotFoundError struct {
+	Mount string
+}
+
+func (e *NotFoundError) Error() string {
+	return fmt.Sprintf("The mount %s does not exist", e.Mount)
+}
+
+// Transient will return the mounts that are only used for a single invocation, they are never stored.
+// The list contains paths separated like the PATH variable, if workDir is true the current working directory is added.
+func Transient(list string, workDir bool) ([]string, error) {
+	mounts := []string{}
+	for _, m := range filepath.SplitList(list) {
+		if len(m) == 0 {
+			continue
+		}
+		m = utils.ImportPath(m)
+		exists, err := utils.Exists(m)
+		if err != nil {
+			return nil, err
+		}
+		if !exists {
+			return nil, &NotFoundError{Mount: m}
+		}
+		mounts = append(mounts, m)
+	}
+	if workDir {
+		wd, err := os.Getwd()
+		if err != nil {
+			return nil, err
+		}
+		mounts = append(mounts, utils.ImportPath(wd))
+	}
+	logrus.WithField("mounts", mounts).Debug("Found transient mounts")
+	return mounts, nil
+}
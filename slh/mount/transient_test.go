@@ -0,0 +1,78 @@
+/*
+Copyright 2018 Adobe
+All Rights Reserved.
+
+NOTICE: Adobe permits you to use, modify, and distribute this file in
+accordance with the terms of the Adobe license agreement accompanying
+it. If you have received this file from a source other than Adobe,
+then your use, modification, or distribution of it requires the prior
+written permission of Adobe.
+*/
+
+package mount_test
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/adobe/sledgehammer/slh/mount"
+	"github.com/adobe/sledgehammer/utils/test"
+)
+
+func TestTransient(t *testing.T) {
+	dir := test.NewTmpDir(t)
+	defer test.DeleteTmpDir(dir, t)
+	other := filepath.Join(dir, "other")
+	if err := os.Mkdir(other, 0755); err != nil {
+		t.Fatal(err)
+	}
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cases := []struct {
+		name     string
+		list     []string
+		workDir  bool
+		expected []string
+		err      error
+	}{
+		{
+			name:     "No transient mounts",
+			expected: []string{},
+		},
+		{
+			name:     "Mounts from the list",
+			list:     []string{dir, "", other},
+			expected: []string{dir, other},
+		},
+		{
+			name:     "Working directory",
+			list:     []string{dir},
+			workDir:  true,
+			expected: []string{dir, wd},
+		},
+		{
+			name: "Missing mount",
+			list: []string{filepath.Join(dir, "missing")},
+			err:  &mount.NotFoundError{Mount: filepath.Join(dir, "missing")},
+		},
+	}
+
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			mounts, err := mount.Transient(strings.Join(tt.list, string(os.PathListSeparator)), tt.workDir)
+			if tt.err != nil {
+				assert.Equal(t, tt.err, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expected, mounts)
+		})
+	}
+}
@@ -27,6 +27,7 @@ type Output struct {
 	Writer       io.Writer
 	RenderFunc   func()
 	ProgressFunc func(string)
+	PullFunc     func(PullEvent)
 	Element      Renderer
 	ExitCode     int
 }
@@ -43,6 +44,13 @@ func (o *Output) Progress(text string) {
 	}
 }
 
+// Pull will render the progress of an image pull
+func (o *Output) Pull(event PullEvent) {
+	if o != nil && o.PullFunc != nil {
+		o.PullFunc(event)
+	}
+}
+
 func (l *Output) RenderTable() {
 	tw := &tabwriter.Writer{}
 	tw = tw.Init(l.Writer, 0, 0, 3, ' ', 0)
@@ -66,3 +74,18 @@ func (l *Output) RenderJSON() {
 func (o *Output) Set(el Renderer) {
 	o.Element = el
 }
+
+// ByteSize will format the given number of bytes in a human readable way
+func ByteSize(b int64) string {
+	units := []string{"B", "kB", "MB", "GB", "TB"}
+	size := float64(b)
+	i := 0
+	for size >= 1000 && i < len(units)-1 {
+		size /= 1000
+		i++
+	}
+	if i == 0 {
+		return fmt.Sprintf("%d%s", b, units[i])
+	}
+	return fmt.Sprintf("%.1f%s", size, units[i])
+}
@@ -0,0 +1,110 @@
+/*
+Copyright 2018 Adobe
+All Rights Reserved.
+
+NOTICE: Adobe permits you to use, modify, and distribute this file in
+accordance with the terms of the Adobe license agreement accompanying
+it. If you have received this file from a source other than Adobe,
+then your use, modification, or distribution of it requires the prior
+written permission of Adobe.
+*/
+
+package out
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+)
+
+// PullEvent is a single progress message of an image pull.
+// Events without a layer describe the pull of the whole image.
+type PullEvent struct {
+	Image   string `json:"image"`
+	Layer   string `json:"layer,omitempty"`
+	Status  string `json:"status"`
+	Current int64  `json:"current,omitempty"`
+	Total   int64  `json:"total,omitempty"`
+}
+
+// PullBars renders pull events as one progress bar per layer.
+// If the writer is no terminal, only changes of the status of a layer are written.
+type PullBars struct {
+	Writer   io.Writer
+	Terminal bool
+	Width    int
+	layers   []string
+	events   map[string]PullEvent
+	lines    int
+	mutex    sync.Mutex
+}
+
+// NewPullBars will create a new renderer for pull events
+func NewPullBars(w io.Writer, terminal bool) *PullBars {
+	return &PullBars{
+		Writer:   w,
+		Terminal: terminal,
+		Width:    30,
+		events:   map[string]PullEvent{},
+	}
+}
+
+// Event will render the given pull event
+func (p *PullBars) Event(e PullEvent) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	if len(e.Layer) == 0 {
+		fmt.Fprintln(p.Writer, e.Status)
+		// the bars are drawn again below the message
+		p.lines = 0
+		return
+	}
+	last, found := p.events[e.Layer]
+	if !found {
+		p.layers = append(p.layers, e.Layer)
+	}
+	p.events[e.Layer] = e
+	if !p.Terminal {
+		if !found || last.Status != e.Status {
+			fmt.Fprintf(p.Writer, "%s: %s\n", e.Layer, e.Status)
+		}
+		return
+	}
+	if p.lines > 0 {
+		// move the cursor to the first bar
+		fmt.Fprintf(p.Writer, "\x1b[%dA", p.lines)
+	}
+	for _, layer := range p.layers {
+		fmt.Fprintf(p.Writer, "\x1b[2K%s\n", p.bar(p.events[layer]))
+	}
+	p.lines = len(p.layers)
+}
+
+func (p *PullBars) bar(e PullEvent) string {
+	line := fmt.Sprintf("%s: %-12s", e.Layer, e.Status)
+	if e.Total <= 0 {
+		return strings.TrimSpace(line)
+	}
+	done := int(int64(p.Width) * e.Current / e.Total)
+	if done > p.Width {
+		done = p.Width
+	}
+	bar := strings.Repeat("=", done)
+	if done < p.Width {
+		bar += ">" + strings.Repeat(" ", p.Width-done-1)
+	}
+	return fmt.Sprintf("%s [%s] %s/%s", line, bar, ByteSize(e.Current), ByteSize(e.Total))
+}
+
+// JSONPullFunc will return a function that writes every pull event as a single line of json
+func JSONPullFunc(w io.Writer) func(PullEvent) {
+	mutex := sync.Mutex{}
+	return func(e PullEvent) {
+		mutex.Lock()
+		defer mutex.Unlock()
+		bb, _ := json.Marshal(map[string]interface{}{"pull": e})
+		fmt.Fprintln(w, string(bb))
+	}
+}
@@ -0,0 +1,148 @@
+/*
+Copyright 2018 Adobe
+All Rights Reserved.
+
+NOTICE: Adobe permits you to use, modify, and distribute this file in
+accordance with the terms of the Adobe license agreement accompanying
+it. If you have received this file from a source other than Adobe,
+then your use, modification, or distribution of it requires the prior
+written permission of Adobe.
+*/
+
+package permission
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/adobe/sledgehammer/slh/config"
+	"github.com/adobe/sledgehammer/slh/forward"
+	"github.com/adobe/sledgehammer/slh/tool"
+	bolt "github.com/coreos/bbolt"
+	"github.com/sirupsen/logrus"
+)
+
+var (
+	// BucketKey is the name of the bucket where the permissions the user approved are stored
+	BucketKey = "permissions"
+)
+
+// MissingError will be thrown if the tool requests permissions the user did not approve
+type MissingError struct {
+	Registry    string
+	Tool        string
+	Permissions []string
+}
+
+func (e *MissingError) Error() string {
+	return fmt.Sprintf("The tool %s/%s requests %s. Allow it with 'slh allow %s/%s'", e.Registry, e.Tool, Describe(e.Permissions), e.Registry, e.Tool)
+}
+
+// Describe will describe the permissions in a way a user understands
+func Describe(permissions []string) string {
+	parts := []string{}
+	for _, p := range permissions {
+		switch {
+		case p == tool.PermissionDocker:
+			parts = append(parts, "access to the docker daemon")
+		case p == tool.PermissionPrivileged:
+			parts = append(parts, "privileged mode")
+		case strings.HasPrefix(p, tool.PermissionPath):
+			parts = append(parts, "access to "+strings.TrimPrefix(p, tool.PermissionPath)+" on the host")
+		case strings.HasPrefix(p, tool.PermissionForward):
+			parts = append(parts, "access to "+forward.Describe([]string{strings.TrimPrefix(p, tool.PermissionForward)}))
+		default:
+			parts = append(parts, p)
+		}
+	}
+	return strings.Join(parts, ", ")
+}
+
+// Missing will return the requested permissions that have not been approved
+func Missing(requested []string, approved []string) []string {
+	missing := []string{}
+	for _, r := range requested {
+		found := false
+		for _, a := range approved {
+			found = found || a == r
+		}
+		if !found {
+			missing = append(missing, r)
+		}
+	}
+	return missing
+}
+
+// Approvals is the struct that can be used to access the permissions the user approved for tools
+type Approvals struct {
+	config.Database
+}
+
+// New will create a new Approvals struct based on the given bolt database.
+func New(db config.Database) *Approvals {
+	return &Approvals{
+		Database: db,
+	}
+}
+
+// Approved will return the permissions the user approved for the given tool
+func (a *Approvals) Approved(registry string, name string) ([]string, error) {
+	var approved []string
+	err := a.DB.View(func(tx *bolt.Tx) error {
+		var err error
+		approved, err = get(tx, registry, name)
+		return err
+	})
+	return approved, err
+}
+
+// Approve will record that the user approved the given permissions for the tool, in addition to the permissions that have been approved before.
+// If the database has been opened read-only, the approval is written when the database is closed.
+func (a *Approvals) Approve(registry string, name string, permissions []string) error {
+	logrus.WithField("tool", key(registry, name)).WithField("permissions", permissions).Info("Approving permissions")
+	return a.Update(func(tx *bolt.Tx) error {
+		approved, err := get(tx, registry, name)
+		if err != nil {
+			return err
+		}
+		approved = append(approved, Missing(permissions, approved)...)
+		sort.Strings(approved)
+		b, err := json.Marshal(approved)
+		if err != nil {
+			return err
+		}
+		bucket, err := tx.CreateBucketIfNotExists([]byte(BucketKey))
+		if err != nil {
+			return err
+		}
+		return bucket.Put([]byte(key(registry, name)), b)
+	})
+}
+
+// Revoke will remove all permissions of the given tool
+func (a *Approvals) Revoke(registry string, name string) error {
+	logrus.WithField("tool", key(registry, name)).Info("Revoking permissions")
+	return a.Update(func(tx *bolt.Tx) error {
+		if bucket := tx.Bucket([]byte(BucketKey)); bucket != nil {
+			return bucket.Delete([]byte(key(registry, name)))
+		}
+		return nil
+	})
+}
+
+// get will return the approved permissions of the tool
+func get(tx *bolt.Tx, registry string, name string) ([]string, error) {
+	approved := []string{}
+	if bucket := tx.Bucket([]byte(BucketKey)); bucket != nil {
+		if b := bucket.Get([]byte(key(registry, name))); b != nil {
+			return approved, json.Unmarshal(b, &approved)
+		}
+	}
+	return approved, nil
+}
+
+func key(registry string, name string) string {
+	return registry + "/" + name
+}
@@ -0,0 +1,78 @@
+/*
+Copyright 2018 Adobe
+All Rights Reserved.
+
+NOTICE: Adobe permits you to use, modify, and distribute this file in
+accordance with the terms of the Adobe license agreement accompanying
+it. If you have received this file from a source other than Adobe,
+then your use, modification, or distribution of it requires the prior
+written permission of Adobe.
+*/
+
+package permission_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/adobe/sledgehammer/slh/config"
+	"github.com/adobe/sledgehammer/slh/permission"
+	"github.com/adobe/sledgehammer/utils/db"
+	"github.com/adobe/sledgehammer/utils/test"
+)
+
+func TestApprovals(t *testing.T) {
+	database := test.NewTestDB(t)
+	defer test.Close(database, t)
+
+	approvals := permission.New(config.Database{DB: database})
+
+	approved, err := approvals.Approved("default", "git")
+	assert.NoError(t, err)
+	assert.Empty(t, approved)
+
+	assert.NoError(t, approvals.Approve("default", "git", []string{"forward:ssh"}))
+	assert.NoError(t, approvals.Approve("default", "git", []string{"docker", "forward:ssh"}))
+	approved, err = approvals.Approved("default", "git")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"docker", "forward:ssh"}, approved)
+
+	approved, err = approvals.Approved("other", "git")
+	assert.NoError(t, err)
+	assert.Empty(t, approved)
+
+	assert.NoError(t, approvals.Revoke("default", "git"))
+	approved, err = approvals.Approved("default", "git")
+	assert.NoError(t, err)
+	assert.Empty(t, approved)
+}
+
+func TestDeferredApproval(t *testing.T) {
+	database := test.NewTestDB(t)
+	defer test.Close(database, t)
+
+	batch := &db.Batch{}
+	approvals := permission.New(config.Database{DB: database, Batch: batch})
+	assert.NoError(t, approvals.Approve("default", "helm", []string{"forward:docker:ghcr.io"}))
+
+	approved, err := approvals.Approved("default", "helm")
+	assert.NoError(t, err)
+	assert.Empty(t, approved)
+
+	assert.NoError(t, batch.Apply(database))
+	approved, err = approvals.Approved("default", "helm")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"forward:docker:ghcr.io"}, approved)
+}
+
+func TestMissing(t *testing.T) {
+	assert.Equal(t, []string{"docker"}, permission.Missing([]string{"forward:ssh", "docker"}, []string{"forward:git", "forward:ssh"}))
+	assert.Empty(t, permission.Missing([]string{"privileged"}, []string{"privileged"}))
+	assert.Empty(t, permission.Missing(nil, nil))
+}
+
+func TestMissingError(t *testing.T) {
+	err := &permission.MissingError{Registry: "default", Tool: "git", Permissions: []string{"docker", "forward:ssh", "forward:docker:ghcr.io", "path:/etc", "privileged"}}
+	assert.Equal(t, "The tool default/git requests access to the docker daemon, access to your ssh agent and known hosts, access to your docker credentials for ghcr.io, access to /etc on the host, privileged mode. Allow it with 'slh allow default/git'", err.Error())
+}
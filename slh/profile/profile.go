@@ -0,0 +1,138 @@
+/*
+Copyright 2018 Adobe
+All Rights Reserved.
+
+NOTICE: Adobe permits you to use, modify, and distribute this file in
+accordance with the terms of the Adobe license agreement accompanying
+it. If you have received this file from a source other than Adobe,
+then your use, modification, or distribution of it requires the prior
+written permission of Adobe.
+*/
+
+package profile
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"sync"
+	"text/tabwriter"
+	"time"
+)
+
+var (
+	// processStart is the time the process has been started, or at least when this package has been initialized
+	processStart = time.Now()
+)
+
+// Span is a named phase of an invocation
+type Span struct {
+	Name     string        `json:"name"`
+	Start    time.Duration `json:"start"`
+	Duration time.Duration `json:"duration"`
+	// Depth is the number of spans this span is nested in
+	Depth int `json:"depth"`
+}
+
+// Profile records the duration of named phases of an invocation.
+// All methods can be called on a nil profile, they will not record anything then.
+type Profile struct {
+	Start time.Time
+	spans []Span
+	mutex sync.Mutex
+}
+
+// New will create a new profile that measures from the start of the process
+func New() *Profile {
+	return &Profile{
+		Start: processStart,
+	}
+}
+
+// Span will start a span with the given name, the returned function has to be called when the phase is done
+func (p *Profile) Span(name string) func() {
+	if p == nil {
+		return func() {}
+	}
+	start := time.Now()
+	return func() {
+		p.Add(name, start, time.Now())
+	}
+}
+
+// Add will record a span that has already been measured
+func (p *Profile) Add(name string, start time.Time, end time.Time) {
+	if p == nil {
+		return
+	}
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	p.spans = append(p.spans, Span{
+		Name:     name,
+		Start:    start.Sub(p.Start),
+		Duration: end.Sub(start),
+	})
+}
+
+// Spans will return all recorded spans ordered by their start.
+// Spans that are started within another span are nested in it.
+func (p *Profile) Spans() []Span {
+	if p == nil {
+		return nil
+	}
+	p.mutex.Lock()
+	spans := make([]Span, len(p.spans))
+	copy(spans, p.spans)
+	p.mutex.Unlock()
+
+	sort.SliceStable(spans, func(i, j int) bool {
+		if spans[i].Start == spans[j].Start {
+			return spans[i].Duration > spans[j].Duration
+		}
+		return spans[i].Start < spans[j].Start
+	})
+	for i := range spans {
+		for _, outer := range spans[:i] {
+			if spans[i].Start >= outer.Start && spans[i].Start+spans[i].Duration <= outer.Start+outer.Duration {
+				spans[i].Depth++
+			}
+		}
+	}
+	return spans
+}
+
+// Write will write the timing breakdown either as table or as json
+func (p *Profile) Write(w io.Writer, asJSON bool) error {
+	if p == nil {
+		return nil
+	}
+	total := time.Since(p.Start)
+	spans := p.Spans()
+	if asJSON {
+		bb, err := json.Marshal(map[string]interface{}{
+			"profile": map[string]interface{}{
+				"total": total,
+				"spans": spans,
+			},
+		})
+		if err != nil {
+			return err
+		}
+		_, err = fmt.Fprintln(w, string(bb))
+		return err
+	}
+	tw := tabwriter.NewWriter(w, 0, 0, 3, ' ', 0)
+	fmt.Fprintln(tw, "Phase\tStart\tDuration\t")
+	fmt.Fprintln(tw, "-----\t-----\t--------\t")
+	for _, s := range spans {
+		fmt.Fprintf(tw, "%s%s\t%s\t%s\t\n", strings.Repeat("  ", s.Depth), s.Name, round(s.Start), round(s.Duration))
+	}
+	fmt.Fprintf(tw, "total\t\t%s\t\n", round(total))
+	return tw.Flush()
+}
+
+func round(d time.Duration) time.Duration {
+	return d.Round(10 * time.Microsecond)
+}
@@ -0,0 +1,87 @@
+/*
+Copyright 2018 Adobe
+All Rights Reserved.
+
+NOTICE: Adobe permits you to use, modify, and distribute this file in
+accordance with the terms of the Adobe license agreement accompanying
+it. If you have received this file from a source other than Adobe,
+then your use, modification, or distribution of it requires the prior
+written permission of Adobe.
+*/
+
+package profile_test
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/adobe/sledgehammer/slh/profile"
+)
+
+func TestNilProfile(t *testing.T) {
+	var p *profile.Profile
+	p.Span("phase")()
+	p.Add("phase", time.Now(), time.Now())
+	assert.Nil(t, p.Spans())
+
+	buffer := &bytes.Buffer{}
+	assert.Nil(t, p.Write(buffer, false))
+	assert.Empty(t, buffer.String())
+}
+
+func TestSpans(t *testing.T) {
+	start := time.Now()
+	p := &profile.Profile{Start: start}
+	p.Add("execute", start.Add(30*time.Millisecond), start.Add(80*time.Millisecond))
+	p.Add("run", start.Add(10*time.Millisecond), start.Add(100*time.Millisecond))
+	p.Add("attach", start.Add(40*time.Millisecond), start.Add(50*time.Millisecond))
+	p.Add("startup", start, start.Add(10*time.Millisecond))
+
+	spans := p.Spans()
+	names := []string{}
+	depths := []int{}
+	for _, s := range spans {
+		names = append(names, s.Name)
+		depths = append(depths, s.Depth)
+	}
+	assert.Equal(t, []string{"startup", "run", "execute", "attach"}, names)
+	assert.Equal(t, []int{0, 0, 1, 2}, depths)
+	assert.Equal(t, 30*time.Millisecond, spans[2].Start)
+	assert.Equal(t, 50*time.Millisecond, spans[2].Duration)
+}
+
+func TestWrite(t *testing.T) {
+	cases := []struct {
+		name     string
+		json     bool
+		expected []string
+	}{
+		{
+			name:     "Table",
+			expected: []string{"Phase", "Duration", "startup", "  execute", "10ms", "total"},
+		},
+		{
+			name:     "JSON",
+			json:     true,
+			expected: []string{`{"profile":{`, `"name":"execute"`, `"depth":1`, `"total":`},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			start := time.Now()
+			p := &profile.Profile{Start: start}
+			p.Add("startup", start, start.Add(20*time.Millisecond))
+			p.Add("execute", start.Add(5*time.Millisecond), start.Add(15*time.Millisecond))
+
+			buffer := &bytes.Buffer{}
+			assert.Nil(t, p.Write(buffer, tc.json))
+			for _, e := range tc.expected {
+				assert.Contains(t, buffer.String(), e)
+			}
+		})
+	}
+}
@@ -99,20 +99,7 @@ func (r FileRegistry) Tools() ([]tool.Tool, error) {
 	for _, v := range registry.Tools {
 		fun, found := tool.Types[v.Type]
 		if found {
-			df := tool.Data{
-				Type:          v.Type,
-				Description:   v.Description,
-				Image:         v.Image,
-				Name:          v.Name,
-				Registry:      r.Core.Name,
-				ImageRegistry: v.Registry,
-				Entry:         v.Entry,
-			}
-			if v.Daemon != nil {
-				df.Daemon = &tool.Daemon{
-					Entry: v.Daemon.Entry,
-				}
-			}
+			df := toolData(v, v.Name, r.Core.Name)
 			tool := fun.Create(df)
 			logrus.WithField("tool", tool.Data().Name).Debug("Found tool")
 			tools = append(tools, tool)
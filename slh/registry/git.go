@@ -224,20 +224,7 @@ func (r *GitRegistry) Tools() ([]tool.Tool, error) {
 			}
 			fun, found := tool.Types[v.Type]
 			if found {
-				df := tool.Data{
-					Type:          v.Type,
-					Description:   v.Description,
-					Image:         v.Image,
-					Name:          f.Name(),
-					Registry:      r.Core.Name,
-					ImageRegistry: v.Registry,
-					Entry:         v.Entry,
-				}
-				if v.Daemon != nil {
-					df.Daemon = &tool.Daemon{
-						Entry: v.Daemon.Entry,
-					}
-				}
+				df := toolData(v, f.Name(), r.Core.Name)
 				tool := fun.Create(df)
 				logrus.WithField("tool", tool.Data().Name).Debug("Found tool")
 				tools = append(tools, tool)
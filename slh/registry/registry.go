@@ -22,6 +22,7 @@ import (
 	"github.com/adobe/sledgehammer/slh/kit"
 	"github.com/adobe/sledgehammer/slh/out"
 	"github.com/adobe/sledgehammer/slh/tool"
+	"github.com/adobe/sledgehammer/utils/contracts"
 	bolt "github.com/coreos/bbolt"
 )
 
@@ -276,3 +277,63 @@ func (r *Registries) Add(registry Registry) error {
 	// add the tools
 	return tools.Add(toolsList...)
 }
+
+// toolData will convert the tool definition of a registry into the data of a tool
+func toolData(v contracts.Tool, name string, registry string) tool.Data {
+	df := tool.Data{
+		Type:          v.Type,
+		Description:   v.Description,
+		Image:         v.Image,
+		Name:          name,
+		Registry:      registry,
+		ImageRegistry: v.Registry,
+		Entry:         v.Entry,
+	}
+	if v.Daemon != nil {
+		df.Daemon = &tool.Daemon{
+			Entry: v.Daemon.Entry,
+		}
+		if v.Daemon.Ready != nil {
+			df.Daemon.Ready = &tool.Readiness{
+				Exec:    v.Daemon.Ready.Exec,
+				Port:    v.Daemon.Ready.Port,
+				Log:     v.Daemon.Ready.Log,
+				Timeout: v.Daemon.Ready.Timeout,
+			}
+		}
+	}
+	if v.Runtime != nil {
+		df.Runtime = &tool.Runtime{
+			Env:      v.Runtime.Env,
+			EnvAllow: v.Runtime.EnvAllow,
+			EnvDeny:  v.Runtime.EnvDeny,
+			Volumes:  v.Runtime.Volumes,
+			Ports:    v.Runtime.Ports,
+			Network:  v.Runtime.Network,
+			WorkDir:  v.Runtime.WorkDir,
+			User:     v.Runtime.User,
+			Caches:   v.Runtime.Caches,
+			Home:     v.Runtime.Home,
+			Forward:  v.Runtime.Forward,
+			Root:     v.Runtime.Root,
+			Security: v.Runtime.Security,
+		}
+		if v.Runtime.Limits != nil {
+			df.Runtime.Limits = &tool.Limits{
+				CPUs:    v.Runtime.Limits.CPUs,
+				Memory:  v.Runtime.Limits.Memory,
+				Pids:    v.Runtime.Limits.Pids,
+				Timeout: v.Runtime.Limits.Timeout,
+			}
+		}
+	}
+	if v.Permissions != nil {
+		df.Permissions = &tool.Permissions{
+			Docker:     v.Permissions.Docker,
+			Privileged: v.Permissions.Privileged,
+			HostPaths:  v.Permissions.HostPaths,
+			Forward:    v.Permissions.Forward,
+		}
+	}
+	return df
+}
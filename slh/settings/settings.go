@@ -0,0 +1,225 @@
+/*
+Copyright 2018 Adobe
+All Rights Reserved.
+
+NOTICE: Adobe permits you to use, modify, and distribute this file in
+accordance with the terms of the Adobe license agreement accompanying
+it. If you have received this file from a source other than Adobe,
+then your use, modification, or distribution of it requires the prior
+written permission of Adobe.
+*/
+
+package settings
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/adobe/sledgehammer/slh/config"
+	bolt "github.com/coreos/bbolt"
+	"github.com/sirupsen/logrus"
+)
+
+var (
+	// BucketKey is the name of the bucket where settings are stored
+	BucketKey = "settings"
+	// ErrorUnknownKey will be thrown if the given setting is not known to Sledgehammer
+	ErrorUnknownKey = errors.New("Unknown setting")
+	// Keys are all settings that are known to Sledgehammer together with a short description
+	Keys = map[string]string{
+		EnvAllow:     "Comma separated list of host variables (globs allowed) that are passed to tools",
+		EnvDeny:      "Comma separated list of host variables (globs allowed) that are never passed to tools",
+		DaemonIdle:   "Time after which a daemon nobody executed a command in is stopped, e.g. 30m (default 10m)",
+		HistorySize:  "Number of executions that are kept in the history, 0 disables the history (default 1000)",
+		Offline:      "Never access the network, tools are only run from local images (true|false, default false)",
+		MountWorkDir: "Mount the working directory for each run if it is not part of a mount (true|false, default false)",
+		Network:      "Network mode of tools that do not define one: host, bridge, none or the name of a docker network (default host)",
+		LimitCPUs:    "Number of cpus a tool can use if neither the tool nor the alias define it, e.g. 1.5 (default unlimited)",
+		LimitMemory:  "Memory a tool can use if neither the tool nor the alias define it, e.g. 2g (default unlimited)",
+		LimitPids:    "Number of processes a tool can start if neither the tool nor the alias define it (default unlimited)",
+		LimitTimeout: "Time after which a tool is stopped if neither the tool nor the alias define it, e.g. 30m (default unlimited)",
+		SecurityMax:  "Most permissive security profile tools run with: strict, default or privileged (default default)",
+	}
+	// validators check the settings that have a fixed format, the other settings are checked when they are used
+	validators = map[string]validator{
+		DaemonIdle:   duration,
+		HistorySize:  count,
+		Offline:      boolean,
+		MountWorkDir: boolean,
+		LimitPids:    count,
+		LimitTimeout: duration,
+	}
+	boolean = validator{
+		expected: "a boolean",
+		valid: func(value string) bool {
+			_, err := strconv.ParseBool(value)
+			return err == nil
+		},
+	}
+	duration = validator{
+		expected: "a duration, e.g. 30m",
+		valid: func(value string) bool {
+			d, err := time.ParseDuration(value)
+			return err == nil && d >= 0
+		},
+	}
+	count = validator{
+		expected: "a positive number or 0",
+		valid: func(value string) bool {
+			n, err := strconv.ParseInt(value, 10, 64)
+			return err == nil && n >= 0
+		},
+	}
+)
+
+// InvalidValueError will be thrown if a setting is set to a value it cannot have
+type InvalidValueError struct {
+	Key      string
+	Value    string
+	Expected string
+}
+
+func (e *InvalidValueError) Error() string {
+	return fmt.Sprintf("The value %s of the setting %s is not %s", e.Value, e.Key, e.Expected)
+}
+
+// validator checks the value of a setting
+type validator struct {
+	expected string
+	valid    func(string) bool
+}
+
+const (
+	// EnvAllow is the key of the global list of allowed environment variables
+	EnvAllow = "env.allow"
+	// EnvDeny is the key of the global list of denied environment variables
+	EnvDeny = "env.deny"
+	// DaemonIdle is the key of the idle timeout of daemon tools
+	DaemonIdle = "daemon.idle"
+	// HistorySize is the key of the number of executions that are kept in the history
+	HistorySize = "history.size"
+	// Offline is the key of the offline mode
+	Offline = "offline"
+	// MountWorkDir is the key of the mode that mounts the working directory for each run
+	MountWorkDir = "mount.workdir"
+	// Network is the key of the global network mode of tools
+	Network = "network"
+	// LimitCPUs is the key of the global number of cpus of tools
+	LimitCPUs = "limits.cpus"
+	// LimitMemory is the key of the global memory limit of tools
+	LimitMemory = "limits.memory"
+	// LimitPids is the key of the global number of processes of tools
+	LimitPids = "limits.pids"
+	// LimitTimeout is the key of the global timeout of tools
+	LimitTimeout = "limits.timeout"
+	// SecurityMax is the key of the most permissive security profile tools run with
+	SecurityMax = "security.max"
+)
+
+// Settings is the struct that can be used to access the global settings of Sledgehammer
+type Settings struct {
+	config.Database
+}
+
+// New will create a new Settings struct based on the given bolt database.
+func New(db config.Database) *Settings {
+	return &Settings{
+		Database: db,
+	}
+}
+
+// Set will set the given setting to the given value
+func (s *Settings) Set(key string, value string) error {
+	if _, found := Keys[key]; !found {
+		return ErrorUnknownKey
+	}
+	if v, found := validators[key]; found && !v.valid(value) {
+		return &InvalidValueError{Key: key, Value: value, Expected: v.expected}
+	}
+	logrus.WithField("key", key).Debug("Setting value")
+	return s.DB.Update(func(tx *bolt.Tx) error {
+		bucket, err := tx.CreateBucketIfNotExists([]byte(BucketKey))
+		if err != nil {
+			return err
+		}
+		return bucket.Put([]byte(key), []byte(value))
+	})
+}
+
+// Remove will reset the given setting to its default
+func (s *Settings) Remove(key string) error {
+	if _, found := Keys[key]; !found {
+		return ErrorUnknownKey
+	}
+	logrus.WithField("key", key).Debug("Removing setting")
+	return s.DB.Update(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket([]byte(BucketKey))
+		if bucket != nil {
+			return bucket.Delete([]byte(key))
+		}
+		return nil
+	})
+}
+
+// Get will return the value of the given setting and if it has been set at all
+func (s *Settings) Get(key string) (string, bool, error) {
+	value := ""
+	found := false
+	err := s.DB.View(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket([]byte(BucketKey))
+		if bucket != nil {
+			v := bucket.Get([]byte(key))
+			if v != nil {
+				value = string(v)
+				found = true
+			}
+		}
+		return nil
+	})
+	return value, found, err
+}
+
+// Values will return the given setting as a list of comma separated values.
+// If the setting has not been set, the defaults are returned
+func (s *Settings) Values(key string, defaults []string) ([]string, error) {
+	value, found, err := s.Get(key)
+	if err != nil || !found {
+		return defaults, err
+	}
+	return Split(value), nil
+}
+
+// List will return all settings that have been set, together with the sorted keys
+func (s *Settings) List() ([]string, map[string]string, error) {
+	keys := []string{}
+	values := map[string]string{}
+	err := s.DB.View(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket([]byte(BucketKey))
+		if bucket != nil {
+			return bucket.ForEach(func(key []byte, value []byte) error {
+				keys = append(keys, string(key))
+				values[string(key)] = string(value)
+				return nil
+			})
+		}
+		return nil
+	})
+	sort.Strings(keys)
+	return keys, values, err
+}
+
+// Split will split a comma separated value into its trimmed, non empty parts
+func Split(value string) []string {
+	values := []string{}
+	for _, v := range strings.Split(value, ",") {
+		v = strings.TrimSpace(v)
+		if len(v) > 0 {
+			values = append(values, v)
+		}
+	}
+	return values
+}
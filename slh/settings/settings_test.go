@@ -0,0 +1,119 @@
+/*
+Copyright 2018 Adobe
+All Rights Reserved.
+
+NOTICE: Adobe permits you to use, modify, and distribute this file in
+accordance with the terms of the Adobe license agreement accompanying
+it. If you have received this file from a source other than Adobe,
+then your use, modification, or distribution of it requires the prior
+written permission of Adobe.
+*/
+
+package settings_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/adobe/sledgehammer/slh/config"
+	"github.com/adobe/sledgehammer/slh/settings"
+	"github.com/adobe/sledgehammer/utils/test"
+)
+
+func TestSettings(t *testing.T) {
+	cases := []struct {
+		name     string
+		set      map[string]string
+		remove   []string
+		key      string
+		defaults []string
+		expected []string
+		err      error
+	}{
+		{
+			name:     "Defaults",
+			key:      settings.EnvAllow,
+			defaults: []string{"*"},
+			expected: []string{"*"},
+		},
+		{
+			name:     "Set value",
+			set:      map[string]string{settings.EnvAllow: "FOO, BAR_*,"},
+			key:      settings.EnvAllow,
+			defaults: []string{"*"},
+			expected: []string{"FOO", "BAR_*"},
+		},
+		{
+			name:     "Set empty value",
+			set:      map[string]string{settings.EnvDeny: ""},
+			key:      settings.EnvDeny,
+			defaults: []string{"*"},
+			expected: []string{},
+		},
+		{
+			name:     "Removed value",
+			set:      map[string]string{settings.EnvAllow: "FOO"},
+			remove:   []string{settings.EnvAllow},
+			key:      settings.EnvAllow,
+			defaults: []string{"*"},
+			expected: []string{"*"},
+		},
+		{
+			name: "Unknown key",
+			set:  map[string]string{"foo": "bar"},
+			err:  settings.ErrorUnknownKey,
+		},
+		{
+			name: "Offline is not a boolean",
+			set:  map[string]string{settings.Offline: "yes please"},
+			err:  &settings.InvalidValueError{Key: settings.Offline, Value: "yes please", Expected: "a boolean"},
+		},
+		{
+			name: "Idle timeout is not a duration",
+			set:  map[string]string{settings.DaemonIdle: "10"},
+			err:  &settings.InvalidValueError{Key: settings.DaemonIdle, Value: "10", Expected: "a duration, e.g. 30m"},
+		},
+		{
+			name: "History size is negative",
+			set:  map[string]string{settings.HistorySize: "-1"},
+			err:  &settings.InvalidValueError{Key: settings.HistorySize, Value: "-1", Expected: "a positive number or 0"},
+		},
+		{
+			name: "Process limit is not a number",
+			set:  map[string]string{settings.LimitPids: "many"},
+			err:  &settings.InvalidValueError{Key: settings.LimitPids, Value: "many", Expected: "a positive number or 0"},
+		},
+		{
+			name:     "Valid values",
+			set:      map[string]string{settings.Offline: "true", settings.DaemonIdle: "1h", settings.HistorySize: "0", settings.LimitPids: "100"},
+			key:      settings.LimitPids,
+			expected: []string{"100"},
+		},
+	}
+
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			db := test.NewTestDB(t)
+			defer test.Close(db, t)
+
+			s := settings.New(config.Database{DB: db})
+
+			for key, value := range tt.set {
+				err := s.Set(key, value)
+				if tt.err != nil {
+					assert.Equal(t, tt.err, err)
+					return
+				}
+				assert.NoError(t, err)
+			}
+			for _, key := range tt.remove {
+				assert.NoError(t, s.Remove(key))
+			}
+
+			values, err := s.Values(tt.key, tt.defaults)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expected, values)
+		})
+	}
+}
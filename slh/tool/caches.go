@@ -0,0 +1,319 @@
+/*
+Copyright 2018 Adobe
+All Rights Reserved.
+
+NOTICE: Adobe permits you to use, modify, and distribute this file in
+accordance with the terms of the Adobe license agreement accompanying
+it. If you have received this file from a source other than Adobe,
+then your use, modification, or distribution of it requires the prior
+written permission of Adobe.
+*/
+
+package tool
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/adobe/sledgehammer/slh/config"
+	docker "github.com/fsouza/go-dockerclient"
+	"github.com/sirupsen/logrus"
+)
+
+var (
+	// ContainerHome is the home directory of tools that keep their home or caches below it
+	ContainerHome = "/home/slh"
+	// ErrorCacheInUse will be thrown if a cache should be removed while a container still uses it
+	ErrorCacheInUse = errors.New("The cache is used by a container, stop the daemon of the tool first")
+
+	volumeChars = regexp.MustCompile("[^a-zA-Z0-9_.-]+")
+)
+
+// Cache is a directory of a tool that is kept between runs in a named volume
+type Cache struct {
+	Volume   string
+	Tool     string
+	Registry string
+	// Path is the directory of the cache inside of the container
+	Path string
+	// Image is the image that created the cache, it is used to measure the size of the cache
+	Image string
+	// Size is the size of the cache in bytes, -1 if it is unknown
+	Size int64
+}
+
+// CacheVolume will return the name of the volume that keeps the given directory of the tool.
+// Every user the tool runs with gets its own volume, a volume of another uid might not be writable.
+func CacheVolume(to Tool, dir string) string {
+	hash := sha256.Sum256([]byte(dir))
+	name := "slh-cache-" + volumeName(to.Data().Registry) + "-" + volumeName(to.Data().Name)
+	if uid := strings.SplitN(containerUser(to), ":", 2)[0]; len(uid) > 0 {
+		name += "-" + volumeName(uid)
+	}
+	return name + "-" + hex.EncodeToString(hash[:4])
+}
+
+func volumeName(name string) string {
+	return strings.Trim(volumeChars.ReplaceAllString(name, "-"), "-")
+}
+
+// cachePaths will return the directories in the container that are kept between runs, including the home directory if requested
+func cachePaths(to Tool) ([]string, error) {
+	paths := []string{}
+	if runtime(to).Home {
+		paths = append(paths, ContainerHome)
+	}
+	for _, c := range runtime(to).Caches {
+		dir, _ := expandHome(c)
+		if !path.IsAbs(dir) {
+			return nil, &InvalidRuntimeError{Setting: "cache", Value: c}
+		}
+		paths = append(paths, path.Clean(dir))
+	}
+	return paths, nil
+}
+
+// usesHome will return true if the tool keeps its home directory or caches below it
+func usesHome(to Tool) bool {
+	if runtime(to).Home {
+		return true
+	}
+	for _, c := range runtime(to).Caches {
+		if _, home := expandHome(c); home {
+			return true
+		}
+	}
+	return false
+}
+
+// expandHome will replace a leading $HOME or ~ with the home directory in the container
+func expandHome(dir string) (string, bool) {
+	for _, prefix := range []string{"$HOME", "${HOME}", "~"} {
+		if dir == prefix || strings.HasPrefix(dir, prefix+"/") {
+			return path.Join(ContainerHome, strings.TrimPrefix(dir, prefix)), true
+		}
+	}
+	return dir, false
+}
+
+// containerEnv will return the environment of the container.
+// $HOME points to the home directory of the tool if it keeps one or if the user is synthesized, $USER is the name of the synthesized user.
+func containerEnv(opt *ExecutionOptions) []string {
+	id := userIdentity(opt)
+	if id == nil && !usesHome(opt.Tool) {
+		return opt.Env
+	}
+	env := []string{}
+	for _, e := range opt.Env {
+		if !strings.HasPrefix(e, "HOME=") && !(id != nil && strings.HasPrefix(e, "USER=")) {
+			env = append(env, e)
+		}
+	}
+	env = append(env, "HOME="+ContainerHome)
+	if id != nil {
+		env = append(env, "USER="+id.Name)
+	}
+	return env
+}
+
+// cacheMounts will return the volumes that keep the caches of the tool
+func cacheMounts(to Tool) ([]docker.HostMount, error) {
+	paths, err := cachePaths(to)
+	if err != nil {
+		return nil, err
+	}
+	mounts := []docker.HostMount{}
+	for _, p := range paths {
+		mounts = append(mounts, docker.HostMount{
+			Source: CacheVolume(to, p),
+			Target: p,
+			Type:   "volume",
+		})
+	}
+	return mounts, nil
+}
+
+// prepareCaches will create the volumes of the caches that do not exist yet.
+// New volumes are owned by the user the tool runs with, otherwise only root could write to them.
+func prepareCaches(opt *ExecutionOptions) error {
+	mounts, err := cacheMounts(opt.Tool)
+	if err != nil || len(mounts) == 0 {
+		return err
+	}
+	defer opt.Profile.Span("prepare caches")()
+	created := []docker.HostMount{}
+	for _, m := range mounts {
+		_, err := opt.Docker.Docker.InspectVolume(m.Source)
+		if err == nil {
+			continue
+		}
+		if err != docker.ErrNoSuchVolume {
+			return err
+		}
+		logrus.WithField("volume", m.Source).WithField("path", m.Target).Info("Creating cache volume")
+		_, err = opt.Docker.Docker.CreateVolume(docker.CreateVolumeOptions{
+			Name: m.Source,
+			Labels: map[string]string{
+				LabelTool:     opt.Tool.Data().Name,
+				LabelRegistry: opt.Tool.Data().Registry,
+				LabelCache:    m.Target,
+				LabelImage:    FullImage(opt.Tool, opt.Version),
+			},
+		})
+		if err != nil {
+			return err
+		}
+		created = append(created, m)
+	}
+	user := containerUser(opt.Tool)
+	if len(created) == 0 || len(user) == 0 || user == "root" || user == "0" || strings.HasPrefix(user, "0:") {
+		return nil
+	}
+	// docker copies the content of the image into a new volume, these files are owned by root as well
+	cmd := []string{"chown", "-R", user}
+	for _, m := range created {
+		cmd = append(cmd, m.Target)
+	}
+	code, out, err := runHelper(*opt.Docker, opt.Installation, FullImage(opt.Tool, opt.Version), opt.Tool.Data().Registry, opt.Tool.Data().Name, cmd, created)
+	if err != nil {
+		return err
+	}
+	if code != 0 {
+		// the image might not contain chown, the tool can still use the caches if it runs as root
+		logrus.WithField("output", out).WithField("code", code).Warn("Could not change the owner of the cache volumes")
+	}
+	return nil
+}
+
+// runHelper will run the command as root in a short lived container of the image and return its exit code and output.
+// The container is labeled with the installation, so it can be collected if Sledgehammer gets killed while it runs.
+func runHelper(client config.Docker, installation string, image string, registry string, name string, cmd []string, mounts []docker.HostMount) (int, string, error) {
+	resp, err := client.Docker.CreateContainer(docker.CreateContainerOptions{
+		Config: &docker.Config{
+			Image:      image,
+			Entrypoint: cmd,
+			User:       "0",
+			Labels: map[string]string{
+				LabelTool:         name,
+				LabelRegistry:     registry,
+				LabelPID:          strconv.Itoa(os.Getpid()),
+				LabelDaemon:       strconv.FormatBool(false),
+				LabelInstallation: installation,
+			},
+		},
+		HostConfig: &docker.HostConfig{
+			NetworkMode: "none",
+			Mounts:      mounts,
+		},
+	})
+	if err != nil {
+		return 1, "", err
+	}
+	defer client.Docker.RemoveContainer(docker.RemoveContainerOptions{
+		Force: true,
+		ID:    resp.ID,
+	})
+	if err := client.Docker.StartContainer(resp.ID, nil); err != nil {
+		return 1, "", err
+	}
+	code, err := client.Docker.WaitContainer(resp.ID)
+	if err != nil {
+		return 1, "", err
+	}
+	out := &bytes.Buffer{}
+	err = client.Docker.Logs(docker.LogsOptions{
+		Container:    resp.ID,
+		OutputStream: out,
+		ErrorStream:  ioutil.Discard,
+		Stdout:       true,
+		Stderr:       true,
+	})
+	return code, out.String(), err
+}
+
+// Caches will return the caches of all tools, or only of the given registry and tool if they are not empty.
+// If sizes is true, the size of each cache is measured with the image that created it in a container of the given installation.
+func Caches(client config.Docker, installation string, registry string, name string, sizes bool) ([]Cache, error) {
+	filter := []string{LabelCache}
+	if len(registry) > 0 {
+		filter = append(filter, LabelRegistry+"="+registry)
+	}
+	if len(name) > 0 {
+		filter = append(filter, LabelTool+"="+name)
+	}
+	volumes, err := client.Docker.ListVolumes(docker.ListVolumesOptions{
+		Filters: map[string][]string{
+			"label": filter,
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+	caches := []Cache{}
+	for _, v := range volumes {
+		c := Cache{
+			Volume:   v.Name,
+			Tool:     v.Labels[LabelTool],
+			Registry: v.Labels[LabelRegistry],
+			Path:     v.Labels[LabelCache],
+			Image:    v.Labels[LabelImage],
+			Size:     -1,
+		}
+		if sizes {
+			c.Size = cacheSize(client, installation, c)
+		}
+		caches = append(caches, c)
+	}
+	sort.Slice(caches, func(i, j int) bool {
+		if caches[i].Registry+"/"+caches[i].Tool == caches[j].Registry+"/"+caches[j].Tool {
+			return caches[i].Path < caches[j].Path
+		}
+		return caches[i].Registry+"/"+caches[i].Tool < caches[j].Registry+"/"+caches[j].Tool
+	})
+	return caches, nil
+}
+
+// cacheSize will measure the size of the cache, it returns -1 if it cannot be measured, e.g. if the image has been removed
+func cacheSize(client config.Docker, installation string, c Cache) int64 {
+	code, out, err := runHelper(client, installation, c.Image, c.Registry, c.Tool, []string{"du", "-sk", c.Path}, []docker.HostMount{{
+		Source:   c.Volume,
+		Target:   c.Path,
+		Type:     "volume",
+		ReadOnly: true,
+	}})
+	if err != nil || code != 0 {
+		logrus.WithField("volume", c.Volume).WithField("output", out).WithError(err).Warn("Could not measure the size of the cache")
+		return -1
+	}
+	fields := strings.Fields(out)
+	if len(fields) == 0 {
+		return -1
+	}
+	kb, err := strconv.ParseInt(fields[0], 10, 64)
+	if err != nil {
+		return -1
+	}
+	return kb * 1024
+}
+
+// RemoveCache will remove the volume of the cache, the tool starts with an empty cache the next time it runs
+func RemoveCache(client config.Docker, c Cache) error {
+	logrus.WithField("volume", c.Volume).Info("Removing cache volume")
+	err := client.Docker.RemoveVolume(c.Volume)
+	if err == docker.ErrVolumeInUse {
+		return ErrorCacheInUse
+	}
+	if err == docker.ErrNoSuchVolume {
+		return nil
+	}
+	return err
+}
@@ -0,0 +1,207 @@
+/*
+Copyright 2018 Adobe
+All Rights Reserved.
+
+NOTICE: Adobe permits you to use, modify, and distribute this file in
+accordance with the terms of the Adobe license agreement accompanying
+it. If you have received this file from a source other than Adobe,
+then your use, modification, or distribution of it requires the prior
+written permission of Adobe.
+*/
+
+package tool_test
+
+import (
+	"testing"
+
+	docker "github.com/fsouza/go-dockerclient"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/adobe/sledgehammer/mocks"
+	"github.com/adobe/sledgehammer/slh/config"
+	"github.com/adobe/sledgehammer/slh/tool"
+)
+
+func cacheTool(runtime *tool.Runtime) tool.Tool {
+	return &tool.LocalTool{
+		Core: tool.Data{
+			Name:     "maven",
+			Registry: "default",
+			Image:    "maven",
+			Daemon:   &tool.Daemon{Entry: []string{"sh"}},
+			Runtime:  runtime,
+		},
+	}
+}
+
+func TestCacheVolume(t *testing.T) {
+	to := cacheTool(nil)
+	other := &tool.LocalTool{Core: tool.Data{Name: "my tool", Registry: "git@host:repo"}}
+
+	assert.Equal(t, tool.CacheVolume(to, "/root/.m2"), tool.CacheVolume(to, "/root/.m2"))
+	assert.NotEqual(t, tool.CacheVolume(to, "/root/.m2"), tool.CacheVolume(to, "/root/.npm"))
+	assert.Regexp(t, "^slh-cache-default-maven-[0-9]+-[0-9a-f]{8}$", tool.CacheVolume(to, "/root/.m2"))
+	assert.Regexp(t, "^slh-cache-git-host-repo-my-tool-[0-9]+-[0-9a-f]{8}$", tool.CacheVolume(other, "/root/.m2"))
+	// every user gets its own volume
+	assert.Regexp(t, "^slh-cache-default-maven-1000-[0-9a-f]{8}$", tool.CacheVolume(cacheTool(&tool.Runtime{User: "1000:1000"}), "/root/.m2"))
+	assert.Regexp(t, "^slh-cache-default-maven-0-[0-9a-f]{8}$", tool.CacheVolume(cacheTool(&tool.Runtime{Root: true}), "/root/.m2"))
+}
+
+func TestPrepareCaches(t *testing.T) {
+	runtime := &tool.Runtime{
+		Caches: []string{"/root/.m2", "$HOME/.cache/go-build"},
+		User:   "1000:1000",
+	}
+	to := cacheTool(runtime)
+	m2 := tool.CacheVolume(to, "/root/.m2")
+	goBuild := tool.CacheVolume(to, "/home/slh/.cache/go-build")
+
+	cases := []struct {
+		name    string
+		runtime *tool.Runtime
+		prepare func(*mocks.MockClient)
+		check   func(*testing.T, docker.CreateContainerOptions)
+		err     error
+	}{
+		{
+			name:    "Existing caches are mounted",
+			runtime: runtime,
+			prepare: func(dockerMock *mocks.MockClient) {
+				dockerMock.EXPECT().InspectVolume(m2).Return(&docker.Volume{Name: m2}, nil)
+				dockerMock.EXPECT().InspectVolume(goBuild).Return(&docker.Volume{Name: goBuild}, nil)
+			},
+			check: func(t *testing.T, opts docker.CreateContainerOptions) {
+				assert.Equal(t, []docker.HostMount{
+					{Source: m2, Target: "/root/.m2", Type: "volume"},
+					{Source: goBuild, Target: "/home/slh/.cache/go-build", Type: "volume"},
+				}, opts.HostConfig.Mounts)
+				assert.Equal(t, []string{"FOO=bar", "HOME=/home/slh"}, opts.Config.Env)
+			},
+		},
+		{
+			name:    "New caches are owned by the user of the tool",
+			runtime: runtime,
+			prepare: func(dockerMock *mocks.MockClient) {
+				dockerMock.EXPECT().InspectVolume(m2).Return(&docker.Volume{Name: m2}, nil)
+				dockerMock.EXPECT().InspectVolume(goBuild).Return(nil, docker.ErrNoSuchVolume)
+				dockerMock.EXPECT().CreateVolume(gomock.Any()).DoAndReturn(func(opts docker.CreateVolumeOptions) (*docker.Volume, error) {
+					assert.Equal(t, goBuild, opts.Name)
+					assert.Equal(t, "/home/slh/.cache/go-build", opts.Labels[tool.LabelCache])
+					assert.Equal(t, "maven", opts.Labels[tool.LabelTool])
+					return &docker.Volume{Name: goBuild}, nil
+				})
+				gomock.InOrder(
+					dockerMock.EXPECT().CreateContainer(gomock.Any()).DoAndReturn(func(opts docker.CreateContainerOptions) (*docker.Container, error) {
+						assert.Equal(t, []string{"chown", "-R", "1000:1000", "/home/slh/.cache/go-build"}, opts.Config.Entrypoint)
+						assert.Equal(t, "0", opts.Config.User)
+						assert.Equal(t, []docker.HostMount{{Source: goBuild, Target: "/home/slh/.cache/go-build", Type: "volume"}}, opts.HostConfig.Mounts)
+						return &docker.Container{ID: "helper"}, nil
+					}),
+					dockerMock.EXPECT().StartContainer("helper", gomock.Any()),
+					dockerMock.EXPECT().WaitContainer("helper").Return(0, nil),
+					dockerMock.EXPECT().Logs(gomock.Any()),
+					dockerMock.EXPECT().RemoveContainer(docker.RemoveContainerOptions{ID: "helper", Force: true}),
+				)
+			},
+			check: func(t *testing.T, opts docker.CreateContainerOptions) {
+				assert.Len(t, opts.HostConfig.Mounts, 2)
+			},
+		},
+		{
+			name:    "Home is kept in a volume",
+			runtime: &tool.Runtime{Home: true, User: "root"},
+			prepare: func(dockerMock *mocks.MockClient) {
+				dockerMock.EXPECT().InspectVolume(gomock.Any()).Return(nil, docker.ErrNoSuchVolume)
+				dockerMock.EXPECT().CreateVolume(gomock.Any()).Return(&docker.Volume{}, nil)
+			},
+			check: func(t *testing.T, opts docker.CreateContainerOptions) {
+				assert.Equal(t, []docker.HostMount{{Source: tool.CacheVolume(cacheTool(&tool.Runtime{Home: true, User: "root"}), "/home/slh"), Target: "/home/slh", Type: "volume"}}, opts.HostConfig.Mounts)
+				assert.Contains(t, opts.Config.Env, "HOME=/home/slh")
+			},
+		},
+		{
+			name:    "Invalid cache",
+			runtime: &tool.Runtime{Caches: []string{".m2"}},
+			prepare: func(dockerMock *mocks.MockClient) {},
+			err:     &tool.InvalidRuntimeError{Setting: "cache", Value: ".m2"},
+		},
+	}
+
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			mockCtrl := gomock.NewController(t)
+			defer mockCtrl.Finish()
+			dockerMock := mocks.NewMockClient(mockCtrl)
+			tt.prepare(dockerMock)
+
+			var created docker.CreateContainerOptions
+			if tt.err == nil {
+				dockerMock.EXPECT().CreateContainer(gomock.Any()).DoAndReturn(func(opts docker.CreateContainerOptions) (*docker.Container, error) {
+					created = opts
+					return &docker.Container{ID: "foo"}, nil
+				})
+				dockerMock.EXPECT().StartContainer("foo", gomock.Any())
+			}
+
+			_, err := tool.StartIfDaemon(&tool.ExecutionOptions{
+				Tool:   cacheTool(tt.runtime),
+				Docker: &config.Docker{Docker: dockerMock},
+				Env:    []string{"FOO=bar", "HOME=/Users/foo"},
+			})
+			if tt.err != nil {
+				assert.Equal(t, tt.err, err)
+				return
+			}
+			assert.NoError(t, err)
+			tt.check(t, created)
+		})
+	}
+}
+
+func TestCaches(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+	dockerMock := mocks.NewMockClient(mockCtrl)
+
+	dockerMock.EXPECT().ListVolumes(docker.ListVolumesOptions{
+		Filters: map[string][]string{"label": {tool.LabelCache, tool.LabelRegistry + "=default", tool.LabelTool + "=maven"}},
+	}).Return([]docker.Volume{
+		{Name: "npm", Labels: map[string]string{tool.LabelTool: "maven", tool.LabelRegistry: "default", tool.LabelCache: "/root/.npm", tool.LabelImage: "maven:3"}},
+		{Name: "m2", Labels: map[string]string{tool.LabelTool: "maven", tool.LabelRegistry: "default", tool.LabelCache: "/root/.m2", tool.LabelImage: "maven:3"}},
+	}, nil)
+	dockerMock.EXPECT().CreateContainer(gomock.Any()).DoAndReturn(func(opts docker.CreateContainerOptions) (*docker.Container, error) {
+		assert.Equal(t, "maven:3", opts.Config.Image)
+		assert.Equal(t, []string{"du", "-sk", "/root/.npm"}, opts.Config.Entrypoint)
+		assert.Equal(t, "test", opts.Config.Labels[tool.LabelInstallation])
+		return &docker.Container{ID: "npm"}, nil
+	})
+	dockerMock.EXPECT().CreateContainer(gomock.Any()).Return(nil, docker.ErrNoSuchImage)
+	dockerMock.EXPECT().StartContainer("npm", gomock.Any())
+	dockerMock.EXPECT().WaitContainer("npm").Return(0, nil)
+	dockerMock.EXPECT().Logs(gomock.Any()).DoAndReturn(func(opts docker.LogsOptions) error {
+		_, err := opts.OutputStream.Write([]byte("2048\t/root/.npm\n"))
+		return err
+	})
+	dockerMock.EXPECT().RemoveContainer(gomock.Any())
+
+	caches, err := tool.Caches(config.Docker{Docker: dockerMock}, "test", "default", "maven", true)
+	assert.NoError(t, err)
+	assert.Equal(t, []tool.Cache{
+		{Volume: "m2", Tool: "maven", Registry: "default", Path: "/root/.m2", Image: "maven:3", Size: -1},
+		{Volume: "npm", Tool: "maven", Registry: "default", Path: "/root/.npm", Image: "maven:3", Size: 2048 * 1024},
+	}, caches)
+}
+
+func TestRemoveCache(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+	dockerMock := mocks.NewMockClient(mockCtrl)
+
+	dockerMock.EXPECT().RemoveVolume("m2").Return(docker.ErrVolumeInUse)
+	dockerMock.EXPECT().RemoveVolume("npm").Return(docker.ErrNoSuchVolume)
+
+	client := config.Docker{Docker: dockerMock}
+	assert.Equal(t, tool.ErrorCacheInUse, tool.RemoveCache(client, tool.Cache{Volume: "m2"}))
+	assert.NoError(t, tool.RemoveCache(client, tool.Cache{Volume: "npm"}))
+}
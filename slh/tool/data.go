@@ -17,22 +17,67 @@ import (
 
 // Data is the data each tool contains
 type Data struct {
-	Name          string    `json:"name"`
-	Description   string    `json:"description"`
-	Registry      string    `json:"registry"`
-	ImageRegistry string    `json:"imageRegistry,omitempty"`
-	Image         string    `json:"image"`
-	Default       bool      `json:"default"`
-	Type          string    `json:"type,omitempty"`
-	Entry         []string  `json:"entry,omitempty"`
-	Added         time.Time `json:"added"`
-	Versions      []string  `json:"versions,omitempty"`
-	Daemon        *Daemon   `json:"daemon,omitempty"`
+	Name          string       `json:"name"`
+	Description   string       `json:"description"`
+	Registry      string       `json:"registry"`
+	ImageRegistry string       `json:"imageRegistry,omitempty"`
+	Image         string       `json:"image"`
+	Default       bool         `json:"default"`
+	Type          string       `json:"type,omitempty"`
+	Entry         []string     `json:"entry,omitempty"`
+	Added         time.Time    `json:"added"`
+	Versions      []string     `json:"versions,omitempty"`
+	Daemon        *Daemon      `json:"daemon,omitempty"`
+	Runtime       *Runtime     `json:"runtime,omitempty"`
+	Permissions   *Permissions `json:"permissions,omitempty"`
 }
 
 // Daemon defines the entry point when the container should be started as a daemon
 type Daemon struct {
-	Entry []string `json:"entry,omitempty"`
+	Entry []string   `json:"entry,omitempty"`
+	Ready *Readiness `json:"ready,omitempty"`
+}
+
+// Readiness defines the probes that need to succeed before a command will be executed in a daemon
+type Readiness struct {
+	Exec    []string `json:"exec,omitempty"`
+	Port    int      `json:"port,omitempty"`
+	Log     string   `json:"log,omitempty"`
+	Timeout string   `json:"timeout,omitempty"`
+}
+
+// Runtime defines additional settings for the container that the tool will be executed in
+type Runtime struct {
+	Env      map[string]string `json:"env,omitempty"`
+	EnvAllow []string          `json:"envAllow,omitempty"`
+	EnvDeny  []string          `json:"envDeny,omitempty"`
+	Volumes  []string          `json:"volumes,omitempty"`
+	Ports    []string          `json:"ports,omitempty"`
+	Network  string            `json:"network,omitempty"`
+	WorkDir  string            `json:"workdir,omitempty"`
+	User     string            `json:"user,omitempty"`
+	Caches   []string          `json:"caches,omitempty"`
+	Home     bool              `json:"home,omitempty"`
+	Forward  []string          `json:"forward,omitempty"`
+	Limits   *Limits           `json:"limits,omitempty"`
+	Root     bool              `json:"root,omitempty"`
+	Security string            `json:"security,omitempty"`
+}
+
+// Permissions defines the elevated access to the host a tool needs
+type Permissions struct {
+	Docker     bool     `json:"docker,omitempty"`
+	Privileged bool     `json:"privileged,omitempty"`
+	HostPaths  []string `json:"hostPaths,omitempty"`
+	Forward    []string `json:"forward,omitempty"`
+}
+
+// Limits restrict the resources a tool can use and the time it can run
+type Limits struct {
+	CPUs    string `json:"cpus,omitempty"`
+	Memory  string `json:"memory,omitempty"`
+	Pids    int64  `json:"pids,omitempty"`
+	Timeout string `json:"timeout,omitempty"`
 }
 
 // FullImage will return the full name of the image including repository and version if possible
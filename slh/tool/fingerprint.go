@@ -0,0 +1,88 @@
+/*
+Copyright 2018 Adobe
+All Rights Reserved.
+
+NOTICE: Adobe permits you to use, modify, and distribute this file in
+accordance with the terms of the Adobe license agreement accompanying
+it. If you have received this file from a source other than Adobe,
+then your use, modification, or distribution of it requires the prior
+written permission of Adobe.
+*/
+package tool
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"sort"
+
+	"github.com/adobe/sledgehammer/slh/environment"
+	"github.com/adobe/sledgehammer/slh/mount"
+)
+
+// fingerprint contains everything a daemon container is created from
+type fingerprint struct {
+	Image       string               `json:"image"`
+	Daemon      *Daemon              `json:"daemon"`
+	Runtime     *Runtime             `json:"runtime"`
+	Permissions *Permissions         `json:"permissions,omitempty"`
+	Mounts      []mount.Mount        `json:"mounts"`
+	Policies    []environment.Policy `json:"policies"`
+	Network     string               `json:"network,omitempty"`
+	Limits      *Limits              `json:"limits,omitempty"`
+	Security    string               `json:"security,omitempty"`
+}
+
+// Fingerprint will return a hash of the configuration a daemon container is created with.
+// If the fingerprint changes, a running daemon does not match the configuration anymore and needs to be recreated.
+// The values of host variables are not part of the fingerprint, they are passed with every execution.
+func Fingerprint(opt *ExecutionOptions) string {
+	// transient mounts are never mounted into daemons
+	mounts := append([]mount.Mount{}, opt.Mounts...)
+	sort.Slice(mounts, func(i, j int) bool {
+		return mounts[i].ContainerTarget() < mounts[j].ContainerTarget()
+	})
+	// the network of the tool is already part of the runtime
+	network := ""
+	if networkMode(opt) != Network(opt.Tool, "", "") {
+		network = networkMode(opt)
+	}
+	// the timeout applies to each execution, only the resources of the container need to match
+	var resources *Limits
+	if l := resourcesOnly(limits(opt)); l != resourcesOnly(ResourceLimits(opt.Tool, Limits{}, Limits{})) {
+		resources = &l
+	}
+	// the profile the tool requests is already part of the runtime
+	security := ""
+	if securityProfile(opt) != SecurityProfile(opt.Tool) {
+		security = securityProfile(opt)
+	}
+	// the alias decides about the variables of its executions, they are passed with every execution
+	policies := []environment.Policy{}
+	for _, p := range opt.Policies {
+		if p.Source != environment.SourceAlias {
+			policies = append(policies, p)
+		}
+	}
+	b, err := json.Marshal(fingerprint{
+		Image:       FullImage(opt.Tool, opt.Version),
+		Daemon:      opt.Tool.Data().Daemon,
+		Runtime:     opt.Tool.Data().Runtime,
+		Permissions: opt.Tool.Data().Permissions,
+		Mounts:      mounts,
+		Policies:    policies,
+		Network:     network,
+		Limits:      resources,
+		Security:    security,
+	})
+	if err != nil {
+		return ""
+	}
+	hash := sha256.Sum256(b)
+	return hex.EncodeToString(hash[:])
+}
+
+func resourcesOnly(l Limits) Limits {
+	l.Timeout = ""
+	return l
+}
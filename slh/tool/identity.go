@@ -0,0 +1,261 @@
+/*
+Copyright 2018 Adobe
+All Rights Reserved.
+
+NOTICE: Adobe permits you to use, modify, and distribute this file in
+accordance with the terms of the Adobe license agreement accompanying
+it. If you have received this file from a source other than Adobe,
+then your use, modification, or distribution of it requires the prior
+written permission of Adobe.
+*/
+
+package tool
+
+import (
+	"archive/tar"
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/user"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+
+	docker "github.com/fsouza/go-dockerclient"
+	"github.com/sirupsen/logrus"
+)
+
+var (
+	// DefaultUserName is the name of the user in the container if the name of the host user cannot be used
+	DefaultUserName = "slh"
+	// CurrentUser will return the uid, gid, user name and group name of the calling user
+	CurrentUser = func() (int, int, string, string) {
+		uid, gid := os.Getuid(), os.Getgid()
+		name, group := DefaultUserName, DefaultUserName
+		if u, err := user.Current(); err == nil && userNameRegex.MatchString(u.Username) {
+			name, group = u.Username, u.Username
+		}
+		if g, err := user.LookupGroupId(strconv.Itoa(gid)); err == nil && userNameRegex.MatchString(g.Name) {
+			group = g.Name
+		}
+		return uid, gid, name, group
+	}
+
+	// nobody is the id of the user and group that exist in most images for unprivileged processes
+	nobody        = 65534
+	userNameRegex = regexp.MustCompile("^[a-z_][a-z0-9_.-]*$")
+)
+
+// identity is the user the tool runs with, it is written to the passwd and group files of the container
+type identity struct {
+	UID   int
+	GID   int
+	Name  string
+	Group string
+}
+
+// userIdentity will return the identity of the user if the tool runs with the uid:gid of the calling user.
+// No identity is synthesized if the tool defines its user, must run as root or if the user is root on the host.
+func userIdentity(opt *ExecutionOptions) *identity {
+	if len(opt.IdentityDir) == 0 || len(runtime(opt.Tool).User) > 0 || runtime(opt.Tool).Root {
+		return nil
+	}
+	uid, gid, name, group := CurrentUser()
+	if uid <= 0 || gid < 0 {
+		return nil
+	}
+	return &identity{UID: uid, GID: gid, Name: name, Group: group}
+}
+
+// entry will return the passwd entry of the user
+func (i *identity) entry() string {
+	return fmt.Sprintf("%s:x:%d:%d:%s:%s:/bin/sh", i.Name, i.UID, i.GID, i.Name, ContainerHome)
+}
+
+// passwd will add the user to the passwd file of the image.
+// The user is placed before an account of the image with the same uid, so the uid resolves to the user. An account with the same name is replaced.
+func (i *identity) passwd(image []byte) []byte {
+	return merge(image, i.Name, i.UID, i.entry(), nil)
+}
+
+// group will add the group of the user to the group file of the image, like passwd does for the user.
+// The user is a member of the root group, like the container is added to it.
+func (i *identity) group(image []byte) []byte {
+	return merge(image, i.Group, i.GID, fmt.Sprintf("%s:x:%d:", i.Group, i.GID), func(fields []string) []string {
+		if fields[2] == "0" && i.GID != 0 {
+			members := strings.Split(fields[3], ",")
+			if len(fields[3]) == 0 {
+				members = []string{}
+			}
+			fields[3] = strings.Join(append(members, i.Name), ",")
+		}
+		return fields
+	})
+}
+
+// merge will add the entry with the given name and id to the lines of a passwd or group file.
+// Update can change the fields of the other entries, group entries have 4 fields, passwd entries 7.
+func merge(file []byte, name string, id int, entry string, update func([]string) []string) []byte {
+	b := &bytes.Buffer{}
+	added := false
+	for _, line := range strings.Split(strings.TrimSpace(string(file)), "\n") {
+		fields := strings.Split(line, ":")
+		if len(fields) < 4 {
+			if len(strings.TrimSpace(line)) > 0 {
+				fmt.Fprintln(b, line)
+			}
+			continue
+		}
+		if fields[0] == name {
+			continue
+		}
+		if !added && fields[2] == strconv.Itoa(id) {
+			fmt.Fprintln(b, entry)
+			added = true
+		}
+		if update != nil {
+			fields = update(fields)
+		}
+		fmt.Fprintln(b, strings.Join(fields, ":"))
+	}
+	if !added {
+		fmt.Fprintln(b, entry)
+	}
+	return b.Bytes()
+}
+
+// identityDir will return the directory with the passwd and group files for the image of the tool
+func identityDir(opt *ExecutionOptions) string {
+	hash := sha256.Sum256([]byte(FullImage(opt.Tool, opt.Version)))
+	return filepath.Join(opt.IdentityDir, hex.EncodeToString(hash[:4]))
+}
+
+// identityMounts will return the passwd and group files of the user together with its home directory.
+// The home directory is a tmpfs, unless the tool keeps its home directory in a volume.
+func identityMounts(opt *ExecutionOptions) []docker.HostMount {
+	if userIdentity(opt) == nil {
+		return nil
+	}
+	dir := identityDir(opt)
+	mounts := []docker.HostMount{
+		{Source: filepath.Join(dir, "passwd"), Target: "/etc/passwd", Type: "bind", ReadOnly: true},
+		{Source: filepath.Join(dir, "group"), Target: "/etc/group", Type: "bind", ReadOnly: true},
+	}
+	if !runtime(opt.Tool).Home {
+		mounts = append(mounts, docker.HostMount{Target: ContainerHome, Type: "tmpfs"})
+	}
+	return mounts
+}
+
+// prepareIdentity will write the passwd and group files of the image with the user added, they are shared by all tools with the same image.
+// The files are only generated again if the image or the user changed, running containers keep using the files they have been started with.
+func prepareIdentity(opt *ExecutionOptions) error {
+	id := userIdentity(opt)
+	if id == nil {
+		return nil
+	}
+	dir := identityDir(opt)
+	image, err := opt.Docker.Docker.InspectImage(FullImage(opt.Tool, opt.Version))
+	if err != nil {
+		return err
+	}
+	source := []byte(image.ID + "\n" + id.entry() + "\n" + id.Group + "\n")
+	if existing, err := ioutil.ReadFile(filepath.Join(dir, "source")); err == nil && bytes.Equal(existing, source) {
+		return nil
+	}
+	defer opt.Profile.Span("prepare identity")()
+	files, err := imageAccounts(opt)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+	logrus.WithField("dir", dir).WithField("user", id.Name).Info("Writing identity of the user")
+	for _, f := range []struct {
+		name    string
+		content []byte
+	}{
+		{"passwd", id.passwd(files["passwd"])},
+		{"group", id.group(files["group"])},
+		// the source is written last, an interrupted run generates the files again
+		{"source", source},
+	} {
+		if err := writeFile(filepath.Join(dir, f.name), f.content); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// imageAccounts will read the passwd and group files of the image from a container that is never started.
+// Images without these files get the accounts of root and nobody.
+func imageAccounts(opt *ExecutionOptions) (map[string][]byte, error) {
+	files := map[string][]byte{
+		"passwd": []byte(fmt.Sprintf("root:x:0:0:root:/root:/bin/sh\nnobody:x:%d:%d:nobody:/nonexistent:/sbin/nologin\n", nobody, nobody)),
+		"group":  []byte(fmt.Sprintf("root:x:0:\nnogroup:x:%d:\n", nobody)),
+	}
+	resp, err := opt.Docker.Docker.CreateContainer(docker.CreateContainerOptions{
+		Config: &docker.Config{
+			Image: FullImage(opt.Tool, opt.Version),
+			// the container is never started, but docker requires a command
+			Entrypoint: []string{"true"},
+			Labels:     labels(opt, false),
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+	defer opt.Docker.Docker.RemoveContainer(docker.RemoveContainerOptions{
+		Force: true,
+		ID:    resp.ID,
+	})
+	for name := range files {
+		archive := &bytes.Buffer{}
+		err := opt.Docker.Docker.DownloadFromContainer(resp.ID, docker.DownloadFromContainerOptions{
+			Path:         "/etc/" + name,
+			OutputStream: archive,
+		})
+		if err != nil {
+			logrus.WithField("file", name).WithError(err).Info("The image has no accounts, using the defaults")
+			continue
+		}
+		r := tar.NewReader(archive)
+		if _, err := r.Next(); err != nil {
+			return nil, err
+		}
+		content, err := ioutil.ReadAll(r)
+		if err != nil {
+			return nil, err
+		}
+		files[name] = content
+	}
+	return files, nil
+}
+
+// writeFile will replace the file with the content.
+// Parallel invocations write the same content, renaming makes sure nobody reads a partial file.
+func writeFile(file string, content []byte) error {
+	tmp, err := ioutil.TempFile(filepath.Dir(file), filepath.Base(file))
+	if err != nil {
+		return err
+	}
+	_, err = tmp.Write(content)
+	if cerr := tmp.Close(); err == nil {
+		err = cerr
+	}
+	if err == nil {
+		err = os.Chmod(tmp.Name(), 0644)
+	}
+	if err == nil {
+		err = os.Rename(tmp.Name(), file)
+	}
+	if err != nil {
+		os.Remove(tmp.Name())
+	}
+	return err
+}
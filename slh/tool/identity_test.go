@@ -0,0 +1,225 @@
+/*
+Copyright 2018 Adobe
+All Rights Reserved.
+
+NOTICE: Adobe permits you to use, modify, and distribute this file in
+accordance with the terms of the Adobe license agreement accompanying
+it. If you have received this file from a source other than Adobe,
+then your use, modification, or distribution of it requires the prior
+written permission of Adobe.
+*/
+
+package tool_test
+
+import (
+	"archive/tar"
+	"crypto/sha256"
+	"encoding/hex"
+	"io/ioutil"
+	"path"
+	"path/filepath"
+	"testing"
+
+	docker "github.com/fsouza/go-dockerclient"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/adobe/sledgehammer/mocks"
+	"github.com/adobe/sledgehammer/slh/config"
+	"github.com/adobe/sledgehammer/slh/tool"
+	"github.com/adobe/sledgehammer/utils/test"
+)
+
+func TestIdentity(t *testing.T) {
+	dir := test.NewTmpDir(t)
+	defer test.DeleteTmpDir(dir, t)
+	previous := tool.CurrentUser
+	defer func() { tool.CurrentUser = previous }()
+	tool.CurrentUser = func() (int, int, string, string) {
+		return 1000, 20, "jane", "staff"
+	}
+	hash := sha256.Sum256([]byte("git"))
+	files := filepath.Join(dir, hex.EncodeToString(hash[:4]))
+	passwd := docker.HostMount{Source: filepath.Join(files, "passwd"), Target: "/etc/passwd", Type: "bind", ReadOnly: true}
+	group := docker.HostMount{Source: filepath.Join(files, "group"), Target: "/etc/group", Type: "bind", ReadOnly: true}
+	accounts := map[string]string{
+		"/etc/passwd": "root:x:0:0:root:/root:/bin/ash\nnode:x:1000:1000::/home/node:/bin/sh\n",
+		"/etc/group":  "root:x:0:root\nnode:x:1000:\n",
+	}
+
+	cases := []struct {
+		name    string
+		runtime *tool.Runtime
+		dir     string
+		prepare func(*mocks.MockClient)
+		mounts  []docker.HostMount
+		env     []string
+	}{
+		{
+			name: "User with a temporary home",
+			dir:  dir,
+			prepare: func(dockerMock *mocks.MockClient) {
+				// the accounts are read from the image
+				dockerMock.EXPECT().InspectImage("git").Return(&docker.Image{ID: "sha256:git"}, nil)
+				dockerMock.EXPECT().CreateContainer(gomock.Any()).DoAndReturn(func(opts docker.CreateContainerOptions) (*docker.Container, error) {
+					assert.Equal(t, "git", opts.Config.Image)
+					return &docker.Container{ID: "accounts"}, nil
+				})
+				dockerMock.EXPECT().DownloadFromContainer("accounts", gomock.Any()).Times(2).DoAndReturn(func(id string, opts docker.DownloadFromContainerOptions) error {
+					w := tar.NewWriter(opts.OutputStream)
+					w.WriteHeader(&tar.Header{Name: path.Base(opts.Path), Mode: 0644, Size: int64(len(accounts[opts.Path]))})
+					w.Write([]byte(accounts[opts.Path]))
+					return w.Close()
+				})
+				dockerMock.EXPECT().RemoveContainer(docker.RemoveContainerOptions{ID: "accounts", Force: true})
+			},
+			mounts: []docker.HostMount{passwd, group, {Target: "/home/slh", Type: "tmpfs"}},
+			env:    []string{"PATH=/bin", "HOME=/home/slh", "USER=jane"},
+		},
+		{
+			name:    "User with a home in a volume",
+			runtime: &tool.Runtime{Home: true},
+			dir:     dir,
+			prepare: func(dockerMock *mocks.MockClient) {
+				dockerMock.EXPECT().InspectVolume(gomock.Any()).Return(&docker.Volume{}, nil)
+				// the files of the image are up to date
+				dockerMock.EXPECT().InspectImage("git").Return(&docker.Image{ID: "sha256:git"}, nil)
+			},
+			mounts: []docker.HostMount{
+				{Source: tool.CacheVolume(&tool.LocalTool{Core: tool.Data{Name: "git"}}, "/home/slh"), Target: "/home/slh", Type: "volume"},
+				passwd,
+				group,
+			},
+			env: []string{"PATH=/bin", "HOME=/home/slh", "USER=jane"},
+		},
+		{
+			name:    "Tool that must run as root",
+			runtime: &tool.Runtime{Root: true},
+			dir:     dir,
+			env:     []string{"PATH=/bin", "HOME=/Users/jane", "USER=jane.doe"},
+		},
+		{
+			name:    "Tool with its own user",
+			runtime: &tool.Runtime{User: "node"},
+			dir:     dir,
+			env:     []string{"PATH=/bin", "HOME=/Users/jane", "USER=jane.doe"},
+		},
+		{
+			name: "No identity directory",
+			env:  []string{"PATH=/bin", "HOME=/Users/jane", "USER=jane.doe"},
+		},
+	}
+
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			mockCtrl := gomock.NewController(t)
+			defer mockCtrl.Finish()
+			dockerMock := mocks.NewMockClient(mockCtrl)
+			if tt.prepare != nil {
+				tt.prepare(dockerMock)
+			}
+			dockerMock.EXPECT().CreateContainer(gomock.Any()).DoAndReturn(func(opts docker.CreateContainerOptions) (*docker.Container, error) {
+				assert.Equal(t, tt.mounts, opts.HostConfig.Mounts)
+				assert.Equal(t, tt.env, opts.Config.Env)
+				return &docker.Container{ID: "foo"}, nil
+			})
+			dockerMock.EXPECT().StartContainer("foo", gomock.Any())
+
+			_, err := tool.StartIfDaemon(&tool.ExecutionOptions{
+				Tool: &tool.LocalTool{
+					Core: tool.Data{
+						Name:    "git",
+						Image:   "git",
+						Daemon:  &tool.Daemon{Entry: []string{"sh"}},
+						Runtime: tt.runtime,
+					},
+				},
+				Docker:      &config.Docker{Docker: dockerMock},
+				Env:         []string{"PATH=/bin", "HOME=/Users/jane", "USER=jane.doe"},
+				IdentityDir: tt.dir,
+			})
+			assert.NoError(t, err)
+		})
+	}
+
+	// the accounts of the image are kept, the user takes precedence over the account with the same uid
+	b, err := ioutil.ReadFile(passwd.Source)
+	assert.NoError(t, err)
+	assert.Equal(t, "root:x:0:0:root:/root:/bin/ash\njane:x:1000:20:jane:/home/slh:/bin/sh\nnode:x:1000:1000::/home/node:/bin/sh\n", string(b))
+	b, err = ioutil.ReadFile(group.Source)
+	assert.NoError(t, err)
+	assert.Equal(t, "root:x:0:root,jane\nnode:x:1000:\nstaff:x:20:\n", string(b))
+}
+
+func TestIdentityWithoutAccounts(t *testing.T) {
+	dir := test.NewTmpDir(t)
+	defer test.DeleteTmpDir(dir, t)
+	previous := tool.CurrentUser
+	defer func() { tool.CurrentUser = previous }()
+	tool.CurrentUser = func() (int, int, string, string) {
+		return 1000, 20, "jane", "staff"
+	}
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+	dockerMock := mocks.NewMockClient(mockCtrl)
+
+	// images without accounts, e.g. from scratch, get root and nobody
+	dockerMock.EXPECT().InspectImage("static").Return(&docker.Image{ID: "sha256:static"}, nil)
+	dockerMock.EXPECT().CreateContainer(gomock.Any()).Return(&docker.Container{ID: "accounts"}, nil)
+	dockerMock.EXPECT().DownloadFromContainer("accounts", gomock.Any()).Times(2).Return(&docker.Error{Status: 404})
+	dockerMock.EXPECT().RemoveContainer(gomock.Any())
+	var passwd string
+	dockerMock.EXPECT().CreateContainer(gomock.Any()).DoAndReturn(func(opts docker.CreateContainerOptions) (*docker.Container, error) {
+		passwd = opts.HostConfig.Mounts[0].Source
+		return &docker.Container{ID: "foo"}, nil
+	})
+	dockerMock.EXPECT().StartContainer("foo", gomock.Any())
+
+	_, err := tool.StartIfDaemon(&tool.ExecutionOptions{
+		Tool: &tool.LocalTool{
+			Core: tool.Data{
+				Name:   "static",
+				Image:  "static",
+				Daemon: &tool.Daemon{Entry: []string{"sh"}},
+			},
+		},
+		Docker:      &config.Docker{Docker: dockerMock},
+		IdentityDir: dir,
+	})
+	assert.NoError(t, err)
+
+	b, err := ioutil.ReadFile(passwd)
+	assert.NoError(t, err)
+	assert.Equal(t, "root:x:0:0:root:/root:/bin/sh\nnobody:x:65534:65534:nobody:/nonexistent:/sbin/nologin\njane:x:1000:20:jane:/home/slh:/bin/sh\n", string(b))
+	b, err = ioutil.ReadFile(filepath.Join(filepath.Dir(passwd), "group"))
+	assert.NoError(t, err)
+	assert.Equal(t, "root:x:0:jane\nnogroup:x:65534:\nstaff:x:20:\n", string(b))
+}
+
+func TestRootTool(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+	dockerMock := mocks.NewMockClient(mockCtrl)
+
+	dockerMock.EXPECT().CreateContainer(gomock.Any()).DoAndReturn(func(opts docker.CreateContainerOptions) (*docker.Container, error) {
+		assert.Equal(t, "0:0", opts.Config.User)
+		return &docker.Container{ID: "foo"}, nil
+	})
+	dockerMock.EXPECT().StartContainer("foo", gomock.Any())
+	dockerMock.EXPECT().AttachToContainer(gomock.Any())
+	dockerMock.EXPECT().InspectContainer("foo").Return(&docker.Container{}, nil)
+	dockerMock.EXPECT().RemoveContainer(gomock.Any())
+
+	_, err := tool.StartAndExecute(&tool.ExecutionOptions{
+		Tool: &tool.LocalTool{
+			Core: tool.Data{
+				Name:    "docker",
+				Image:   "docker",
+				Runtime: &tool.Runtime{Root: true},
+			},
+		},
+		IO:     limitedIO(),
+		Docker: &config.Docker{Docker: dockerMock},
+	})
+	assert.NoError(t, err)
+}
@@ -0,0 +1,154 @@
+/*
+Copyright 2018 Adobe
+All Rights Reserved.
+
+NOTICE: Adobe permits you to use, modify, and distribute this file in
+accordance with the terms of the Adobe license agreement accompanying
+it. If you have received this file from a source other than Adobe,
+then your use, modification, or distribution of it requires the prior
+written permission of Adobe.
+*/
+
+package tool
+
+import (
+	"strings"
+
+	"github.com/adobe/sledgehammer/slh/config"
+	"github.com/adobe/sledgehammer/slh/version"
+	docker "github.com/fsouza/go-dockerclient"
+	"github.com/sirupsen/logrus"
+)
+
+// Image is a single tagged local image of a tool
+type Image struct {
+	ID         string
+	Repository string
+	Version    string
+	Size       int64
+	// Tags are all tags of the image, also the ones of other repositories
+	Tags []string
+}
+
+// Name will return the name of the image including the tag
+func (i Image) Name() string {
+	return i.Repository + ":" + i.Version
+}
+
+// PrunePolicy decides which local images of a tool are outdated
+type PrunePolicy struct {
+	// Keep is the number of newest versions that are kept, 0 will keep all versions
+	Keep int
+	// Unselectable will remove all versions of installed tools that no alias can select
+	Unselectable bool
+	// Uninstalled will remove all versions of tools that have no alias
+	Uninstalled bool
+}
+
+// Empty will return true if the policy would not remove any image
+func (p PrunePolicy) Empty() bool {
+	return p.Keep <= 0 && !p.Unselectable && !p.Uninstalled
+}
+
+// Images will return all local images of the given tool
+func Images(client config.Docker, to Tool) ([]Image, error) {
+	repository := FullImage(to, "")
+	apiImages, err := client.Docker.ListImages(docker.ListImagesOptions{
+		Filter: fullImageName(to),
+	})
+	if err != nil {
+		return nil, err
+	}
+	images := []Image{}
+	for _, image := range apiImages {
+		for _, tag := range image.RepoTags {
+			if !strings.HasPrefix(tag, repository+":") {
+				continue
+			}
+			images = append(images, Image{
+				ID:         image.ID,
+				Repository: repository,
+				Version:    strings.TrimPrefix(tag, repository+":"),
+				Size:       image.Size,
+				Tags:       image.RepoTags,
+			})
+		}
+	}
+	return images, nil
+}
+
+// Prunable will return the images of a single repository that are outdated according to the policy.
+// The constraints are the version constraints of all aliases that use the repository, without constraints the repository is not installed.
+func Prunable(images []Image, constraints []string, policy PrunePolicy) []Image {
+	versions := []string{}
+	for _, image := range images {
+		versions = append(versions, image.Version)
+	}
+	// only semantic versions can be outdated, tags like latest or alpine are never pruned to keep the newest versions
+	newest := []string{}
+	for _, v := range version.Newest(versions) {
+		if version.Semantic(v) {
+			newest = append(newest, v)
+		}
+	}
+
+	prunable := []Image{}
+	for _, image := range images {
+		if shouldPrune(image.Version, newest, constraints, policy) {
+			prunable = append(prunable, image)
+		}
+	}
+	return prunable
+}
+
+func shouldPrune(v string, newest []string, constraints []string, policy PrunePolicy) bool {
+	if len(constraints) == 0 {
+		if policy.Uninstalled {
+			return true
+		}
+	} else if policy.Unselectable {
+		selectable := false
+		for _, constraint := range constraints {
+			selectable = selectable || version.Matches(v, constraint)
+		}
+		if !selectable {
+			return true
+		}
+	}
+	if policy.Keep > 0 {
+		for i, n := range newest {
+			if n == v {
+				return i >= policy.Keep
+			}
+		}
+	}
+	return false
+}
+
+// RemoveImage will remove the tag of the given image.
+// It will return the size of the image if the image has been removed completely, which is not the case if other tags still use it.
+func RemoveImage(client config.Docker, image Image) (int64, error) {
+	logrus.WithField("image", image.Name()).Info("Removing image")
+	err := client.Docker.RemoveImage(image.Name())
+	if err != nil {
+		return 0, err
+	}
+	_, err = client.Docker.InspectImage(image.ID)
+	if err == docker.ErrNoSuchImage {
+		return image.Size, nil
+	}
+	return 0, err
+}
+
+// Digest will return the digest of the image of the given tool and version.
+// Images that have been built locally have no digest, their id is returned instead.
+func Digest(client config.Docker, to Tool, tag string) (string, error) {
+	image, err := client.Docker.InspectImage(FullImage(to, tag))
+	if err != nil {
+		return "", err
+	}
+	if len(image.RepoDigests) > 0 {
+		return image.RepoDigests[0], nil
+	}
+	return image.ID, nil
+}
@@ -0,0 +1,174 @@
+/*
+Copyright 2018 Adobe
+All Rights Reserved.
+
+NOTICE: Adobe permits you to use, modify, and distribute this file in
+accordance with the terms of the Adobe license agreement accompanying
+it. If you have received this file from a source other than Adobe,
+then your use, modification, or distribution of it requires the prior
+written permission of Adobe.
+*/
+
+package tool_test
+
+import (
+	"errors"
+	"testing"
+
+	docker "github.com/fsouza/go-dockerclient"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/adobe/sledgehammer/mocks"
+	"github.com/adobe/sledgehammer/slh/config"
+	"github.com/adobe/sledgehammer/slh/tool"
+)
+
+func TestImages(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+	dockerMock := mocks.NewMockClient(mockCtrl)
+	dockerMock.EXPECT().ListImages(docker.ListImagesOptions{Filter: "foo"}).Return([]docker.APIImages{
+		{ID: "a", RepoTags: []string{"foo:1.0.0", "foo:latest", "bar:1.0.0"}, Size: 10},
+		{ID: "b", RepoTags: []string{"foo:2.0.0"}, Size: 20},
+	}, nil)
+
+	images, err := tool.Images(config.Docker{Docker: dockerMock}, &tool.LocalTool{Core: tool.Data{Name: "foo", Image: "foo"}})
+	assert.NoError(t, err)
+	assert.Equal(t, []tool.Image{
+		{ID: "a", Repository: "foo", Version: "1.0.0", Size: 10, Tags: []string{"foo:1.0.0", "foo:latest", "bar:1.0.0"}},
+		{ID: "a", Repository: "foo", Version: "latest", Size: 10, Tags: []string{"foo:1.0.0", "foo:latest", "bar:1.0.0"}},
+		{ID: "b", Repository: "foo", Version: "2.0.0", Size: 20, Tags: []string{"foo:2.0.0"}},
+	}, images)
+}
+
+func TestPrunable(t *testing.T) {
+	images := []tool.Image{
+		{Repository: "foo", Version: "1.0.0"},
+		{Repository: "foo", Version: "1.1.0"},
+		{Repository: "foo", Version: "2.0.0"},
+		{Repository: "foo", Version: "3.0.0"},
+	}
+
+	cases := []struct {
+		name        string
+		constraints []string
+		policy      tool.PrunePolicy
+		expected    []string
+	}{
+		{
+			name:     "Empty policy",
+			expected: []string{},
+		},
+		{
+			name:     "Keep the newest versions",
+			policy:   tool.PrunePolicy{Keep: 2},
+			expected: []string{"1.0.0", "1.1.0"},
+		},
+		{
+			name:        "Keep the newest versions of an installed tool",
+			constraints: []string{"^1"},
+			policy:      tool.PrunePolicy{Keep: 3},
+			expected:    []string{"1.0.0"},
+		},
+		{
+			name:        "Unselectable versions",
+			constraints: []string{"^1", "~3.0"},
+			policy:      tool.PrunePolicy{Unselectable: true},
+			expected:    []string{"2.0.0"},
+		},
+		{
+			name:     "Unselectable versions of an uninstalled tool are kept",
+			policy:   tool.PrunePolicy{Unselectable: true},
+			expected: []string{},
+		},
+		{
+			name:        "Uninstalled tools",
+			constraints: []string{"^1"},
+			policy:      tool.PrunePolicy{Uninstalled: true},
+			expected:    []string{},
+		},
+		{
+			name:     "Uninstalled tools are removed completely",
+			policy:   tool.PrunePolicy{Uninstalled: true, Keep: 1},
+			expected: []string{"1.0.0", "1.1.0", "2.0.0", "3.0.0"},
+		},
+		{
+			name:        "Policies are combined",
+			constraints: []string{"*"},
+			policy:      tool.PrunePolicy{Unselectable: true, Keep: 1},
+			expected:    []string{"1.0.0", "1.1.0", "2.0.0"},
+		},
+	}
+
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			versions := []string{}
+			for _, image := range tool.Prunable(images, tt.constraints, tt.policy) {
+				versions = append(versions, image.Version)
+			}
+			assert.Equal(t, tt.expected, versions)
+		})
+	}
+}
+
+func TestPrunableTags(t *testing.T) {
+	images := []tool.Image{
+		{Repository: "foo", Version: "latest"},
+		{Repository: "foo", Version: "1.0.0"},
+		{Repository: "foo", Version: "alpine"},
+		{Repository: "foo", Version: "2.0.0"},
+	}
+	versions := []string{}
+	for _, image := range tool.Prunable(images, []string{"*"}, tool.PrunePolicy{Keep: 1}) {
+		versions = append(versions, image.Version)
+	}
+	assert.Equal(t, []string{"1.0.0"}, versions)
+}
+
+func TestRemoveImage(t *testing.T) {
+	image := tool.Image{ID: "a", Repository: "foo", Version: "1.0.0", Size: 10}
+
+	cases := []struct {
+		name   string
+		before func(*mocks.MockClient)
+		freed  int64
+		err    error
+	}{
+		{
+			name: "Image removed",
+			before: func(m *mocks.MockClient) {
+				m.EXPECT().RemoveImage("foo:1.0.0")
+				m.EXPECT().InspectImage("a").Return(nil, docker.ErrNoSuchImage)
+			},
+			freed: 10,
+		},
+		{
+			name: "Image still tagged",
+			before: func(m *mocks.MockClient) {
+				m.EXPECT().RemoveImage("foo:1.0.0")
+				m.EXPECT().InspectImage("a").Return(&docker.Image{ID: "a"}, nil)
+			},
+		},
+		{
+			name: "Image in use",
+			before: func(m *mocks.MockClient) {
+				m.EXPECT().RemoveImage("foo:1.0.0").Return(errors.New("image is in use"))
+			},
+			err: errors.New("image is in use"),
+		},
+	}
+
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			mockCtrl := gomock.NewController(t)
+			defer mockCtrl.Finish()
+			dockerMock := mocks.NewMockClient(mockCtrl)
+			tt.before(dockerMock)
+
+			freed, err := tool.RemoveImage(config.Docker{Docker: dockerMock}, image)
+			assert.Equal(t, tt.err, err)
+			assert.Equal(t, tt.freed, freed)
+		})
+	}
+}
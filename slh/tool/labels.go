@@ -0,0 +1,144 @@
+/*
+Copyright 2018 Adobe
+All Rights Reserved.
+
+NOTICE: Adobe permits you to use, modify, and distribute this file in
+accordance with the terms of the Adobe license agreement accompanying
+it. If you have received this file from a source other than Adobe,
+then your use, modification, or distribution of it requires the prior
+written permission of Adobe.
+*/
+package tool
+
+import (
+	"os"
+	"strconv"
+
+	"github.com/adobe/sledgehammer/slh/config"
+	"github.com/adobe/sledgehammer/slh/version"
+	"github.com/adobe/sledgehammer/utils"
+	docker "github.com/fsouza/go-dockerclient"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// LabelTool is the label that marks a container as created by Sledgehammer, its value is the name of the tool
+	LabelTool = "com.adobe.sledgehammer.tool"
+	// LabelRegistry is the label with the registry of the tool
+	LabelRegistry = "com.adobe.sledgehammer.registry"
+	// LabelVersion is the label with the version of the tool
+	LabelVersion = "com.adobe.sledgehammer.version"
+	// LabelAlias is the label with the alias the tool has been called with
+	LabelAlias = "com.adobe.sledgehammer.alias"
+	// LabelSlhVersion is the label with the version of Sledgehammer that created the container
+	LabelSlhVersion = "com.adobe.sledgehammer.slh-version"
+	// LabelPID is the label with the process id of the Sledgehammer process that created the container
+	LabelPID = "com.adobe.sledgehammer.pid"
+	// LabelInstallation is the label with the installation of Sledgehammer that created the container, only this installation removes it
+	LabelInstallation = "com.adobe.sledgehammer.installation"
+	// LabelDaemon is the label that marks daemon containers
+	LabelDaemon = "com.adobe.sledgehammer.daemon"
+	// LabelCache is the label that marks a volume as cache of a tool, its value is the path of the cache in the container
+	LabelCache = "com.adobe.sledgehammer.cache"
+	// LabelImage is the label with the image that has created a cache
+	LabelImage = "com.adobe.sledgehammer.image"
+)
+
+// Container is a container that has been created by Sledgehammer
+type Container struct {
+	ID           string
+	Tool         string
+	Registry     string
+	Version      string
+	Alias        string
+	State        string
+	PID          int
+	Daemon       bool
+	Installation string
+}
+
+// labels will return the labels for a container of the tool
+func labels(opt *ExecutionOptions, daemon bool) map[string]string {
+	return map[string]string{
+		LabelTool:         opt.Tool.Data().Name,
+		LabelRegistry:     opt.Tool.Data().Registry,
+		LabelVersion:      opt.Version,
+		LabelAlias:        opt.Alias,
+		LabelSlhVersion:   version.Version,
+		LabelPID:          strconv.Itoa(os.Getpid()),
+		LabelDaemon:       strconv.FormatBool(daemon),
+		LabelInstallation: opt.Installation,
+	}
+}
+
+// Containers will return all containers that have been created by Sledgehammer
+func Containers(client config.Docker) ([]Container, error) {
+	containers := []Container{}
+	list, err := client.Docker.ListContainers(docker.ListContainersOptions{
+		All: true,
+		Filters: map[string][]string{
+			"label": {LabelTool},
+		},
+	})
+	if err != nil {
+		return containers, err
+	}
+	for _, c := range list {
+		pid, _ := strconv.Atoi(c.Labels[LabelPID])
+		daemon, _ := strconv.ParseBool(c.Labels[LabelDaemon])
+		containers = append(containers, Container{
+			ID:           c.ID,
+			Tool:         c.Labels[LabelTool],
+			Registry:     c.Labels[LabelRegistry],
+			Version:      c.Labels[LabelVersion],
+			Alias:        c.Labels[LabelAlias],
+			State:        c.State,
+			PID:          pid,
+			Daemon:       daemon,
+			Installation: c.Labels[LabelInstallation],
+		})
+	}
+	return containers, nil
+}
+
+// Orphaned will return true if nobody is going to remove the container anymore.
+// Only containers of the given installation can be orphaned, containers of other users or hosts are left alone. Their processes and daemons are unknown.
+// Containers are never orphaned as long as the Sledgehammer process that created them is running, including the own process that might still start or use them.
+// After that, tool containers are always orphaned and daemons if they are not running or not cached anymore.
+func (c Container) Orphaned(installation string, daemons map[string]bool) bool {
+	if len(c.Installation) == 0 || c.Installation != installation {
+		return false
+	}
+	if c.PID > 0 && (c.PID == os.Getpid() || utils.ProcessAlive(c.PID)) {
+		return false
+	}
+	if !c.Daemon {
+		return true
+	}
+	return c.State != "running" || !daemons[c.ID]
+}
+
+// Collect will remove all orphaned containers of the given installation and return them.
+// Daemons are the ids of all daemon containers that are still in use.
+func Collect(client config.Docker, installation string, daemons map[string]bool) ([]Container, error) {
+	removed := []Container{}
+	containers, err := Containers(client)
+	if err != nil {
+		return removed, err
+	}
+	for _, c := range containers {
+		if !c.Orphaned(installation, daemons) {
+			continue
+		}
+		logrus.WithField("id", c.ID).WithField("tool", c.Tool).Infoln("Removing orphaned container")
+		err := client.Docker.RemoveContainer(docker.RemoveContainerOptions{
+			Force: true,
+			ID:    c.ID,
+		})
+		if _, ok := err.(*docker.NoSuchContainer); err != nil && !ok {
+			return removed, err
+		}
+		removed = append(removed, c)
+	}
+	return removed, nil
+}
@@ -0,0 +1,141 @@
+/*
+Copyright 2018 Adobe
+All Rights Reserved.
+
+NOTICE: Adobe permits you to use, modify, and distribute this file in
+accordance with the terms of the Adobe license agreement accompanying
+it. If you have received this file from a source other than Adobe,
+then your use, modification, or distribution of it requires the prior
+written permission of Adobe.
+*/
+package tool_test
+
+import (
+	"os"
+	"strconv"
+	"testing"
+
+	docker "github.com/fsouza/go-dockerclient"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/adobe/sledgehammer/mocks"
+	"github.com/adobe/sledgehammer/slh/config"
+	"github.com/adobe/sledgehammer/slh/tool"
+)
+
+func TestLabels(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+	dockerMock := mocks.NewMockClient(mockCtrl)
+
+	dockerMock.EXPECT().CreateContainer(gomock.Any()).DoAndReturn(func(opts docker.CreateContainerOptions) (*docker.Container, error) {
+		assert.Equal(t, "foo", opts.Config.Labels[tool.LabelTool])
+		assert.Equal(t, "bar", opts.Config.Labels[tool.LabelRegistry])
+		assert.Equal(t, "1.0", opts.Config.Labels[tool.LabelVersion])
+		assert.Equal(t, "baz", opts.Config.Labels[tool.LabelAlias])
+		assert.Equal(t, strconv.Itoa(os.Getpid()), opts.Config.Labels[tool.LabelPID])
+		assert.Equal(t, "true", opts.Config.Labels[tool.LabelDaemon])
+		assert.Equal(t, "id@host", opts.Config.Labels[tool.LabelInstallation])
+		assert.NotEmpty(t, opts.Config.Labels[tool.LabelSlhVersion])
+		return &docker.Container{ID: "foo"}, nil
+	})
+	dockerMock.EXPECT().StartContainer("foo", gomock.Any())
+
+	_, err := tool.StartIfDaemon(&tool.ExecutionOptions{
+		Tool: &tool.LocalTool{
+			Core: tool.Data{
+				Name:     "foo",
+				Registry: "bar",
+				Image:    "foo",
+				Daemon:   &tool.Daemon{Entry: []string{"sh"}},
+			},
+		},
+		Version:      "1.0",
+		Alias:        "baz",
+		Installation: "id@host",
+		Docker:       &config.Docker{Docker: dockerMock},
+	})
+	assert.NoError(t, err)
+}
+
+func TestCollect(t *testing.T) {
+	// the parent process is running, this one is not
+	running := strconv.Itoa(os.Getppid())
+	gone := "999999999"
+
+	cases := []struct {
+		name       string
+		containers []docker.APIContainers
+		daemons    map[string]bool
+		removed    []string
+	}{
+		{
+			name: "No containers",
+		},
+		{
+			name: "Tool container of a running process",
+			containers: []docker.APIContainers{
+				{ID: "a", State: "running", Labels: map[string]string{tool.LabelTool: "foo", tool.LabelPID: running, tool.LabelDaemon: "false", tool.LabelInstallation: "id@host"}},
+			},
+		},
+		{
+			name: "Tool containers of a killed process",
+			containers: []docker.APIContainers{
+				{ID: "a", State: "running", Labels: map[string]string{tool.LabelTool: "foo", tool.LabelPID: gone, tool.LabelDaemon: "false", tool.LabelInstallation: "id@host"}},
+				{ID: "b", State: "exited", Labels: map[string]string{tool.LabelTool: "foo", tool.LabelPID: gone, tool.LabelDaemon: "false", tool.LabelInstallation: "id@host"}},
+			},
+			removed: []string{"a", "b"},
+		},
+		{
+			name: "Containers of other installations",
+			containers: []docker.APIContainers{
+				{ID: "a", State: "exited", Labels: map[string]string{tool.LabelTool: "foo", tool.LabelPID: gone, tool.LabelDaemon: "false", tool.LabelInstallation: "other@host"}},
+				{ID: "b", State: "exited", Labels: map[string]string{tool.LabelTool: "foo", tool.LabelPID: gone, tool.LabelDaemon: "false", tool.LabelInstallation: "id@other"}},
+				{ID: "c", State: "exited", Labels: map[string]string{tool.LabelTool: "foo", tool.LabelPID: gone, tool.LabelDaemon: "false"}},
+			},
+		},
+		{
+			name: "Containers of the own process",
+			containers: []docker.APIContainers{
+				{ID: "a", State: "created", Labels: map[string]string{tool.LabelTool: "foo", tool.LabelPID: strconv.Itoa(os.Getpid()), tool.LabelDaemon: "false", tool.LabelInstallation: "id@host"}},
+				{ID: "b", State: "running", Labels: map[string]string{tool.LabelTool: "foo", tool.LabelPID: strconv.Itoa(os.Getpid()), tool.LabelDaemon: "true", tool.LabelInstallation: "id@host"}},
+			},
+		},
+		{
+			name: "Daemons",
+			containers: []docker.APIContainers{
+				{ID: "cached", State: "running", Labels: map[string]string{tool.LabelTool: "foo", tool.LabelPID: gone, tool.LabelDaemon: "true", tool.LabelInstallation: "id@host"}},
+				{ID: "uncached", State: "running", Labels: map[string]string{tool.LabelTool: "foo", tool.LabelPID: gone, tool.LabelDaemon: "true", tool.LabelInstallation: "id@host"}},
+				{ID: "starting", State: "created", Labels: map[string]string{tool.LabelTool: "foo", tool.LabelPID: running, tool.LabelDaemon: "true", tool.LabelInstallation: "id@host"}},
+				{ID: "exited", State: "exited", Labels: map[string]string{tool.LabelTool: "foo", tool.LabelPID: gone, tool.LabelDaemon: "true", tool.LabelInstallation: "id@host"}},
+			},
+			daemons: map[string]bool{"cached": true, "exited": true},
+			removed: []string{"uncached", "exited"},
+		},
+	}
+
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			mockCtrl := gomock.NewController(t)
+			defer mockCtrl.Finish()
+			dockerMock := mocks.NewMockClient(mockCtrl)
+
+			dockerMock.EXPECT().ListContainers(docker.ListContainersOptions{
+				All:     true,
+				Filters: map[string][]string{"label": {tool.LabelTool}},
+			}).Return(tt.containers, nil)
+			for _, id := range tt.removed {
+				dockerMock.EXPECT().RemoveContainer(docker.RemoveContainerOptions{ID: id, Force: true})
+			}
+
+			removed, err := tool.Collect(config.Docker{Docker: dockerMock}, "id@host", tt.daemons)
+			assert.NoError(t, err)
+			ids := []string{}
+			for _, c := range removed {
+				ids = append(ids, c.ID)
+			}
+			assert.ElementsMatch(t, tt.removed, ids)
+		})
+	}
+}
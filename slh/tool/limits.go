@@ -0,0 +1,227 @@
+/*
+Copyright 2018 Adobe
+All Rights Reserved.
+
+NOTICE: Adobe permits you to use, modify, and distribute this file in
+accordance with the terms of the Adobe license agreement accompanying
+it. If you have received this file from a source other than Adobe,
+then your use, modification, or distribution of it requires the prior
+written permission of Adobe.
+*/
+
+package tool
+
+import (
+	"fmt"
+	"strconv"
+	"sync"
+	"syscall"
+	"time"
+
+	units "github.com/docker/go-units"
+	docker "github.com/fsouza/go-dockerclient"
+	"github.com/sirupsen/logrus"
+)
+
+var (
+	// ExitCodeTimeout is the exit code of a tool that has been stopped because it reached its timeout, like timeout(1)
+	ExitCodeTimeout = 124
+	// ExitCodeOutOfMemory is the exit code of a tool that has been killed because it exceeded its memory, like a SIGKILL
+	ExitCodeOutOfMemory = 137
+	// StopGrace is the time in seconds a tool gets to terminate after its timeout before it is killed
+	StopGrace uint = 10
+	// cpuPeriod is the scheduler period in microseconds the cpu quota is based on
+	cpuPeriod int64 = 100000
+)
+
+// TimeoutError will be thrown if the tool has been stopped because it ran longer than its timeout
+type TimeoutError struct {
+	Tool    string
+	Timeout string
+}
+
+func (e *TimeoutError) Error() string {
+	return fmt.Sprintf("The tool %s has been stopped because it ran longer than %s", e.Tool, e.Timeout)
+}
+
+// ExitCode will return the exit code Sledgehammer exits with
+func (e *TimeoutError) ExitCode() int {
+	return ExitCodeTimeout
+}
+
+// OutOfMemoryError will be thrown if the tool has been killed because it needed more memory than it is allowed to use
+type OutOfMemoryError struct {
+	Tool   string
+	Memory string
+}
+
+func (e *OutOfMemoryError) Error() string {
+	if len(e.Memory) == 0 {
+		return fmt.Sprintf("The tool %s has been killed because it ran out of memory", e.Tool)
+	}
+	return fmt.Sprintf("The tool %s has been killed because it needed more than %s of memory", e.Tool, e.Memory)
+}
+
+// ExitCode will return the exit code Sledgehammer exits with
+func (e *OutOfMemoryError) ExitCode() int {
+	return ExitCodeOutOfMemory
+}
+
+// ResourceLimits will return the limits of the tool.
+// Each limit of the alias takes precedence over the limit of the tool, which takes precedence over the global limit.
+func ResourceLimits(to Tool, alias Limits, global Limits) Limits {
+	layers := []Limits{alias, global}
+	if runtime(to).Limits != nil {
+		layers = []Limits{alias, *runtime(to).Limits, global}
+	}
+	limits := Limits{}
+	for i := len(layers) - 1; i >= 0; i-- {
+		if len(layers[i].CPUs) > 0 {
+			limits.CPUs = layers[i].CPUs
+		}
+		if len(layers[i].Memory) > 0 {
+			limits.Memory = layers[i].Memory
+		}
+		if layers[i].Pids > 0 {
+			limits.Pids = layers[i].Pids
+		}
+		if len(layers[i].Timeout) > 0 {
+			limits.Timeout = layers[i].Timeout
+		}
+	}
+	return limits
+}
+
+// Validate will check that all limits can be parsed
+func (l Limits) Validate() error {
+	_, err := l.resources(&docker.HostConfig{})
+	if err != nil {
+		return err
+	}
+	_, err = l.timeout()
+	return err
+}
+
+// Empty will return true if no limit is set
+func (l Limits) Empty() bool {
+	return l == Limits{}
+}
+
+// String will describe the limits in the way they can be set
+func (l Limits) String() string {
+	desc := ""
+	add := func(name string, value string) {
+		if len(desc) > 0 {
+			desc += ", "
+		}
+		desc += name + " " + value
+	}
+	if len(l.CPUs) > 0 {
+		add("cpus", l.CPUs)
+	}
+	if len(l.Memory) > 0 {
+		add("memory", l.Memory)
+	}
+	if l.Pids > 0 {
+		add("pids", strconv.FormatInt(l.Pids, 10))
+	}
+	if len(l.Timeout) > 0 {
+		add("timeout", l.Timeout)
+	}
+	return desc
+}
+
+// resources will restrict the cpus, the memory and the number of processes of the container
+func (l Limits) resources(host *docker.HostConfig) (*docker.HostConfig, error) {
+	if len(l.CPUs) > 0 {
+		cpus, err := strconv.ParseFloat(l.CPUs, 64)
+		if err != nil || cpus <= 0 {
+			return nil, &InvalidRuntimeError{Setting: "cpus", Value: l.CPUs}
+		}
+		host.CPUPeriod = cpuPeriod
+		host.CPUQuota = int64(cpus * float64(cpuPeriod))
+	}
+	if len(l.Memory) > 0 {
+		memory, err := units.RAMInBytes(l.Memory)
+		if err != nil || memory <= 0 {
+			return nil, &InvalidRuntimeError{Setting: "memory", Value: l.Memory}
+		}
+		host.Memory = memory
+		// without swap the tool is killed when it exceeds the memory instead of slowing down the host
+		host.MemorySwap = memory
+	}
+	if l.Pids < 0 {
+		return nil, &InvalidRuntimeError{Setting: "pids", Value: strconv.FormatInt(l.Pids, 10)}
+	}
+	host.PidsLimit = l.Pids
+	return host, nil
+}
+
+// timeout will return the time the tool can run, 0 if it can run forever
+func (l Limits) timeout() (time.Duration, error) {
+	if len(l.Timeout) == 0 {
+		return 0, nil
+	}
+	timeout, err := time.ParseDuration(l.Timeout)
+	if err != nil || timeout <= 0 {
+		return 0, &InvalidRuntimeError{Setting: "timeout", Value: l.Timeout}
+	}
+	return timeout, nil
+}
+
+// limits will return the limits of the execution, the limits of the tool are used for limits that are not set
+func limits(opt *ExecutionOptions) Limits {
+	return ResourceLimits(opt.Tool, opt.Limits, Limits{})
+}
+
+// watchTimeout will call stop as soon as the tool ran longer than its timeout.
+// The returned function has to be called when the tool exited, it returns an error if the tool has been stopped.
+func watchTimeout(opt *ExecutionOptions, stop func() error) (func() error, error) {
+	l := limits(opt)
+	timeout, err := l.timeout()
+	if err != nil || timeout == 0 {
+		return func() error { return nil }, err
+	}
+	var mutex sync.Mutex
+	stopped := false
+	timer := time.AfterFunc(timeout, func() {
+		mutex.Lock()
+		stopped = true
+		mutex.Unlock()
+		logrus.WithField("tool", opt.Tool.Data().Name).WithField("timeout", l.Timeout).Warn("Stopping tool, the timeout has been reached")
+		if err := stop(); err != nil {
+			logrus.WithField("tool", opt.Tool.Data().Name).Warnln("Could not stop the tool: ", err.Error())
+		}
+	})
+	return func() error {
+		timer.Stop()
+		mutex.Lock()
+		defer mutex.Unlock()
+		if stopped {
+			return &TimeoutError{Tool: opt.Tool.Data().Name, Timeout: l.Timeout}
+		}
+		return nil
+	}, nil
+}
+
+// stopContainer will return a function that stops the given container, it is killed if it does not terminate in time
+func stopContainer(opt *ExecutionOptions, containerID string) func() error {
+	return func() error {
+		return opt.Docker.Docker.StopContainer(containerID, StopGrace)
+	}
+}
+
+// stopExec will return a function that kills the process of an exec, other execs and the daemon itself keep running
+func stopExec(opt *ExecutionOptions, containerID string, execID string) func() error {
+	return func() error {
+		return signalExec(opt, containerID, execID)(syscall.SIGKILL)
+	}
+}
+
+// outOfMemory will return an error if the container has been killed because it exceeded its memory
+func outOfMemory(opt *ExecutionOptions, container *docker.Container) error {
+	if container == nil || !container.State.OOMKilled {
+		return nil
+	}
+	return &OutOfMemoryError{Tool: opt.Tool.Data().Name, Memory: limits(opt).Memory}
+}
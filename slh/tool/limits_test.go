@@ -0,0 +1,207 @@
+/*
+Copyright 2018 Adobe
+All Rights Reserved.
+
+NOTICE: Adobe permits you to use, modify, and distribute this file in
+accordance with the terms of the Adobe license agreement accompanying
+it. If you have received this file from a source other than Adobe,
+then your use, modification, or distribution of it requires the prior
+written permission of Adobe.
+*/
+
+package tool_test
+
+import (
+	"bytes"
+	"strings"
+	"syscall"
+	"testing"
+
+	docker "github.com/fsouza/go-dockerclient"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/adobe/sledgehammer/mocks"
+	"github.com/adobe/sledgehammer/slh/config"
+	"github.com/adobe/sledgehammer/slh/tool"
+)
+
+func limitedTool(limits *tool.Limits, daemon bool) tool.Tool {
+	to := &tool.LocalTool{
+		Core: tool.Data{
+			Name:    "lint",
+			Image:   "lint",
+			Runtime: &tool.Runtime{Limits: limits},
+		},
+	}
+	if daemon {
+		to.Core.Daemon = &tool.Daemon{Entry: []string{"sh"}}
+	}
+	return to
+}
+
+func limitedIO() *config.IO {
+	return &config.IO{
+		In:  strings.NewReader(""),
+		Out: &bytes.Buffer{},
+		Err: &bytes.Buffer{},
+	}
+}
+
+func TestResourceLimits(t *testing.T) {
+	cases := []struct {
+		name     string
+		tool     *tool.Limits
+		alias    tool.Limits
+		global   tool.Limits
+		expected tool.Limits
+	}{
+		{
+			name: "No limits",
+		},
+		{
+			name:     "Global limits",
+			global:   tool.Limits{CPUs: "2", Memory: "4g"},
+			expected: tool.Limits{CPUs: "2", Memory: "4g"},
+		},
+		{
+			name:     "Limits of the tool take precedence",
+			tool:     &tool.Limits{Memory: "1g", Pids: 100},
+			global:   tool.Limits{CPUs: "2", Memory: "4g", Timeout: "1h"},
+			expected: tool.Limits{CPUs: "2", Memory: "1g", Pids: 100, Timeout: "1h"},
+		},
+		{
+			name:     "Limits of the alias take precedence",
+			tool:     &tool.Limits{Memory: "1g", Pids: 100},
+			alias:    tool.Limits{Memory: "512m", Timeout: "5m"},
+			global:   tool.Limits{CPUs: "2", Timeout: "1h"},
+			expected: tool.Limits{CPUs: "2", Memory: "512m", Pids: 100, Timeout: "5m"},
+		},
+	}
+
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, tool.ResourceLimits(limitedTool(tt.tool, false), tt.alias, tt.global))
+		})
+	}
+}
+
+func TestLimitsValidate(t *testing.T) {
+	assert.NoError(t, tool.Limits{}.Validate())
+	assert.NoError(t, tool.Limits{CPUs: "0.5", Memory: "512m", Pids: 10, Timeout: "1m30s"}.Validate())
+	assert.Equal(t, &tool.InvalidRuntimeError{Setting: "cpus", Value: "-1"}, tool.Limits{CPUs: "-1"}.Validate())
+	assert.Equal(t, &tool.InvalidRuntimeError{Setting: "memory", Value: "lots"}, tool.Limits{Memory: "lots"}.Validate())
+	assert.Equal(t, &tool.InvalidRuntimeError{Setting: "pids", Value: "-1"}, tool.Limits{Pids: -1}.Validate())
+	assert.Equal(t, &tool.InvalidRuntimeError{Setting: "timeout", Value: "10"}, tool.Limits{Timeout: "10"}.Validate())
+}
+
+func TestContainerResources(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+	dockerMock := mocks.NewMockClient(mockCtrl)
+
+	dockerMock.EXPECT().CreateContainer(gomock.Any()).DoAndReturn(func(opts docker.CreateContainerOptions) (*docker.Container, error) {
+		assert.Equal(t, int64(100000), opts.HostConfig.CPUPeriod)
+		assert.Equal(t, int64(150000), opts.HostConfig.CPUQuota)
+		assert.Equal(t, int64(256*1024*1024), opts.HostConfig.Memory)
+		assert.Equal(t, int64(256*1024*1024), opts.HostConfig.MemorySwap)
+		assert.Equal(t, int64(64), opts.HostConfig.PidsLimit)
+		return &docker.Container{ID: "foo"}, nil
+	})
+	dockerMock.EXPECT().StartContainer("foo", gomock.Any())
+
+	_, err := tool.StartIfDaemon(&tool.ExecutionOptions{
+		Tool:   limitedTool(&tool.Limits{CPUs: "1.5", Memory: "1g", Pids: 64}, true),
+		Docker: &config.Docker{Docker: dockerMock},
+		Limits: tool.Limits{Memory: "256m"},
+	})
+	assert.NoError(t, err)
+
+	_, err = tool.StartIfDaemon(&tool.ExecutionOptions{
+		Tool:   limitedTool(&tool.Limits{Memory: "1x"}, true),
+		Docker: &config.Docker{Docker: dockerMock},
+	})
+	assert.Equal(t, &tool.InvalidRuntimeError{Setting: "memory", Value: "1x"}, err)
+}
+
+func TestTimeout(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+	dockerMock := mocks.NewMockClient(mockCtrl)
+
+	stopped := make(chan struct{})
+	dockerMock.EXPECT().CreateContainer(gomock.Any()).Return(&docker.Container{ID: "foo"}, nil)
+	dockerMock.EXPECT().StartContainer("foo", gomock.Any())
+	dockerMock.EXPECT().AttachToContainer(gomock.Any()).DoAndReturn(func(opts docker.AttachToContainerOptions) error {
+		<-stopped
+		return nil
+	})
+	dockerMock.EXPECT().StopContainer("foo", tool.StopGrace).DoAndReturn(func(id string, timeout uint) error {
+		close(stopped)
+		return nil
+	})
+	dockerMock.EXPECT().InspectContainer("foo").Return(&docker.Container{State: docker.State{ExitCode: 143}}, nil)
+	dockerMock.EXPECT().RemoveContainer(gomock.Any())
+
+	code, err := tool.StartAndExecute(&tool.ExecutionOptions{
+		Tool:   limitedTool(&tool.Limits{Timeout: "1h"}, false),
+		IO:     limitedIO(),
+		Docker: &config.Docker{Docker: dockerMock},
+		Limits: tool.Limits{Timeout: "10ms"},
+	})
+	assert.Equal(t, &tool.TimeoutError{Tool: "lint", Timeout: "10ms"}, err)
+	assert.Equal(t, tool.ExitCodeTimeout, code)
+}
+
+func TestExecTimeout(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+	dockerMock := mocks.NewMockClient(mockCtrl)
+	previous := tool.SignalProcess
+	defer func() { tool.SignalProcess = previous }()
+
+	stopped := make(chan struct{})
+	tool.SignalProcess = func(pid int, container string, sig syscall.Signal) error {
+		// only the process of the exec is killed
+		assert.Equal(t, 42, pid)
+		assert.Equal(t, "foo", container)
+		assert.Equal(t, syscall.SIGKILL, sig)
+		close(stopped)
+		return nil
+	}
+	dockerMock.EXPECT().CreateExec(gomock.Any()).Return(&docker.Exec{ID: "exec"}, nil)
+	dockerMock.EXPECT().StartExec("exec", gomock.Any()).DoAndReturn(func(id string, opts docker.StartExecOptions) error {
+		<-stopped
+		return nil
+	})
+	dockerMock.EXPECT().InspectExec("exec").Return(&docker.ExecInspect{ID: "exec", Running: true, Pid: 42}, nil)
+
+	code, err := tool.Execute("foo", &tool.ExecutionOptions{
+		Tool:   limitedTool(&tool.Limits{Timeout: "10ms"}, true),
+		IO:     limitedIO(),
+		Docker: &config.Docker{Docker: dockerMock},
+	})
+	assert.Equal(t, &tool.TimeoutError{Tool: "lint", Timeout: "10ms"}, err)
+	assert.Equal(t, tool.ExitCodeTimeout, code)
+}
+
+func TestOutOfMemory(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+	dockerMock := mocks.NewMockClient(mockCtrl)
+
+	dockerMock.EXPECT().CreateContainer(gomock.Any()).Return(&docker.Container{ID: "foo"}, nil)
+	dockerMock.EXPECT().StartContainer("foo", gomock.Any())
+	dockerMock.EXPECT().AttachToContainer(gomock.Any())
+	dockerMock.EXPECT().InspectContainer("foo").Return(&docker.Container{State: docker.State{ExitCode: 137, OOMKilled: true}}, nil)
+	dockerMock.EXPECT().RemoveContainer(gomock.Any())
+
+	code, err := tool.StartAndExecute(&tool.ExecutionOptions{
+		Tool:   limitedTool(&tool.Limits{Memory: "64m"}, false),
+		IO:     limitedIO(),
+		Docker: &config.Docker{Docker: dockerMock},
+	})
+	assert.Equal(t, &tool.OutOfMemoryError{Tool: "lint", Memory: "64m"}, err)
+	assert.Equal(t, tool.ExitCodeOutOfMemory, code)
+	assert.Equal(t, "The tool lint has been killed because it needed more than 64m of memory", err.Error())
+}
@@ -0,0 +1,340 @@
+/*
+Copyright 2018 Adobe
+All Rights Reserved.
+
+NOTICE: Adobe permits you to use, modify, and distribute this file in
+accordance with the terms of the Adobe license agreement accompanying
+it. If you have received this file from a source other than Adobe,
+then your use, modification, or distribution of it requires the prior
+written permission of Adobe.
+*/
+
+package tool
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/docker/docker-credential-helpers/credentials"
+
+	"github.com/adobe/sledgehammer/utils/docker"
+	"github.com/sirupsen/logrus"
+)
+
+// OCIFactory is the factory for the OCITool
+type OCIFactory struct{}
+
+// Raw will return a raw OCITool struct for populating from the db
+func (g *OCIFactory) Raw() Tool {
+	return &OCITool{}
+}
+
+// Create will take data and return an OCITool from the given arguments
+func (g *OCIFactory) Create(dt Data) Tool {
+	return &OCITool{
+		Core: dt,
+	}
+}
+
+// OCITool represents a sledgehammer tool which is stored in any registry that implements the OCI distribution api, e.g. GHCR, Quay, Harbor or registry:2
+type OCITool struct {
+	Core Data `json:"code"`
+}
+
+// OCITagResponse is the response the registry returns when asked for tags
+type OCITagResponse struct {
+	Name string   `json:"name"`
+	Tags []string `json:"tags"`
+}
+
+// OCITokenResponse is the response of the token service of the registry, registries return either of the tokens
+type OCITokenResponse struct {
+	Token       string `json:"token"`
+	AccessToken string `json:"access_token"`
+}
+
+// OCIError will be thrown if the registry does not return the tags of the image
+type OCIError struct {
+	URL    string
+	Status int
+}
+
+func (e *OCIError) Error() string {
+	return fmt.Sprintf("The registry returned %d %s for %s", e.Status, http.StatusText(e.Status), e.URL)
+}
+
+var (
+	// ErrorNoRealm will be thrown if the registry requests a bearer token, but does not tell where to get it
+	ErrorNoRealm = errors.New("The registry requested a bearer token without a realm")
+	// ErrorForeignPage will be thrown if the registry links the next page of tags to another host, the credentials of the user are never sent there
+	ErrorForeignPage = errors.New("The registry linked the next page of tags to another host")
+	// OCITagsURL is the URL that will return the tags of an image, it is requested with the page size
+	OCITagsURL = "%s/v2/%s/tags/list?n=%d"
+	// OCIPageSize is the number of tags that are requested per page, registries may return less
+	OCIPageSize = 100
+	// OCIClientID identifies Sledgehammer when it exchanges an identity token of the user for an access token
+	OCIClientID = "sledgehammer"
+	// OCICredentials will return the credentials of the user for the given registry
+	OCICredentials = docker.GetCredentials
+)
+
+// Data will return the inner data for the tool
+func (t *OCITool) Data() *Data {
+	return &t.Core
+}
+
+// Versions returns the versions of the tool while it fetches them from the tags of the registry
+func (t *OCITool) Versions() ([]string, error) {
+	logrus.Info("Checking remote image versions of OCITool")
+	versions := []string{}
+	if len(t.Data().ImageRegistry) == 0 {
+		return versions, nil
+	}
+
+	creds, err := OCICredentials(t.Data().ImageRegistry)
+	if err != nil {
+		logrus.WithField("registry", FullImage(t, "")).Warnln(err.Error())
+	}
+	session := &ociSession{
+		client: &http.Client{
+			Timeout: time.Second * 10,
+		},
+		creds: creds,
+		scope: fmt.Sprintf("repository:%s:pull", t.Data().Image),
+	}
+
+	next := fmt.Sprintf(OCITagsURL, ociBaseURL(t.Data().ImageRegistry), t.Data().Image, OCIPageSize)
+	base, err := url.Parse(next)
+	if err != nil {
+		return versions, err
+	}
+	visited := map[string]bool{}
+	for len(next) > 0 && !visited[next] {
+		visited[next] = true
+		logrus.WithField("url", next).Debug("Fetching tag page")
+		resp, err := session.get(next)
+		if err != nil {
+			return versions, err
+		}
+		body, err := ioutil.ReadAll(resp.Body)
+		resp.Body.Close()
+		if err != nil {
+			return versions, err
+		}
+		if resp.StatusCode != http.StatusOK {
+			return versions, &OCIError{URL: next, Status: resp.StatusCode}
+		}
+		tagResponse := OCITagResponse{}
+		if err := json.Unmarshal(body, &tagResponse); err != nil {
+			return versions, err
+		}
+		versions = append(versions, tagResponse.Tags...)
+		next, err = nextPage(resp, base)
+		if err != nil {
+			return versions, err
+		}
+	}
+	return versions, nil
+}
+
+// ociBaseURL will return the URL of the registry, registries on the loopback interface are accessed without tls like docker does
+func ociBaseURL(registry string) string {
+	if strings.HasPrefix(registry, "http://") || strings.HasPrefix(registry, "https://") {
+		return strings.TrimSuffix(registry, "/")
+	}
+	host := registry
+	if h, _, err := net.SplitHostPort(registry); err == nil {
+		host = h
+	}
+	if ip := net.ParseIP(host); host == "localhost" || (ip != nil && ip.IsLoopback()) {
+		return "http://" + registry
+	}
+	return "https://" + registry
+}
+
+// nextPage will return the absolute URL of the next page from the Link header of the response, or an empty string on the last page.
+// The next page has to be on the same host as the base URL, the session authenticates all requests.
+func nextPage(resp *http.Response, base *url.URL) (string, error) {
+	for _, header := range resp.Header["Link"] {
+		for _, link := range strings.Split(header, ",") {
+			parts := strings.Split(link, ";")
+			target := strings.TrimSpace(parts[0])
+			if !strings.HasPrefix(target, "<") || !strings.HasSuffix(target, ">") {
+				continue
+			}
+			for _, param := range parts[1:] {
+				if rel := strings.TrimSpace(param); rel != `rel="next"` && rel != "rel=next" {
+					continue
+				}
+				u, err := resp.Request.URL.Parse(strings.Trim(target, "<>"))
+				if err != nil {
+					return "", err
+				}
+				if u.Scheme != base.Scheme || u.Host != base.Host {
+					return "", ErrorForeignPage
+				}
+				return u.String(), nil
+			}
+		}
+	}
+	return "", nil
+}
+
+// ociSession will authenticate the requests to a registry, the token it gets from the challenge of the registry is reused for all pages
+type ociSession struct {
+	client *http.Client
+	creds  *credentials.Credentials
+	scope  string
+	auth   string
+}
+
+// get will request the given URL and answer the challenge of the registry if it requires authentication
+func (s *ociSession) get(u string) (*http.Response, error) {
+	resp, err := s.do(u)
+	if err != nil || resp.StatusCode != http.StatusUnauthorized {
+		return resp, err
+	}
+	challenge := resp.Header.Get("WWW-Authenticate")
+	resp.Body.Close()
+	scheme, params := parseChallenge(challenge)
+	switch strings.ToLower(scheme) {
+	case "bearer":
+		token, err := s.token(params)
+		if err != nil {
+			return nil, err
+		}
+		s.auth = "Bearer " + token
+	case "basic":
+		if s.creds == nil {
+			return nil, &OCIError{URL: u, Status: http.StatusUnauthorized}
+		}
+		s.auth = "Basic " + basicAuth(s.creds)
+	default:
+		return nil, &OCIError{URL: u, Status: http.StatusUnauthorized}
+	}
+	logrus.WithField("scheme", scheme).Info("Authenticated at registry")
+	return s.do(u)
+}
+
+func (s *ociSession) do(u string) (*http.Response, error) {
+	req, err := http.NewRequest("GET", u, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/json")
+	if len(s.auth) > 0 {
+		req.Header.Set("Authorization", s.auth)
+	}
+	return s.client.Do(req)
+}
+
+// token will fetch a bearer token from the token service of the registry.
+// The credentials of the user are sent with basic authentication, an identity token is exchanged with an oauth2 refresh grant.
+func (s *ociSession) token(params map[string]string) (string, error) {
+	realm, found := params["realm"]
+	if !found {
+		return "", ErrorNoRealm
+	}
+	scope := s.scope
+	if len(params["scope"]) > 0 {
+		scope = params["scope"]
+	}
+	var req *http.Request
+	var err error
+	if s.creds != nil && s.creds.Username == "<token>" {
+		form := url.Values{}
+		form.Set("grant_type", "refresh_token")
+		form.Set("refresh_token", s.creds.Secret)
+		form.Set("service", params["service"])
+		form.Set("scope", scope)
+		form.Set("client_id", OCIClientID)
+		req, err = http.NewRequest("POST", realm, strings.NewReader(form.Encode()))
+		if err != nil {
+			return "", err
+		}
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	} else {
+		req, err = http.NewRequest("GET", realm, nil)
+		if err != nil {
+			return "", err
+		}
+		query := req.URL.Query()
+		if len(params["service"]) > 0 {
+			query.Set("service", params["service"])
+		}
+		query.Set("scope", scope)
+		req.URL.RawQuery = query.Encode()
+		if s.creds != nil {
+			req.Header.Set("Authorization", "Basic "+basicAuth(s.creds))
+		}
+	}
+	logrus.WithField("realm", realm).WithField("scope", scope).Info("Trying to get token")
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return "", err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return "", &OCIError{URL: realm, Status: resp.StatusCode}
+	}
+	tokenResponse := OCITokenResponse{}
+	if err := json.Unmarshal(body, &tokenResponse); err != nil {
+		return "", err
+	}
+	if len(tokenResponse.Token) > 0 {
+		return tokenResponse.Token, nil
+	}
+	return tokenResponse.AccessToken, nil
+}
+
+// parseChallenge will parse the scheme and the parameters of a WWW-Authenticate header, e.g. Bearer realm="https://auth.io/token",service="registry"
+func parseChallenge(header string) (string, map[string]string) {
+	params := map[string]string{}
+	header = strings.TrimSpace(header)
+	i := strings.Index(header, " ")
+	if i < 0 {
+		return header, params
+	}
+	scheme, rest := header[:i], header[i+1:]
+	for len(rest) > 0 {
+		rest = strings.TrimLeft(rest, " ,")
+		eq := strings.Index(rest, "=")
+		if eq < 0 {
+			break
+		}
+		key := strings.ToLower(strings.TrimSpace(rest[:eq]))
+		rest = rest[eq+1:]
+		value := ""
+		if strings.HasPrefix(rest, `"`) {
+			// quoted values can contain commas, e.g. scopes with several actions
+			end := strings.Index(rest[1:], `"`)
+			if end < 0 {
+				value, rest = rest[1:], ""
+			} else {
+				value, rest = rest[1:end+1], rest[end+2:]
+			}
+		} else if end := strings.Index(rest, ","); end >= 0 {
+			value, rest = rest[:end], rest[end+1:]
+		} else {
+			value, rest = rest, ""
+		}
+		params[key] = value
+	}
+	return scheme, params
+}
+
+func basicAuth(creds *credentials.Credentials) string {
+	return base64.StdEncoding.EncodeToString([]byte(creds.Username + ":" + creds.Secret))
+}
@@ -0,0 +1,215 @@
+/*
+Copyright 2018 Adobe
+All Rights Reserved.
+
+NOTICE: Adobe permits you to use, modify, and distribute this file in
+accordance with the terms of the Adobe license agreement accompanying
+it. If you have received this file from a source other than Adobe,
+then your use, modification, or distribution of it requires the prior
+written permission of Adobe.
+*/
+
+package tool_test
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/docker/docker-credential-helpers/credentials"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/adobe/sledgehammer/slh/tool"
+)
+
+// registry is a stand-in for a registry that implements the OCI distribution api
+type registry struct {
+	// auth is the challenge of the registry, Bearer, Basic or none
+	auth string
+	// tags are returned in pages of two
+	tags []string
+	// next is the host the next page is linked to, the registry itself if it is empty
+	next string
+	// token is the token the token service issues
+	token string
+	// tokenRequests are the requests the token service received
+	tokenRequests []*http.Request
+}
+
+func (r *registry) serve() *httptest.Server {
+	var server *httptest.Server
+	mux := http.NewServeMux()
+	mux.HandleFunc("/token", func(w http.ResponseWriter, req *http.Request) {
+		req.ParseForm()
+		r.tokenRequests = append(r.tokenRequests, req)
+		json.NewEncoder(w).Encode(map[string]string{"access_token": r.token})
+	})
+	mux.HandleFunc("/v2/org/lint/tags/list", func(w http.ResponseWriter, req *http.Request) {
+		authorized := false
+		switch r.auth {
+		case "Bearer":
+			authorized = req.Header.Get("Authorization") == "Bearer "+r.token
+			w.Header().Set("WWW-Authenticate", fmt.Sprintf(`Bearer realm="%s/token",service="registry.test",scope="repository:org/lint:pull,push"`, server.URL))
+		case "Basic":
+			user, pass, _ := req.BasicAuth()
+			authorized = user == "jane" && pass == "secret"
+			w.Header().Set("WWW-Authenticate", `Basic realm="registry.test"`)
+		default:
+			authorized = true
+		}
+		if !authorized {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		last := 0
+		fmt.Sscanf(req.URL.Query().Get("last"), "%d", &last)
+		end := last + 2
+		if end < len(r.tags) {
+			w.Header().Set("Link", fmt.Sprintf(`<%s/v2/org/lint/tags/list?n=2&last=%d>; rel="next"`, r.next, end))
+		} else {
+			end = len(r.tags)
+		}
+		json.NewEncoder(w).Encode(tool.OCITagResponse{Name: "org/lint", Tags: r.tags[last:end]})
+	})
+	server = httptest.NewServer(mux)
+	return server
+}
+
+func ociTool(server *httptest.Server) tool.Tool {
+	return (&tool.OCIFactory{}).Create(tool.Data{
+		Name:          "lint",
+		Type:          "oci",
+		Image:         "org/lint",
+		ImageRegistry: strings.TrimPrefix(server.URL, "http://"),
+	})
+}
+
+func TestOCIVersions(t *testing.T) {
+	previous := tool.OCICredentials
+	defer func() { tool.OCICredentials = previous }()
+
+	cases := []struct {
+		name     string
+		registry *registry
+		creds    *credentials.Credentials
+		check    func(*testing.T, *registry)
+		err      bool
+	}{
+		{
+			name:     "Anonymous registry",
+			registry: &registry{tags: []string{"1.0.0", "1.1.0", "2.0.0"}},
+		},
+		{
+			name:     "Anonymous bearer token",
+			registry: &registry{auth: "Bearer", token: "anonymous", tags: []string{"1.0.0", "1.1.0", "2.0.0"}},
+			check: func(t *testing.T, r *registry) {
+				// the token is reused for all pages
+				assert.Len(t, r.tokenRequests, 1)
+				assert.Equal(t, "registry.test", r.tokenRequests[0].Form.Get("service"))
+				assert.Equal(t, "repository:org/lint:pull,push", r.tokenRequests[0].Form.Get("scope"))
+				assert.Empty(t, r.tokenRequests[0].Header.Get("Authorization"))
+			},
+		},
+		{
+			name:     "Bearer token with credentials",
+			registry: &registry{auth: "Bearer", token: "jane", tags: []string{"1.0.0", "1.1.0", "2.0.0"}},
+			creds:    &credentials.Credentials{Username: "jane", Secret: "secret"},
+			check: func(t *testing.T, r *registry) {
+				user, pass, ok := r.tokenRequests[0].BasicAuth()
+				assert.True(t, ok)
+				assert.Equal(t, "jane", user)
+				assert.Equal(t, "secret", pass)
+			},
+		},
+		{
+			name:     "Bearer token with an identity token",
+			registry: &registry{auth: "Bearer", token: "refreshed", tags: []string{"1.0.0", "1.1.0", "2.0.0"}},
+			creds:    &credentials.Credentials{Username: "<token>", Secret: "identity"},
+			check: func(t *testing.T, r *registry) {
+				assert.Equal(t, "POST", r.tokenRequests[0].Method)
+				assert.Equal(t, "refresh_token", r.tokenRequests[0].Form.Get("grant_type"))
+				assert.Equal(t, "identity", r.tokenRequests[0].Form.Get("refresh_token"))
+			},
+		},
+		{
+			name:     "Basic authentication",
+			registry: &registry{auth: "Basic", tags: []string{"1.0.0", "1.1.0", "2.0.0"}},
+			creds:    &credentials.Credentials{Username: "jane", Secret: "secret"},
+		},
+		{
+			name:     "Basic authentication without credentials",
+			registry: &registry{auth: "Basic", tags: []string{"1.0.0"}},
+			err:      true,
+		},
+	}
+
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			server := tt.registry.serve()
+			defer server.Close()
+			tool.OCICredentials = func(server string) (*credentials.Credentials, error) {
+				if tt.creds == nil {
+					return nil, errors.New("No credentials found")
+				}
+				return tt.creds, nil
+			}
+
+			versions, err := ociTool(server).Versions()
+			if tt.err {
+				assert.Equal(t, &tool.OCIError{URL: server.URL + "/v2/org/lint/tags/list?n=100", Status: http.StatusUnauthorized}, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.registry.tags, versions)
+			if tt.check != nil {
+				tt.check(t, tt.registry)
+			}
+		})
+	}
+}
+
+func TestOCIVersionsForeignPage(t *testing.T) {
+	previous := tool.OCICredentials
+	defer func() { tool.OCICredentials = previous }()
+	tool.OCICredentials = func(server string) (*credentials.Credentials, error) {
+		return &credentials.Credentials{Username: "jane", Secret: "secret"}, nil
+	}
+	requests := 0
+	foreign := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		requests++
+	}))
+	defer foreign.Close()
+	r := &registry{auth: "Basic", tags: []string{"1.0.0", "1.1.0", "2.0.0"}, next: foreign.URL}
+	server := r.serve()
+	defer server.Close()
+
+	// the credentials of the user are never sent to another host
+	versions, err := ociTool(server).Versions()
+	assert.Equal(t, tool.ErrorForeignPage, err)
+	assert.Equal(t, []string{"1.0.0", "1.1.0"}, versions)
+	assert.Equal(t, 0, requests)
+}
+
+func TestOCIVersionsWithoutRegistry(t *testing.T) {
+	versions, err := (&tool.OCIFactory{}).Create(tool.Data{Name: "lint", Image: "org/lint"}).Versions()
+	assert.NoError(t, err)
+	assert.Empty(t, versions)
+}
+
+func TestOCIError(t *testing.T) {
+	previous := tool.OCICredentials
+	defer func() { tool.OCICredentials = previous }()
+	tool.OCICredentials = func(server string) (*credentials.Credentials, error) {
+		return nil, errors.New("No credentials found")
+	}
+	server := httptest.NewServer(http.NotFoundHandler())
+	defer server.Close()
+
+	_, err := ociTool(server).Versions()
+	assert.Equal(t, &tool.OCIError{URL: server.URL + "/v2/org/lint/tags/list?n=100", Status: http.StatusNotFound}, err)
+	assert.Equal(t, fmt.Sprintf("The registry returned 404 Not Found for %s/v2/org/lint/tags/list?n=100", server.URL), err.Error())
+}
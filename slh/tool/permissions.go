@@ -0,0 +1,129 @@
+/*
+Copyright 2018 Adobe
+All Rights Reserved.
+
+NOTICE: Adobe permits you to use, modify, and distribute this file in
+accordance with the terms of the Adobe license agreement accompanying
+it. If you have received this file from a source other than Adobe,
+then your use, modification, or distribution of it requires the prior
+written permission of Adobe.
+*/
+
+package tool
+
+import (
+	"sort"
+	"strconv"
+
+	"github.com/adobe/sledgehammer/utils"
+	docker "github.com/fsouza/go-dockerclient"
+)
+
+var (
+	// PermissionDocker is the permission to access the docker daemon of the host
+	PermissionDocker = "docker"
+	// PermissionPrivileged is the permission to run with the privileged security profile
+	PermissionPrivileged = "privileged"
+	// PermissionPath is the prefix of the permission to mount a path of the host, e.g. path:/etc
+	PermissionPath = "path:"
+	// PermissionForward is the prefix of the permission to access forwarded credentials, e.g. forward:ssh
+	PermissionForward = "forward:"
+	// DockerSocket is the socket of the docker daemon, it is mounted at the same path into the tool
+	DockerSocket = "/var/run/docker.sock"
+)
+
+// permissions will return the permissions of the tool, never nil
+func permissions(to Tool) *Permissions {
+	if to.Data().Permissions != nil {
+		return to.Data().Permissions
+	}
+	return &Permissions{}
+}
+
+// RequestedPermissions will return the elevated access to the host the tool needs, sorted and without duplicates.
+// Besides the permissions of the tool, forwarded credentials, the privileged profile and volumes of host paths in the runtime are requested as well.
+func RequestedPermissions(to Tool) ([]string, error) {
+	requested := map[string]bool{}
+	if permissions(to).Docker {
+		requested[PermissionDocker] = true
+	}
+	if SecurityProfile(to) == SecurityPrivileged {
+		requested[PermissionPrivileged] = true
+	}
+	mounts, err := hostPathMounts(to)
+	if err != nil {
+		return nil, err
+	}
+	for _, volume := range runtime(to).Volumes {
+		m, err := parseVolume(volume)
+		if err != nil {
+			return nil, err
+		}
+		if m.Type == "bind" {
+			mounts = append(mounts, m)
+		}
+	}
+	for _, m := range mounts {
+		requested[PermissionPath+m.Source] = true
+	}
+	for _, kind := range Forwards(to) {
+		requested[PermissionForward+kind] = true
+	}
+	list := []string{}
+	for p := range requested {
+		list = append(list, p)
+	}
+	sort.Strings(list)
+	return list, nil
+}
+
+// hostPathMounts will return the mounts of the host paths the tool requests, they must be absolute
+func hostPathMounts(to Tool) ([]docker.HostMount, error) {
+	mounts := []docker.HostMount{}
+	for _, p := range permissions(to).HostPaths {
+		m, err := parseVolume(p)
+		if err != nil || m.Type != "bind" {
+			return nil, &InvalidRuntimeError{Setting: "host path", Value: p}
+		}
+		mounts = append(mounts, m)
+	}
+	return mounts, nil
+}
+
+// permissionMounts will return the mounts of the docker socket and the host paths the tool requests
+func permissionMounts(opt *ExecutionOptions) ([]docker.HostMount, error) {
+	mounts, err := hostPathMounts(opt.Tool)
+	if err != nil {
+		return nil, err
+	}
+	if permissions(opt.Tool).Docker {
+		mounts = append(mounts, docker.HostMount{Source: DockerSocket, Target: DockerSocket, Type: "bind"})
+	}
+	return mounts, nil
+}
+
+// socketGroup will add the group of the docker socket to the container, so that the user of the tool can access it
+func socketGroup(opt *ExecutionOptions, host *docker.HostConfig) *docker.HostConfig {
+	if !permissions(opt.Tool).Docker {
+		return host
+	}
+	if gid, found := utils.FileGroup(DockerSocket); found && gid > 0 {
+		host.GroupAdd = append(host.GroupAdd, strconv.Itoa(gid))
+	}
+	return host
+}
+
+// forwards will merge the credentials the runtime and the permissions of the tool request to be forwarded
+func forwards(to Tool) []string {
+	kinds := append([]string{}, runtime(to).Forward...)
+	for _, k := range permissions(to).Forward {
+		found := false
+		for _, e := range kinds {
+			found = found || e == k
+		}
+		if !found {
+			kinds = append(kinds, k)
+		}
+	}
+	return kinds
+}
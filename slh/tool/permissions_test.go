@@ -0,0 +1,105 @@
+/*
+Copyright 2018 Adobe
+All Rights Reserved.
+
+NOTICE: Adobe permits you to use, modify, and distribute this file in
+accordance with the terms of the Adobe license agreement accompanying
+it. If you have received this file from a source other than Adobe,
+then your use, modification, or distribution of it requires the prior
+written permission of Adobe.
+*/
+
+package tool_test
+
+import (
+	"testing"
+
+	docker "github.com/fsouza/go-dockerclient"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/adobe/sledgehammer/mocks"
+	"github.com/adobe/sledgehammer/slh/config"
+	"github.com/adobe/sledgehammer/slh/tool"
+)
+
+func TestRequestedPermissions(t *testing.T) {
+	cases := []struct {
+		name        string
+		runtime     *tool.Runtime
+		permissions *tool.Permissions
+		expected    []string
+		err         error
+	}{
+		{
+			name:     "No permissions",
+			runtime:  &tool.Runtime{Volumes: []string{"data:/data"}, Security: "strict"},
+			expected: []string{},
+		},
+		{
+			name:        "Permissions of the tool",
+			permissions: &tool.Permissions{Docker: true, Privileged: true, HostPaths: []string{"/etc:/host/etc:ro"}, Forward: []string{"ssh"}},
+			expected:    []string{"docker", "forward:ssh", "path:/etc", "privileged"},
+		},
+		{
+			name:     "Elevated runtime settings",
+			runtime:  &tool.Runtime{Volumes: []string{"/var/log:/logs", "data:/data"}, Security: "privileged", Forward: []string{"git", "ssh"}},
+			expected: []string{"forward:git", "forward:ssh", "path:/var/log", "privileged"},
+		},
+		{
+			name:        "Duplicates are removed",
+			runtime:     &tool.Runtime{Volumes: []string{"/etc:/etc"}, Forward: []string{"ssh"}},
+			permissions: &tool.Permissions{HostPaths: []string{"/etc:/host/etc"}, Forward: []string{"ssh", "git"}},
+			expected:    []string{"forward:git", "forward:ssh", "path:/etc"},
+		},
+		{
+			name:        "Security profile of the runtime takes precedence",
+			runtime:     &tool.Runtime{Security: "default"},
+			permissions: &tool.Permissions{Privileged: true},
+			expected:    []string{},
+		},
+		{
+			name:        "Host paths must be absolute",
+			permissions: &tool.Permissions{HostPaths: []string{"data:/data"}},
+			err:         &tool.InvalidRuntimeError{Setting: "host path", Value: "data:/data"},
+		},
+	}
+
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			to := &tool.LocalTool{Core: tool.Data{Name: "foo", Runtime: tt.runtime, Permissions: tt.permissions}}
+			permissions, err := tool.RequestedPermissions(to)
+			assert.Equal(t, tt.err, err)
+			assert.Equal(t, tt.expected, permissions)
+		})
+	}
+}
+
+func TestPermissionMounts(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+	dockerMock := mocks.NewMockClient(mockCtrl)
+
+	dockerMock.EXPECT().CreateContainer(gomock.Any()).DoAndReturn(func(opts docker.CreateContainerOptions) (*docker.Container, error) {
+		assert.Equal(t, []docker.HostMount{
+			{Source: "/etc", Target: "/host/etc", Type: "bind", ReadOnly: true},
+			{Source: tool.DockerSocket, Target: tool.DockerSocket, Type: "bind"},
+		}, opts.HostConfig.Mounts)
+		assert.True(t, opts.HostConfig.Privileged)
+		return &docker.Container{ID: "foo"}, nil
+	})
+	dockerMock.EXPECT().StartContainer("foo", gomock.Any())
+
+	_, err := tool.StartIfDaemon(&tool.ExecutionOptions{
+		Tool: &tool.LocalTool{
+			Core: tool.Data{
+				Name:        "dind",
+				Image:       "dind",
+				Daemon:      &tool.Daemon{Entry: []string{"sh"}},
+				Permissions: &tool.Permissions{Docker: true, Privileged: true, HostPaths: []string{"/etc:/host/etc:ro"}},
+			},
+		},
+		Docker: &config.Docker{Docker: dockerMock},
+	})
+	assert.NoError(t, err)
+}
@@ -0,0 +1,78 @@
+/*
+Copyright 2018 Adobe
+All Rights Reserved.
+
+NOTICE: Adobe permits you to use, modify, and distribute this file in
+accordance with the terms of the Adobe license agreement accompanying
+it. If you have received this file from a source other than Adobe,
+then your use, modification, or distribution of it requires the prior
+written permission of Adobe.
+*/
+
+package tool
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"io/ioutil"
+	"strings"
+
+	"github.com/adobe/sledgehammer/slh/out"
+	"github.com/sirupsen/logrus"
+)
+
+// pullMessage is a single message of the json stream that docker sends during an image pull
+type pullMessage struct {
+	ID       string `json:"id"`
+	Status   string `json:"status"`
+	Progress struct {
+		Current int64 `json:"current"`
+		Total   int64 `json:"total"`
+	} `json:"progressDetail"`
+	Error string `json:"error"`
+}
+
+// decodePull will decode the json stream of an image pull and send the progress to the output.
+// It returns the first error contained in the stream, the rest of the stream is discarded.
+func decodePull(r io.Reader, image string, output *out.Output) error {
+	err := decodePullMessages(r, image, output)
+	// never block the writer of the stream
+	io.Copy(ioutil.Discard, r)
+	return err
+}
+
+func decodePullMessages(r io.Reader, image string, output *out.Output) error {
+	decoder := json.NewDecoder(r)
+	for {
+		msg := pullMessage{}
+		err := decoder.Decode(&msg)
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if len(msg.Error) > 0 {
+			return errors.New(msg.Error)
+		}
+		logrus.WithFields(logrus.Fields{
+			"image": image,
+			"layer": msg.ID,
+		}).Debugln(msg.Status)
+
+		event := out.PullEvent{
+			Image:   image,
+			Layer:   msg.ID,
+			Status:  msg.Status,
+			Current: msg.Progress.Current,
+			Total:   msg.Progress.Total,
+		}
+		// the id of the first message is the tag and no layer
+		if strings.HasPrefix(msg.Status, "Pulling from") {
+			event.Layer = ""
+			event.Status = msg.ID + ": " + msg.Status
+		}
+		output.Pull(event)
+	}
+}
@@ -0,0 +1,98 @@
+/*
+Copyright 2018 Adobe
+All Rights Reserved.
+
+NOTICE: Adobe permits you to use, modify, and distribute this file in
+accordance with the terms of the Adobe license agreement accompanying
+it. If you have received this file from a source other than Adobe,
+then your use, modification, or distribution of it requires the prior
+written permission of Adobe.
+*/
+
+package tool_test
+
+import (
+	"errors"
+	"io"
+	"testing"
+	"time"
+
+	docker "github.com/fsouza/go-dockerclient"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/adobe/sledgehammer/mocks"
+	"github.com/adobe/sledgehammer/slh/config"
+	"github.com/adobe/sledgehammer/slh/out"
+	"github.com/adobe/sledgehammer/slh/tool"
+)
+
+func TestPullProgress(t *testing.T) {
+	cases := []struct {
+		name   string
+		stream string
+		err    error
+		events []out.PullEvent
+	}{
+		{
+			name: "Layers are reported",
+			stream: `{"status":"Pulling from library/foo","id":"1.0"}
+{"status":"Pulling fs layer","progressDetail":{},"id":"a1"}
+{"status":"Downloading","progressDetail":{"current":50,"total":100},"id":"a1"}
+{"status":"Pull complete","progressDetail":{},"id":"a1"}
+{"status":"Status: Downloaded newer image for foo:1.0"}
+`,
+			events: []out.PullEvent{
+				{Image: "foo:1.0", Status: "1.0: Pulling from library/foo"},
+				{Image: "foo:1.0", Layer: "a1", Status: "Pulling fs layer"},
+				{Image: "foo:1.0", Layer: "a1", Status: "Downloading", Current: 50, Total: 100},
+				{Image: "foo:1.0", Layer: "a1", Status: "Pull complete"},
+				{Image: "foo:1.0", Status: "Status: Downloaded newer image for foo:1.0"},
+			},
+		},
+		{
+			name: "Error in the stream",
+			stream: `{"status":"Pulling from library/foo","id":"1.0"}
+{"errorDetail":{"message":"unauthorized"},"error":"unauthorized"}
+{"status":"Pull complete","progressDetail":{},"id":"a1"}
+`,
+			err: errors.New("unauthorized"),
+			events: []out.PullEvent{
+				{Image: "foo:1.0", Status: "1.0: Pulling from library/foo"},
+			},
+		},
+		{
+			name:   "Empty stream",
+			stream: "",
+		},
+	}
+
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			mockCtrl := gomock.NewController(t)
+			defer mockCtrl.Finish()
+			dockerMock := mocks.NewMockClient(mockCtrl)
+
+			dockerMock.EXPECT().PullImage(gomock.Any(), gomock.Any()).DoAndReturn(func(opts docker.PullImageOptions, _ docker.AuthConfiguration) error {
+				assert.True(t, opts.RawJSONStream)
+				assert.Equal(t, "1.0", opts.Tag)
+				io.WriteString(opts.OutputStream, tt.stream)
+				return nil
+			})
+
+			var events []out.PullEvent
+			output := &out.Output{PullFunc: func(e out.PullEvent) {
+				events = append(events, e)
+			}}
+
+			err := tool.Pull(config.Docker{Docker: dockerMock}, &tool.LocalTool{
+				Core: tool.Data{
+					Name:  "foo",
+					Image: "foo",
+				},
+			}, "1.0", time.Second, output)
+			assert.Equal(t, tt.err, err)
+			assert.Equal(t, tt.events, events)
+		})
+	}
+}
@@ -0,0 +1,212 @@
+/*
+Copyright 2018 Adobe
+All Rights Reserved.
+
+NOTICE: Adobe permits you to use, modify, and distribute this file in
+accordance with the terms of the Adobe license agreement accompanying
+it. If you have received this file from a source other than Adobe,
+then your use, modification, or distribution of it requires the prior
+written permission of Adobe.
+*/
+
+package tool
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+
+	docker "github.com/fsouza/go-dockerclient"
+	"github.com/sirupsen/logrus"
+)
+
+var (
+	// DefaultReadinessTimeout is the time Sledgehammer waits for a daemon to become ready if the tool does not define one
+	DefaultReadinessTimeout = 30 * time.Second
+	// ReadinessInterval is the time between two readiness probes
+	ReadinessInterval = 250 * time.Millisecond
+)
+
+// NotReadyError will be thrown if a daemon did not become ready
+type NotReadyError struct {
+	Tool    string
+	Timeout time.Duration
+	Reason  string
+}
+
+func (e *NotReadyError) Error() string {
+	if e.Timeout > 0 {
+		return fmt.Sprintf("Daemon of tool '%s' did not become ready within %s: %s", e.Tool, e.Timeout, e.Reason)
+	}
+	return fmt.Sprintf("Daemon of tool '%s' did not become ready: %s", e.Tool, e.Reason)
+}
+
+// String will return a short description of the readiness probes
+func (r *Readiness) String() string {
+	probes := []string{}
+	if len(r.Exec) > 0 {
+		probes = append(probes, "exec '"+strings.Join(r.Exec, " ")+"'")
+	}
+	if r.Port > 0 {
+		probes = append(probes, "port "+strconv.Itoa(r.Port))
+	}
+	if len(r.Log) > 0 {
+		probes = append(probes, "log '"+r.Log+"'")
+	}
+	timeout := r.Timeout
+	if len(timeout) == 0 {
+		timeout = DefaultReadinessTimeout.String()
+	}
+	return strings.Join(probes, ", ") + " (timeout " + timeout + ")"
+}
+
+// readinessTimeout will return the time to wait for the daemon to become ready
+func readinessTimeout(ready *Readiness) (time.Duration, error) {
+	if len(ready.Timeout) == 0 {
+		return DefaultReadinessTimeout, nil
+	}
+	timeout, err := time.ParseDuration(ready.Timeout)
+	if err != nil || timeout <= 0 {
+		return 0, &InvalidRuntimeError{Setting: "readiness timeout", Value: ready.Timeout}
+	}
+	return timeout, nil
+}
+
+// waitUntilReady will block until all readiness probes of the daemon succeed, the daemon exits or the timeout is reached
+func waitUntilReady(opt *ExecutionOptions, containerID string) error {
+	ready := opt.Tool.Data().Daemon.Ready
+	if ready == nil {
+		return nil
+	}
+	timeout, err := readinessTimeout(ready)
+	if err != nil {
+		return err
+	}
+	logrus.WithField("tool", opt.Tool.Data().Name).WithField("timeout", timeout).Info("Waiting for daemon to become ready")
+	deadline := time.Now().Add(timeout)
+	for {
+		container, err := opt.Docker.Docker.InspectContainer(containerID)
+		if _, ok := err.(*docker.NoSuchContainer); ok {
+			return &NotReadyError{Tool: opt.Tool.Data().Name, Reason: "daemon exited"}
+		}
+		if err != nil {
+			return err
+		}
+		if !container.State.Running {
+			return &NotReadyError{Tool: opt.Tool.Data().Name, Reason: fmt.Sprintf("daemon exited with code %d", container.State.ExitCode)}
+		}
+		err = probe(opt, ready, container)
+		if err == nil {
+			logrus.WithField("tool", opt.Tool.Data().Name).Info("Daemon is ready")
+			return nil
+		}
+		logrus.WithField("tool", opt.Tool.Data().Name).Debugln("Daemon is not ready yet: ", err.Error())
+		if time.Now().After(deadline) {
+			return &NotReadyError{Tool: opt.Tool.Data().Name, Timeout: timeout, Reason: err.Error()}
+		}
+		time.Sleep(ReadinessInterval)
+	}
+}
+
+// probe will run all readiness probes once and return the first one that failed
+func probe(opt *ExecutionOptions, ready *Readiness, container *docker.Container) error {
+	if len(ready.Exec) > 0 {
+		if err := probeExec(opt, container.ID, ready.Exec); err != nil {
+			return err
+		}
+	}
+	if ready.Port > 0 {
+		if err := probePort(opt, container, ready.Port); err != nil {
+			return err
+		}
+	}
+	if len(ready.Log) > 0 {
+		if err := probeLog(opt, container.ID, ready.Log); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func probeExec(opt *ExecutionOptions, containerID string, cmd []string) error {
+	exec, err := opt.Docker.Docker.CreateExec(docker.CreateExecOptions{
+		Container:    containerID,
+		Cmd:          cmd,
+		AttachStdout: true,
+		AttachStderr: true,
+	})
+	if err != nil {
+		return err
+	}
+	err = opt.Docker.Docker.StartExec(exec.ID, docker.StartExecOptions{
+		OutputStream: ioutil.Discard,
+		ErrorStream:  ioutil.Discard,
+	})
+	if err != nil {
+		return err
+	}
+	inspect, err := opt.Docker.Docker.InspectExec(exec.ID)
+	if err != nil {
+		return err
+	}
+	if inspect.ExitCode != 0 {
+		return fmt.Errorf("'%s' exited with code %d", strings.Join(cmd, " "), inspect.ExitCode)
+	}
+	return nil
+}
+
+func probePort(opt *ExecutionOptions, container *docker.Container, port int) error {
+	address := portAddress(opt, container, port)
+	conn, err := net.DialTimeout("tcp", address, ReadinessInterval)
+	if err != nil {
+		return fmt.Errorf("port %d is not reachable at %s", port, address)
+	}
+	return conn.Close()
+}
+
+// portAddress will return the address on the host under which the given port of the daemon is reachable
+func portAddress(opt *ExecutionOptions, container *docker.Container, port int) string {
+	p := strconv.Itoa(port)
+	if networkMode(opt) == "host" {
+		return net.JoinHostPort("127.0.0.1", p)
+	}
+	if container.NetworkSettings != nil {
+		for _, binding := range container.NetworkSettings.Ports[docker.Port(p+"/tcp")] {
+			if len(binding.HostPort) > 0 {
+				ip := binding.HostIP
+				if len(ip) == 0 || ip == "0.0.0.0" {
+					ip = "127.0.0.1"
+				}
+				return net.JoinHostPort(ip, binding.HostPort)
+			}
+		}
+		if len(container.NetworkSettings.IPAddress) > 0 {
+			return net.JoinHostPort(container.NetworkSettings.IPAddress, p)
+		}
+	}
+	return net.JoinHostPort("127.0.0.1", p)
+}
+
+func probeLog(opt *ExecutionOptions, containerID string, text string) error {
+	logs := &bytes.Buffer{}
+	err := opt.Docker.Docker.Logs(docker.LogsOptions{
+		Container:    containerID,
+		OutputStream: logs,
+		ErrorStream:  logs,
+		Stdout:       true,
+		Stderr:       true,
+		// daemons are started with a tty
+		RawTerminal: true,
+	})
+	if err != nil {
+		return err
+	}
+	if !strings.Contains(logs.String(), text) {
+		return fmt.Errorf("'%s' not found in the logs", text)
+	}
+	return nil
+}
@@ -0,0 +1,141 @@
+/*
+Copyright 2018 Adobe
+All Rights Reserved.
+
+NOTICE: Adobe permits you to use, modify, and distribute this file in
+accordance with the terms of the Adobe license agreement accompanying
+it. If you have received this file from a source other than Adobe,
+then your use, modification, or distribution of it requires the prior
+written permission of Adobe.
+*/
+
+package tool_test
+
+import (
+	"io"
+	"net"
+	"testing"
+	"time"
+
+	docker "github.com/fsouza/go-dockerclient"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/adobe/sledgehammer/mocks"
+	"github.com/adobe/sledgehammer/slh/config"
+	"github.com/adobe/sledgehammer/slh/tool"
+)
+
+func TestReadiness(t *testing.T) {
+	defer func(interval time.Duration) { tool.ReadinessInterval = interval }(tool.ReadinessInterval)
+	tool.ReadinessInterval = time.Millisecond
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer listener.Close()
+	port := listener.Addr().(*net.TCPAddr).Port
+
+	running := &docker.Container{ID: "foo", State: docker.State{Running: true}}
+
+	cases := []struct {
+		name   string
+		ready  *tool.Readiness
+		before func(*mocks.MockClient)
+		err    error
+	}{
+		{
+			name: "No readiness probe",
+		},
+		{
+			name:  "Exec probe succeeds after a retry",
+			ready: &tool.Readiness{Exec: []string{"true"}},
+			before: func(m *mocks.MockClient) {
+				m.EXPECT().InspectContainer("foo").Return(running, nil).Times(2)
+				m.EXPECT().CreateExec(gomock.Any()).Return(&docker.Exec{ID: "probe"}, nil).Times(2)
+				m.EXPECT().StartExec("probe", gomock.Any()).Times(2)
+				gomock.InOrder(
+					m.EXPECT().InspectExec("probe").Return(&docker.ExecInspect{ExitCode: 1}, nil),
+					m.EXPECT().InspectExec("probe").Return(&docker.ExecInspect{ExitCode: 0}, nil),
+				)
+			},
+		},
+		{
+			name:  "Log probe",
+			ready: &tool.Readiness{Log: "Server started"},
+			before: func(m *mocks.MockClient) {
+				m.EXPECT().InspectContainer("foo").Return(running, nil)
+				m.EXPECT().Logs(gomock.Any()).DoAndReturn(func(opts docker.LogsOptions) error {
+					io.WriteString(opts.OutputStream, "Booting\nServer started on port 1234\n")
+					return nil
+				})
+			},
+		},
+		{
+			name:  "Port probe",
+			ready: &tool.Readiness{Port: port},
+			before: func(m *mocks.MockClient) {
+				m.EXPECT().InspectContainer("foo").Return(running, nil)
+			},
+		},
+		{
+			name:  "Probe times out",
+			ready: &tool.Readiness{Log: "Server started", Timeout: "10ms"},
+			before: func(m *mocks.MockClient) {
+				m.EXPECT().InspectContainer("foo").Return(running, nil).MinTimes(1)
+				m.EXPECT().Logs(gomock.Any()).MinTimes(1)
+				m.EXPECT().RemoveContainer(docker.RemoveContainerOptions{ID: "foo", Force: true})
+			},
+			err: &tool.NotReadyError{Tool: "foo", Timeout: 10 * time.Millisecond, Reason: "'Server started' not found in the logs"},
+		},
+		{
+			name:  "Daemon exits",
+			ready: &tool.Readiness{Log: "Server started"},
+			before: func(m *mocks.MockClient) {
+				m.EXPECT().InspectContainer("foo").Return(&docker.Container{ID: "foo", State: docker.State{ExitCode: 2}}, nil)
+				m.EXPECT().RemoveContainer(gomock.Any())
+			},
+			err: &tool.NotReadyError{Tool: "foo", Reason: "daemon exited with code 2"},
+		},
+		{
+			name:  "Invalid timeout",
+			ready: &tool.Readiness{Log: "Server started", Timeout: "soon"},
+			before: func(m *mocks.MockClient) {
+				m.EXPECT().RemoveContainer(gomock.Any())
+			},
+			err: &tool.InvalidRuntimeError{Setting: "readiness timeout", Value: "soon"},
+		},
+	}
+
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			mockCtrl := gomock.NewController(t)
+			defer mockCtrl.Finish()
+			dockerMock := mocks.NewMockClient(mockCtrl)
+
+			dockerMock.EXPECT().CreateContainer(gomock.Any()).Return(&docker.Container{ID: "foo"}, nil)
+			dockerMock.EXPECT().StartContainer("foo", gomock.Any())
+			if tt.before != nil {
+				tt.before(dockerMock)
+			}
+
+			id, err := tool.StartIfDaemon(&tool.ExecutionOptions{
+				Tool: &tool.LocalTool{
+					Core: tool.Data{
+						Name:   "foo",
+						Image:  "foo",
+						Daemon: &tool.Daemon{Entry: []string{"sh"}, Ready: tt.ready},
+					},
+				},
+				Docker: &config.Docker{Docker: dockerMock},
+			})
+			if tt.err != nil {
+				assert.Equal(t, tt.err, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, "foo", id)
+		})
+	}
+}
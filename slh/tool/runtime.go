@@ -0,0 +1,345 @@
+/*
+Copyright 2018 Adobe
+All Rights Reserved.
+
+NOTICE: Adobe permits you to use, modify, and distribute this file in
+accordance with the terms of the Adobe license agreement accompanying
+it. If you have received this file from a source other than Adobe,
+then your use, modification, or distribution of it requires the prior
+written permission of Adobe.
+*/
+
+package tool
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/adobe/sledgehammer/slh/environment"
+	"github.com/adobe/sledgehammer/slh/mount"
+	"github.com/docker/docker/pkg/homedir"
+	docker "github.com/fsouza/go-dockerclient"
+	"github.com/sirupsen/logrus"
+)
+
+var (
+	// DefaultNetworkMode is the network mode that will be used if the tool does not define one
+	DefaultNetworkMode = "host"
+	// WorkDirMount is the working directory policy that uses the current directory if it is part of a mount
+	WorkDirMount = "mount"
+	// WorkDirImage is the working directory policy that keeps the working directory of the image
+	WorkDirImage = "image"
+)
+
+// InvalidRuntimeError will be thrown if a runtime setting of a tool cannot be parsed
+type InvalidRuntimeError struct {
+	Setting string
+	Value   string
+}
+
+func (e *InvalidRuntimeError) Error() string {
+	return fmt.Sprintf("Invalid %s '%s'", e.Setting, e.Value)
+}
+
+// runtime will return the runtime settings of the tool, never nil
+func runtime(to Tool) *Runtime {
+	if to.Data().Runtime != nil {
+		return to.Data().Runtime
+	}
+	return &Runtime{}
+}
+
+// EnvironmentPolicy will return the environment policy that is defined by the tool
+func EnvironmentPolicy(to Tool) environment.Policy {
+	return environment.Policy{
+		Source: environment.SourceTool,
+		Allow:  runtime(to).EnvAllow,
+		Deny:   runtime(to).EnvDeny,
+	}
+}
+
+// Forwards will return the credentials of the user the tool requests to be forwarded
+func Forwards(to Tool) []string {
+	return forwards(to)
+}
+
+// EnvironmentDefaults will return the default values of environment variables defined by the tool
+func EnvironmentDefaults(to Tool) map[string]string {
+	return runtime(to).Env
+}
+
+// Network will return the network mode of the container.
+// The network of the alias takes precedence over the network of the tool, which takes precedence over the global network.
+func Network(to Tool, alias string, global string) string {
+	for _, network := range []string{alias, runtime(to).Network, global} {
+		if len(network) > 0 {
+			return network
+		}
+	}
+	return DefaultNetworkMode
+}
+
+// networkMode will return the network mode of the container for the execution, a strict tool has no network
+func networkMode(opt *ExecutionOptions) string {
+	if securityProfile(opt) == SecurityStrict {
+		return "none"
+	}
+	return Network(opt.Tool, opt.Network, "")
+}
+
+// publishes will return true if ports are published with the network mode.
+// With the host network the tool listens on the host directly, without a network or with the network of another container nothing is published.
+func publishes(network string) bool {
+	return network != "host" && network != "none" && !strings.HasPrefix(network, "container:")
+}
+
+// containerUser will return the user the tool should run with
+func containerUser(to Tool) string {
+	if len(runtime(to).User) > 0 {
+		return runtime(to).User
+	}
+	if runtime(to).Root {
+		return "0:0"
+	}
+	if os.Getuid() >= 0 && os.Getgid() >= 0 {
+		return strconv.Itoa(os.Getuid()) + ":" + strconv.Itoa(os.Getgid())
+	}
+	return ""
+}
+
+// workingDirectory will return the working directory inside of the container according to the policy of the tool
+func workingDirectory(to Tool, mounts []mount.Mount, transient []string) (string, error) {
+	policy := runtime(to).WorkDir
+	switch {
+	case policy == "" || policy == WorkDirMount:
+		return mount.WorkingDirectory(mounts, transient)
+	case policy == WorkDirImage:
+		return "", nil
+	case path.IsAbs(policy):
+		return policy, nil
+	}
+	return "", &InvalidRuntimeError{Setting: "working directory policy", Value: policy}
+}
+
+// hostConfig will create the host configuration for the container of the tool
+func hostConfig(opt *ExecutionOptions, autoRemove bool) (*docker.HostConfig, error) {
+	mounts := mount.HostMounts(opt.Mounts, opt.TransientMounts)
+	for _, volume := range runtime(opt.Tool).Volumes {
+		mount, err := parseVolume(volume)
+		if err != nil {
+			return nil, err
+		}
+		mounts = append(mounts, mount)
+	}
+	caches, err := cacheMounts(opt.Tool)
+	if err != nil {
+		return nil, err
+	}
+	mounts = append(mounts, caches...)
+	mounts = append(mounts, identityMounts(opt)...)
+	elevated, err := permissionMounts(opt)
+	if err != nil {
+		return nil, err
+	}
+	mounts = append(mounts, elevated...)
+	bindings := map[docker.Port][]docker.PortBinding{}
+	for _, port := range runtime(opt.Tool).Ports {
+		containerPort, binding, err := parsePort(port)
+		if err != nil {
+			return nil, err
+		}
+		bindings[containerPort] = append(bindings[containerPort], binding)
+	}
+	l := limits(opt)
+	if err := l.Validate(); err != nil {
+		return nil, err
+	}
+	host, err := secure(opt, &docker.HostConfig{
+		NetworkMode:  networkMode(opt),
+		AutoRemove:   autoRemove,
+		Mounts:       mounts,
+		PortBindings: bindings,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return l.resources(socketGroup(opt, host))
+}
+
+// PortInUseError will be thrown if a port of the tool should be published on the host, but it is already in use
+type PortInUseError struct {
+	Port string
+}
+
+func (e *PortInUseError) Error() string {
+	return fmt.Sprintf("The port %s is already in use on the host", e.Port)
+}
+
+// checkPorts will make sure that the ports that are published are not used on the host or by another port of the tool
+func checkPorts(host *docker.HostConfig) error {
+	if !publishes(host.NetworkMode) {
+		return nil
+	}
+	ports := []string{}
+	for port := range host.PortBindings {
+		ports = append(ports, string(port))
+	}
+	sort.Strings(ports)
+	used := map[string]bool{}
+	for _, port := range ports {
+		proto := docker.Port(port).Proto()
+		for _, binding := range host.PortBindings[docker.Port(port)] {
+			address := net.JoinHostPort(binding.HostIP, binding.HostPort)
+			if used[address+"/"+proto] || !portFree(proto, address) {
+				return &PortInUseError{Port: address + "/" + proto}
+			}
+			used[address+"/"+proto] = true
+		}
+	}
+	return nil
+}
+
+// portFree will return true if the address can be bound on the host
+func portFree(proto string, address string) bool {
+	switch proto {
+	case "tcp":
+		l, err := net.Listen("tcp", address)
+		if err != nil {
+			return false
+		}
+		return l.Close() == nil
+	case "udp":
+		c, err := net.ListenPacket("udp", address)
+		if err != nil {
+			return false
+		}
+		return c.Close() == nil
+	}
+	// other protocols cannot be checked, docker will fail if the port is used
+	return true
+}
+
+// printPorts will print the ports that have been published for the container, or the ports of the tool that could not be published in its network
+func printPorts(opt *ExecutionOptions, host *docker.HostConfig, id string) {
+	if opt.IO == nil || len(host.PortBindings) == 0 {
+		return
+	}
+	if !publishes(host.NetworkMode) {
+		printUnpublished(opt, host)
+		return
+	}
+	container, err := opt.Docker.Docker.InspectContainer(id)
+	if err != nil || container.NetworkSettings == nil {
+		logrus.WithError(err).Warn("Could not inspect the published ports")
+		return
+	}
+	ports := []string{}
+	for port := range container.NetworkSettings.Ports {
+		ports = append(ports, string(port))
+	}
+	sort.Strings(ports)
+	for _, port := range ports {
+		for _, binding := range container.NetworkSettings.Ports[docker.Port(port)] {
+			fmt.Fprintf(opt.IO.Err, "Publishing %s of %s on %s\n", port, opt.Tool.Data().Name, net.JoinHostPort(binding.HostIP, binding.HostPort))
+		}
+	}
+}
+
+// exposedPorts will return the ports that need to be exposed for the port bindings
+func exposedPorts(host *docker.HostConfig) map[docker.Port]struct{} {
+	if len(host.PortBindings) == 0 {
+		return nil
+	}
+	exposed := map[docker.Port]struct{}{}
+	for port := range host.PortBindings {
+		exposed[port] = struct{}{}
+	}
+	return exposed
+}
+
+// parseVolume will parse a volume in the form of 'source:target[:ro]'
+func parseVolume(volume string) (docker.HostMount, error) {
+	parts := strings.Split(volume, ":")
+	// windows paths contain the drive letter
+	if len(parts) > 2 && len(parts[0]) == 1 && filepath.VolumeName(parts[0]+":") != "" {
+		parts = append([]string{parts[0] + ":" + parts[1]}, parts[2:]...)
+	}
+	if len(parts) < 2 || len(parts) > 3 || len(parts[0]) == 0 || !path.IsAbs(parts[1]) {
+		return docker.HostMount{}, &InvalidRuntimeError{Setting: "volume", Value: volume}
+	}
+	mount := docker.HostMount{
+		Source: parts[0],
+		Target: parts[1],
+		Type:   "bind",
+	}
+	if len(parts) == 3 {
+		if parts[2] != "ro" && parts[2] != "rw" {
+			return docker.HostMount{}, &InvalidRuntimeError{Setting: "volume", Value: volume}
+		}
+		mount.ReadOnly = parts[2] == "ro"
+	}
+	if strings.HasPrefix(mount.Source, "~") {
+		mount.Source = homedir.Get() + mount.Source[1:]
+	}
+	if !filepath.IsAbs(mount.Source) {
+		mount.Type = "volume"
+	}
+	return mount, nil
+}
+
+// parsePort will parse a port in the form of '[ip:]hostPort:containerPort[/protocol]' or 'containerPort[/protocol]'
+func parsePort(port string) (docker.Port, docker.PortBinding, error) {
+	protocol := "tcp"
+	spec := port
+	if i := strings.LastIndex(spec, "/"); i >= 0 {
+		protocol = spec[i+1:]
+		spec = spec[:i]
+	}
+	if protocol != "tcp" && protocol != "udp" && protocol != "sctp" {
+		return "", docker.PortBinding{}, &InvalidRuntimeError{Setting: "port", Value: port}
+	}
+	binding := docker.PortBinding{}
+	parts := strings.Split(spec, ":")
+	switch len(parts) {
+	case 1:
+		binding.HostPort = parts[0]
+	case 2:
+		binding.HostPort = parts[0]
+	case 3:
+		binding.HostIP = parts[0]
+		binding.HostPort = parts[1]
+	default:
+		return "", docker.PortBinding{}, &InvalidRuntimeError{Setting: "port", Value: port}
+	}
+	containerPort := parts[len(parts)-1]
+	for _, p := range []string{binding.HostPort, containerPort} {
+		if n, err := strconv.Atoi(p); err != nil || n <= 0 || n > 65535 {
+			return "", docker.PortBinding{}, &InvalidRuntimeError{Setting: "port", Value: port}
+		}
+	}
+	return docker.Port(containerPort + "/" + protocol), binding, nil
+}
+
+// printUnpublished will warn about the ports of the tool that are not reachable as declared in the network of the container.
+// In the host network a port is only reachable if it is the same on the host and in the container.
+func printUnpublished(opt *ExecutionOptions, host *docker.HostConfig) {
+	ports := []string{}
+	for port := range host.PortBindings {
+		ports = append(ports, string(port))
+	}
+	sort.Strings(ports)
+	for _, port := range ports {
+		for _, binding := range host.PortBindings[docker.Port(port)] {
+			if host.NetworkMode == "host" && binding.HostPort == docker.Port(port).Port() {
+				continue
+			}
+			fmt.Fprintf(opt.IO.Err, "Port %s of %s is not published on %s in the network %s\n", port, opt.Tool.Data().Name, net.JoinHostPort(binding.HostIP, binding.HostPort), host.NetworkMode)
+		}
+	}
+}
@@ -0,0 +1,468 @@
+/*
+Copyright 2018 Adobe
+All Rights Reserved.
+
+NOTICE: Adobe permits you to use, modify, and distribute this file in
+accordance with the terms of the Adobe license agreement accompanying
+it. If you have received this file from a source other than Adobe,
+then your use, modification, or distribution of it requires the prior
+written permission of Adobe.
+*/
+
+package tool_test
+
+import (
+	"bytes"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+
+	docker "github.com/fsouza/go-dockerclient"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/adobe/sledgehammer/mocks"
+	"github.com/adobe/sledgehammer/slh/config"
+	"github.com/adobe/sledgehammer/slh/mount"
+	"github.com/adobe/sledgehammer/slh/tool"
+)
+
+func TestRuntime(t *testing.T) {
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	used, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer used.Close()
+	usedPort := strconv.Itoa(used.Addr().(*net.TCPAddr).Port)
+	free, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	freePort := strconv.Itoa(free.Addr().(*net.TCPAddr).Port)
+	free.Close()
+
+	cases := []struct {
+		name      string
+		runtime   *tool.Runtime
+		network   string
+		mounts    []mount.Mount
+		transient []string
+		check     func(*testing.T, docker.CreateContainerOptions)
+		err       error
+	}{
+		{
+			name: "No runtime settings",
+			check: func(t *testing.T, opts docker.CreateContainerOptions) {
+				assert.Equal(t, "host", opts.HostConfig.NetworkMode)
+				assert.Equal(t, []string{"0"}, opts.HostConfig.GroupAdd)
+				assert.Empty(t, opts.HostConfig.PortBindings)
+				assert.Empty(t, opts.Config.User)
+			},
+		},
+		{
+			name: "Network and user",
+			runtime: &tool.Runtime{
+				Network: "bridge",
+				User:    "root",
+			},
+			check: func(t *testing.T, opts docker.CreateContainerOptions) {
+				assert.Equal(t, "bridge", opts.HostConfig.NetworkMode)
+				assert.Equal(t, "root", opts.Config.User)
+			},
+		},
+		{
+			name: "Ports",
+			runtime: &tool.Runtime{
+				Ports: []string{"8080", "9000:90/udp", "127.0.0.1:5432:5432"},
+			},
+			check: func(t *testing.T, opts docker.CreateContainerOptions) {
+				assert.Equal(t, map[docker.Port][]docker.PortBinding{
+					"8080/tcp": {{HostPort: "8080"}},
+					"90/udp":   {{HostPort: "9000"}},
+					"5432/tcp": {{HostIP: "127.0.0.1", HostPort: "5432"}},
+				}, opts.HostConfig.PortBindings)
+				assert.Len(t, opts.Config.ExposedPorts, 3)
+			},
+		},
+		{
+			name: "Volumes",
+			runtime: &tool.Runtime{
+				Volumes: []string{"/data:/data:ro", "cache:/root/.cache"},
+			},
+			check: func(t *testing.T, opts docker.CreateContainerOptions) {
+				assert.Contains(t, opts.HostConfig.Mounts, docker.HostMount{Source: "/data", Target: "/data", Type: "bind", ReadOnly: true})
+				assert.Contains(t, opts.HostConfig.Mounts, docker.HostMount{Source: "cache", Target: "/root/.cache", Type: "volume"})
+			},
+		},
+		{
+			name:      "Transient mounts are not mounted into daemons",
+			mounts:    []mount.Mount{mount.Bind("/data")},
+			transient: []string{wd, "/other"},
+			check: func(t *testing.T, opts docker.CreateContainerOptions) {
+				assert.Equal(t, []docker.HostMount{{Source: "/data", Target: "/data", Type: "bind"}}, opts.HostConfig.Mounts)
+			},
+		},
+		{
+			name: "Mount options",
+			mounts: []mount.Mount{
+				{Type: mount.TypeBind, Source: "/home/user/src", Target: "/src", ReadOnly: true},
+				{Type: mount.TypeVolume, Source: "go", Target: "/go"},
+				{Type: mount.TypeTmpfs, Target: "/scratch"},
+			},
+			check: func(t *testing.T, opts docker.CreateContainerOptions) {
+				assert.Equal(t, []docker.HostMount{
+					{Source: "/home/user/src", Target: "/src", Type: "bind", ReadOnly: true},
+					{Source: "go", Target: "/go", Type: "volume"},
+					{Target: "/scratch", Type: "tmpfs"},
+				}, opts.HostConfig.Mounts)
+			},
+		},
+		{
+			name:    "Network of the execution takes precedence",
+			runtime: &tool.Runtime{Network: "bridge"},
+			network: "none",
+			check: func(t *testing.T, opts docker.CreateContainerOptions) {
+				assert.Equal(t, "none", opts.HostConfig.NetworkMode)
+			},
+		},
+		{
+			name:    "Port in use",
+			runtime: &tool.Runtime{Ports: []string{"127.0.0.1:" + usedPort + ":80"}},
+			network: "bridge",
+			err:     &tool.PortInUseError{Port: "127.0.0.1:" + usedPort + "/tcp"},
+		},
+		{
+			name:    "Port in use is ignored with host network",
+			runtime: &tool.Runtime{Ports: []string{"127.0.0.1:" + usedPort + ":80"}},
+			check: func(t *testing.T, opts docker.CreateContainerOptions) {
+				assert.Equal(t, "host", opts.HostConfig.NetworkMode)
+			},
+		},
+		{
+			name:    "Port published twice",
+			runtime: &tool.Runtime{Ports: []string{"127.0.0.1:" + freePort + ":80", "127.0.0.1:" + freePort + ":90"}},
+			network: "bridge",
+			err:     &tool.PortInUseError{Port: "127.0.0.1:" + freePort + "/tcp"},
+		},
+		{
+			name: "Invalid port",
+			runtime: &tool.Runtime{
+				Ports: []string{"foo:bar"},
+			},
+			err: &tool.InvalidRuntimeError{Setting: "port", Value: "foo:bar"},
+		},
+		{
+			name: "Invalid volume",
+			runtime: &tool.Runtime{
+				Volumes: []string{"/data"},
+			},
+			err: &tool.InvalidRuntimeError{Setting: "volume", Value: "/data"},
+		},
+	}
+
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			mockCtrl := gomock.NewController(t)
+			defer mockCtrl.Finish()
+			dockerMock := mocks.NewMockClient(mockCtrl)
+
+			var created docker.CreateContainerOptions
+			if tt.err == nil {
+				dockerMock.EXPECT().CreateContainer(gomock.Any()).DoAndReturn(func(opts docker.CreateContainerOptions) (*docker.Container, error) {
+					created = opts
+					return &docker.Container{ID: "foo"}, nil
+				})
+				dockerMock.EXPECT().StartContainer("foo", gomock.Any())
+			}
+
+			id, err := tool.StartIfDaemon(&tool.ExecutionOptions{
+				Tool: &tool.LocalTool{
+					Core: tool.Data{
+						Name:    "foo",
+						Image:   "foo",
+						Daemon:  &tool.Daemon{Entry: []string{"sh"}},
+						Runtime: tt.runtime,
+					},
+				},
+				Docker:          &config.Docker{Docker: dockerMock},
+				Mounts:          tt.mounts,
+				TransientMounts: tt.transient,
+				Network:         tt.network,
+			})
+			if tt.err != nil {
+				assert.Equal(t, tt.err, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, "foo", id)
+			tt.check(t, created)
+		})
+	}
+}
+
+func TestTransientWorkingDirectory(t *testing.T) {
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cases := []struct {
+		name      string
+		mounts    []mount.Mount
+		transient []string
+		expected  string
+	}{
+		{
+			name: "Working directory is not part of a mount",
+		},
+		{
+			name:      "Working directory is not mounted into daemons",
+			transient: []string{wd},
+		},
+		{
+			name:     "Working directory is remapped",
+			mounts:   []mount.Mount{{Type: mount.TypeBind, Source: filepath.Dir(wd), Target: "/src"}},
+			expected: "/src/" + filepath.Base(wd),
+		},
+	}
+
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			mockCtrl := gomock.NewController(t)
+			defer mockCtrl.Finish()
+			dockerMock := mocks.NewMockClient(mockCtrl)
+			dockerMock.EXPECT().CreateExec(gomock.Any()).DoAndReturn(func(opts docker.CreateExecOptions) (*docker.Exec, error) {
+				assert.Equal(t, tt.expected, opts.WorkingDir)
+				return &docker.Exec{ID: "exec"}, nil
+			})
+			dockerMock.EXPECT().StartExec("exec", gomock.Any())
+			dockerMock.EXPECT().InspectExec("exec").Return(&docker.ExecInspect{ExitCode: 0}, nil)
+
+			_, err := tool.Execute("foo", &tool.ExecutionOptions{
+				Tool: &tool.LocalTool{
+					Core: tool.Data{
+						Name:  "foo",
+						Image: "foo",
+					},
+				},
+				IO: &config.IO{
+					In:  strings.NewReader(""),
+					Out: &bytes.Buffer{},
+					Err: &bytes.Buffer{},
+				},
+				Docker:          &config.Docker{Docker: dockerMock},
+				Mounts:          tt.mounts,
+				TransientMounts: tt.transient,
+			})
+			assert.NoError(t, err)
+		})
+	}
+}
+
+func TestTransientMounts(t *testing.T) {
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cases := []struct {
+		name      string
+		mounts    []mount.Mount
+		transient []string
+		expected  []docker.HostMount
+		workDir   string
+	}{
+		{
+			name:      "Working directory is mounted for this execution",
+			transient: []string{wd},
+			expected:  []docker.HostMount{{Source: wd, Target: wd, Type: "bind"}},
+			workDir:   wd,
+		},
+		{
+			name:      "Transient mounts within a mount are skipped",
+			mounts:    []mount.Mount{mount.Bind("/data")},
+			transient: []string{"/data/project", "/data", "/other"},
+			expected: []docker.HostMount{
+				{Source: "/data", Target: "/data", Type: "bind"},
+				{Source: "/other", Target: "/other", Type: "bind"},
+			},
+		},
+	}
+
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			mockCtrl := gomock.NewController(t)
+			defer mockCtrl.Finish()
+			dockerMock := mocks.NewMockClient(mockCtrl)
+			dockerMock.EXPECT().CreateContainer(gomock.Any()).DoAndReturn(func(opts docker.CreateContainerOptions) (*docker.Container, error) {
+				assert.Equal(t, tt.expected, opts.HostConfig.Mounts)
+				assert.Equal(t, tt.workDir, opts.Config.WorkingDir)
+				return &docker.Container{ID: "foo"}, nil
+			})
+			dockerMock.EXPECT().StartContainer("foo", gomock.Any())
+			dockerMock.EXPECT().AttachToContainer(gomock.Any())
+			dockerMock.EXPECT().InspectContainer("foo").Return(&docker.Container{}, nil)
+			dockerMock.EXPECT().RemoveContainer(gomock.Any())
+
+			_, err := tool.StartAndExecute(&tool.ExecutionOptions{
+				Tool: &tool.LocalTool{
+					Core: tool.Data{
+						Name:  "foo",
+						Image: "foo",
+					},
+				},
+				IO: &config.IO{
+					In:  strings.NewReader(""),
+					Out: &bytes.Buffer{},
+					Err: &bytes.Buffer{},
+				},
+				Docker:          &config.Docker{Docker: dockerMock},
+				Mounts:          tt.mounts,
+				TransientMounts: tt.transient,
+			})
+			assert.NoError(t, err)
+		})
+	}
+}
+
+func TestNetwork(t *testing.T) {
+	cases := []struct {
+		name     string
+		runtime  *tool.Runtime
+		alias    string
+		global   string
+		expected string
+	}{
+		{
+			name:     "Default",
+			expected: "host",
+		},
+		{
+			name:     "Global network",
+			global:   "bridge",
+			expected: "bridge",
+		},
+		{
+			name:     "Network of the tool takes precedence",
+			runtime:  &tool.Runtime{Network: "none"},
+			global:   "bridge",
+			expected: "none",
+		},
+		{
+			name:     "Network of the alias takes precedence",
+			runtime:  &tool.Runtime{Network: "none"},
+			alias:    "dev",
+			global:   "bridge",
+			expected: "dev",
+		},
+	}
+
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			to := &tool.LocalTool{Core: tool.Data{Name: "foo", Runtime: tt.runtime}}
+			assert.Equal(t, tt.expected, tool.Network(to, tt.alias, tt.global))
+		})
+	}
+}
+
+func TestPublishedPorts(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+	dockerMock := mocks.NewMockClient(mockCtrl)
+
+	free, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	port := strconv.Itoa(free.Addr().(*net.TCPAddr).Port)
+	free.Close()
+
+	dockerMock.EXPECT().CreateContainer(gomock.Any()).Return(&docker.Container{ID: "foo"}, nil)
+	dockerMock.EXPECT().StartContainer("foo", gomock.Any())
+	dockerMock.EXPECT().InspectContainer("foo").Return(&docker.Container{
+		NetworkSettings: &docker.NetworkSettings{
+			Ports: map[docker.Port][]docker.PortBinding{
+				"80/tcp": {{HostIP: "127.0.0.1", HostPort: port}},
+			},
+		},
+	}, nil)
+
+	stderr := &bytes.Buffer{}
+	_, err = tool.StartIfDaemon(&tool.ExecutionOptions{
+		Tool: &tool.LocalTool{
+			Core: tool.Data{
+				Name:    "foo",
+				Image:   "foo",
+				Daemon:  &tool.Daemon{Entry: []string{"sh"}},
+				Runtime: &tool.Runtime{Ports: []string{"127.0.0.1:" + port + ":80"}},
+			},
+		},
+		IO:      &config.IO{Out: &bytes.Buffer{}, Err: stderr},
+		Docker:  &config.Docker{Docker: dockerMock},
+		Network: "bridge",
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, "Publishing 80/tcp of foo on 127.0.0.1:"+port+"\n", stderr.String())
+}
+
+func TestUnpublishedPorts(t *testing.T) {
+	cases := []struct {
+		name     string
+		network  string
+		ports    []string
+		expected string
+	}{
+		{
+			name:    "Same port in the host network",
+			network: "host",
+			ports:   []string{"8080:8080"},
+		},
+		{
+			name:     "Other port in the host network",
+			network:  "host",
+			ports:    []string{"9000:90/udp"},
+			expected: "Port 90/udp of foo is not published on :9000 in the network host\n",
+		},
+		{
+			name:     "No network",
+			network:  "none",
+			ports:    []string{"127.0.0.1:5432:5432"},
+			expected: "Port 5432/tcp of foo is not published on 127.0.0.1:5432 in the network none\n",
+		},
+	}
+
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			mockCtrl := gomock.NewController(t)
+			defer mockCtrl.Finish()
+			dockerMock := mocks.NewMockClient(mockCtrl)
+			dockerMock.EXPECT().CreateContainer(gomock.Any()).Return(&docker.Container{ID: "foo"}, nil)
+			dockerMock.EXPECT().StartContainer("foo", gomock.Any())
+
+			stderr := &bytes.Buffer{}
+			_, err := tool.StartIfDaemon(&tool.ExecutionOptions{
+				Tool: &tool.LocalTool{
+					Core: tool.Data{
+						Name:    "foo",
+						Image:   "foo",
+						Daemon:  &tool.Daemon{Entry: []string{"sh"}},
+						Runtime: &tool.Runtime{Ports: tt.ports},
+					},
+				},
+				IO:      &config.IO{Out: &bytes.Buffer{}, Err: stderr},
+				Docker:  &config.Docker{Docker: dockerMock},
+				Network: tt.network,
+			})
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expected, stderr.String())
+		})
+	}
+}
@@ -0,0 +1,98 @@
+/*
+Copyright 2018 Adobe
+All Rights Reserved.
+
+NOTICE: Adobe permits you to use, modify, and distribute this file in
+accordance with the terms of the Adobe license agreement accompanying
+it. If you have received this file from a source other than Adobe,
+then your use, modification, or distribution of it requires the prior
+written permission of Adobe.
+*/
+
+package tool
+
+import (
+	docker "github.com/fsouza/go-dockerclient"
+	"github.com/sirupsen/logrus"
+)
+
+var (
+	// SecurityStrict is the profile for untrusted tools: no network, no capabilities, no new privileges and a read-only root filesystem
+	SecurityStrict = "strict"
+	// SecurityDefault is the profile tools run with if they do not request one
+	SecurityDefault = "default"
+	// SecurityPrivileged is the profile for tools that need full access to the host, e.g. to manage devices
+	SecurityPrivileged = "privileged"
+	// SecurityProfiles are all profiles, from the most restrictive to the most permissive
+	SecurityProfiles = []string{SecurityStrict, SecurityDefault, SecurityPrivileged}
+)
+
+// ValidateSecurity will check that the given security profile exists
+func ValidateSecurity(profile string) error {
+	if securityLevel(profile) < 0 {
+		return &InvalidRuntimeError{Setting: "security profile", Value: profile}
+	}
+	return nil
+}
+
+// SecurityProfile will return the security profile the tool requests, a tool with the privileged permission is privileged unless the runtime requests another profile
+func SecurityProfile(to Tool) string {
+	if len(runtime(to).Security) > 0 {
+		return runtime(to).Security
+	}
+	if permissions(to).Privileged {
+		return SecurityPrivileged
+	}
+	return SecurityDefault
+}
+
+// Security will return the security profile of the container.
+// The profile the tool requests is lowered to the given maximum if it is more permissive, an empty maximum allows all profiles.
+func Security(to Tool, max string) string {
+	profile := SecurityProfile(to)
+	if len(max) > 0 && securityLevel(max) >= 0 && securityLevel(profile) > securityLevel(max) {
+		logrus.WithField("tool", to.Data().Name).WithField("requested", profile).WithField("max", max).Info("Lowering security profile of tool")
+		return max
+	}
+	return profile
+}
+
+// securityProfile will return the security profile of the container for the execution
+func securityProfile(opt *ExecutionOptions) string {
+	if len(opt.Security) > 0 {
+		return opt.Security
+	}
+	return SecurityProfile(opt.Tool)
+}
+
+func securityLevel(profile string) int {
+	for i, p := range SecurityProfiles {
+		if p == profile {
+			return i
+		}
+	}
+	return -1
+}
+
+// secure will restrict or widen the host configuration according to the security profile of the execution
+func secure(opt *ExecutionOptions, host *docker.HostConfig) (*docker.HostConfig, error) {
+	profile := securityProfile(opt)
+	switch profile {
+	case SecurityStrict:
+		host.SecurityOpt = []string{"no-new-privileges"}
+		host.CapDrop = []string{"ALL"}
+		host.ReadonlyRootfs = true
+		host.Mounts = append(host.Mounts, docker.HostMount{Target: "/tmp", Type: "tmpfs"})
+		// without a network nothing can be published
+		host.PortBindings = nil
+	case SecurityDefault:
+		// the same container as without profiles, setuid binaries like sudo or ping keep working
+		host.GroupAdd = []string{"0"}
+	case SecurityPrivileged:
+		host.Privileged = true
+		host.GroupAdd = []string{"0"}
+	default:
+		return nil, &InvalidRuntimeError{Setting: "security profile", Value: profile}
+	}
+	return host, nil
+}
@@ -0,0 +1,159 @@
+/*
+Copyright 2018 Adobe
+All Rights Reserved.
+
+NOTICE: Adobe permits you to use, modify, and distribute this file in
+accordance with the terms of the Adobe license agreement accompanying
+it. If you have received this file from a source other than Adobe,
+then your use, modification, or distribution of it requires the prior
+written permission of Adobe.
+*/
+
+package tool_test
+
+import (
+	"testing"
+
+	docker "github.com/fsouza/go-dockerclient"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/adobe/sledgehammer/mocks"
+	"github.com/adobe/sledgehammer/slh/config"
+	"github.com/adobe/sledgehammer/slh/tool"
+)
+
+func TestSecurity(t *testing.T) {
+	cases := []struct {
+		name      string
+		requested string
+		max       string
+		expected  string
+	}{
+		{
+			name:     "Default profile",
+			expected: "default",
+		},
+		{
+			name:      "Requested profile",
+			requested: "privileged",
+			expected:  "privileged",
+		},
+		{
+			name:      "Profile is lowered to the maximum",
+			requested: "privileged",
+			max:       "default",
+			expected:  "default",
+		},
+		{
+			name:     "Default profile is lowered to the maximum",
+			max:      "strict",
+			expected: "strict",
+		},
+		{
+			name:      "More restrictive profiles are kept",
+			requested: "strict",
+			max:       "default",
+			expected:  "strict",
+		},
+	}
+
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			to := &tool.LocalTool{Core: tool.Data{Name: "foo", Runtime: &tool.Runtime{Security: tt.requested}}}
+			assert.Equal(t, tt.expected, tool.Security(to, tt.max))
+		})
+	}
+
+	assert.NoError(t, tool.ValidateSecurity("strict"))
+	assert.Equal(t, &tool.InvalidRuntimeError{Setting: "security profile", Value: "root"}, tool.ValidateSecurity("root"))
+}
+
+func TestSecurityProfiles(t *testing.T) {
+	cases := []struct {
+		name     string
+		runtime  *tool.Runtime
+		security string
+		check    func(*testing.T, docker.CreateContainerOptions)
+		err      error
+	}{
+		{
+			name:    "Strict",
+			runtime: &tool.Runtime{Security: "strict", Ports: []string{"8080"}, Network: "bridge"},
+			check: func(t *testing.T, opts docker.CreateContainerOptions) {
+				assert.Equal(t, "none", opts.HostConfig.NetworkMode)
+				assert.Equal(t, []string{"no-new-privileges"}, opts.HostConfig.SecurityOpt)
+				assert.Equal(t, []string{"ALL"}, opts.HostConfig.CapDrop)
+				assert.True(t, opts.HostConfig.ReadonlyRootfs)
+				assert.Empty(t, opts.HostConfig.GroupAdd)
+				assert.Empty(t, opts.HostConfig.PortBindings)
+				assert.Empty(t, opts.Config.ExposedPorts)
+				assert.Equal(t, []docker.HostMount{{Target: "/tmp", Type: "tmpfs"}}, opts.HostConfig.Mounts)
+			},
+		},
+		{
+			name: "Default",
+			check: func(t *testing.T, opts docker.CreateContainerOptions) {
+				assert.Equal(t, "host", opts.HostConfig.NetworkMode)
+				assert.Empty(t, opts.HostConfig.SecurityOpt)
+				assert.Empty(t, opts.HostConfig.CapDrop)
+				assert.False(t, opts.HostConfig.ReadonlyRootfs)
+				assert.False(t, opts.HostConfig.Privileged)
+				assert.Equal(t, []string{"0"}, opts.HostConfig.GroupAdd)
+			},
+		},
+		{
+			name:    "Privileged",
+			runtime: &tool.Runtime{Security: "privileged"},
+			check: func(t *testing.T, opts docker.CreateContainerOptions) {
+				assert.True(t, opts.HostConfig.Privileged)
+				assert.Empty(t, opts.HostConfig.SecurityOpt)
+				assert.Equal(t, []string{"0"}, opts.HostConfig.GroupAdd)
+			},
+		},
+		{
+			name:     "Profile of the execution takes precedence",
+			runtime:  &tool.Runtime{Security: "privileged"},
+			security: "strict",
+			check: func(t *testing.T, opts docker.CreateContainerOptions) {
+				assert.False(t, opts.HostConfig.Privileged)
+				assert.True(t, opts.HostConfig.ReadonlyRootfs)
+			},
+		},
+		{
+			name:    "Invalid profile",
+			runtime: &tool.Runtime{Security: "root"},
+			err:     &tool.InvalidRuntimeError{Setting: "security profile", Value: "root"},
+		},
+	}
+
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			mockCtrl := gomock.NewController(t)
+			defer mockCtrl.Finish()
+			dockerMock := mocks.NewMockClient(mockCtrl)
+
+			if tt.err == nil {
+				dockerMock.EXPECT().CreateContainer(gomock.Any()).DoAndReturn(func(opts docker.CreateContainerOptions) (*docker.Container, error) {
+					tt.check(t, opts)
+					return &docker.Container{ID: "foo"}, nil
+				})
+				dockerMock.EXPECT().StartContainer("foo", gomock.Any())
+			}
+
+			_, err := tool.StartIfDaemon(&tool.ExecutionOptions{
+				Tool: &tool.LocalTool{
+					Core: tool.Data{
+						Name:    "foo",
+						Image:   "foo",
+						Daemon:  &tool.Daemon{Entry: []string{"sh"}},
+						Runtime: tt.runtime,
+					},
+				},
+				Docker:   &config.Docker{Docker: dockerMock},
+				Security: tt.security,
+			})
+			assert.Equal(t, tt.err, err)
+		})
+	}
+}
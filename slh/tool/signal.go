@@ -0,0 +1,192 @@
+/*
+Copyright 2018 Adobe
+All Rights Reserved.
+
+NOTICE: Adobe permits you to use, modify, and distribute this file in
+accordance with the terms of the Adobe license agreement accompanying
+it. If you have received this file from a source other than Adobe,
+then your use, modification, or distribution of it requires the prior
+written permission of Adobe.
+*/
+
+package tool
+
+import (
+	"bytes"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+
+	"golang.org/x/crypto/ssh/terminal"
+
+	"github.com/adobe/sledgehammer/utils"
+	docker "github.com/fsouza/go-dockerclient"
+	"github.com/sirupsen/logrus"
+)
+
+var (
+	// RelayedSignals are the signals that will be forwarded to a running tool
+	RelayedSignals = []os.Signal{os.Interrupt, syscall.SIGTERM, syscall.SIGHUP}
+	// SignalProcess will send a signal to the process with the given pid of the docker daemon, if it belongs to the given container
+	SignalProcess = utils.SignalContainerProcess
+	// ExitProcess will exit Sledgehammer, it is used if a signal cannot be forwarded to the tool
+	ExitProcess  = os.Exit
+	terminalSize = func() (int, int, error) {
+		return terminal.GetSize(int(os.Stdin.Fd()))
+	}
+)
+
+// relay forwards signals and terminal resizes of the Sledgehammer process to a running tool
+type relay struct {
+	signals  chan os.Signal
+	done     chan struct{}
+	forward  func(syscall.Signal) error
+	fallback func(syscall.Signal)
+	resize   func(height, width int) error
+	mutex    sync.Mutex
+	received syscall.Signal
+}
+
+// relaySignals will start to forward signals to the tool until stop is called.
+// If a signal cannot be forwarded, the fallback has to stop the tool in another way.
+// If resize is nil, no terminal is attached and changes of the terminal size will be ignored.
+func relaySignals(forward func(syscall.Signal) error, fallback func(syscall.Signal), resize func(height, width int) error) *relay {
+	r := &relay{
+		signals:  make(chan os.Signal, 1),
+		done:     make(chan struct{}),
+		forward:  forward,
+		fallback: fallback,
+		resize:   resize,
+	}
+	signals := append([]os.Signal{}, RelayedSignals...)
+	if resize != nil {
+		signals = append(signals, utils.ResizeSignals()...)
+	}
+	signal.Notify(r.signals, signals...)
+	go r.run()
+	return r
+}
+
+func (r *relay) run() {
+	for {
+		select {
+		case <-r.done:
+			return
+		case sig := <-r.signals:
+			if isResize(sig) {
+				r.resizeTerminal()
+				continue
+			}
+			s, ok := sig.(syscall.Signal)
+			if !ok {
+				continue
+			}
+			logrus.WithField("signal", s.String()).Info("Forwarding signal to tool")
+			r.mutex.Lock()
+			r.received = s
+			r.mutex.Unlock()
+			if err := r.forward(s); err != nil {
+				logrus.WithField("signal", s.String()).Warnln("Could not forward signal, stopping the tool: ", err.Error())
+				r.fallback(s)
+			}
+		}
+	}
+}
+
+// attached will resize the terminal of the tool as soon as the streams are attached.
+// The channel has to be passed as Success channel to the attach or exec options.
+func (r *relay) attached(success chan struct{}) {
+	go func() {
+		select {
+		case <-success:
+			r.resizeTerminal()
+			success <- struct{}{}
+		case <-r.done:
+		}
+	}()
+}
+
+func (r *relay) resizeTerminal() {
+	if r.resize == nil {
+		return
+	}
+	width, height, err := terminalSize()
+	if err != nil {
+		logrus.Debugln("Could not get the terminal size: ", err.Error())
+		return
+	}
+	logrus.WithField("height", height).WithField("width", width).Debug("Resizing terminal of tool")
+	if err := r.resize(height, width); err != nil {
+		logrus.Debugln("Could not resize the terminal of the tool: ", err.Error())
+	}
+}
+
+// stop will stop forwarding signals to the tool
+func (r *relay) stop() {
+	signal.Stop(r.signals)
+	close(r.done)
+}
+
+// signaled will return the exit code according to shell conventions (128 + signal) if a signal has been forwarded
+func (r *relay) signaled() (int, bool) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	if r.received == 0 {
+		return 0, false
+	}
+	return 128 + int(r.received), true
+}
+
+func isResize(sig os.Signal) bool {
+	for _, s := range utils.ResizeSignals() {
+		if s == sig {
+			return true
+		}
+	}
+	return false
+}
+
+// killContainer will return a function that sends signals to the main process of the given container
+func killContainer(opt *ExecutionOptions, containerID string) func(syscall.Signal) error {
+	return func(s syscall.Signal) error {
+		return opt.Docker.Docker.KillContainer(docker.KillContainerOptions{
+			ID:     containerID,
+			Signal: docker.Signal(s),
+		})
+	}
+}
+
+// stopContainerOnSignal will return a fallback that stops the container, it is killed if it does not terminate in time
+func stopContainerOnSignal(opt *ExecutionOptions, containerID string) func(syscall.Signal) {
+	return func(s syscall.Signal) {
+		if err := stopContainer(opt, containerID)(); err != nil {
+			logrus.WithField("id", containerID).Warnln("Could not stop the tool: ", err.Error())
+		}
+	}
+}
+
+// exitOnSignal will return a fallback that exits Sledgehammer with 128 + signal like a shell does, the exec cannot be stopped.
+// The terminal is restored before, the exec loses its streams.
+func exitOnSignal(opt *ExecutionOptions, state *terminal.State, buffer *bytes.Buffer) func(syscall.Signal) {
+	return func(s syscall.Signal) {
+		restoreFunc(state, buffer, opt.IO.Out)
+		ExitProcess(128 + int(s))
+	}
+}
+
+// signalExec will return a function that sends signals to the process started by the exec.
+// Docker cannot signal an exec, so the process is signaled by the pid the docker daemon reports for it. Only this process, or its process group, is signaled,
+// concurrent execs and the processes of the daemon keep running. If the docker daemon runs on another host or in a VM, the exec cannot be signaled.
+func signalExec(opt *ExecutionOptions, containerID string, execID string) func(syscall.Signal) error {
+	return func(s syscall.Signal) error {
+		process, err := opt.Docker.Docker.InspectExec(execID)
+		if err != nil {
+			return err
+		}
+		if !process.Running || process.Pid <= 0 {
+			return nil
+		}
+		return SignalProcess(process.Pid, containerID, s)
+	}
+}
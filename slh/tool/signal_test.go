@@ -0,0 +1,220 @@
+// +build !windows
+
+/*
+Copyright 2018 Adobe
+All Rights Reserved.
+
+NOTICE: Adobe permits you to use, modify, and distribute this file in
+accordance with the terms of the Adobe license agreement accompanying
+it. If you have received this file from a source other than Adobe,
+then your use, modification, or distribution of it requires the prior
+written permission of Adobe.
+*/
+
+package tool_test
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"syscall"
+	"testing"
+	"time"
+
+	docker "github.com/fsouza/go-dockerclient"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/adobe/sledgehammer/mocks"
+	"github.com/adobe/sledgehammer/slh/config"
+	"github.com/adobe/sledgehammer/slh/tool"
+	"github.com/adobe/sledgehammer/utils"
+)
+
+func TestSignalRelay(t *testing.T) {
+	cases := []struct {
+		name    string
+		signal  syscall.Signal
+		kill    error
+		inspect error
+		code    int
+	}{
+		{
+			name:   "Exit code of the tool",
+			signal: syscall.SIGTERM,
+			code:   143,
+		},
+		{
+			name:    "Exit code for interrupt",
+			signal:  syscall.SIGINT,
+			inspect: errors.New("No such container"),
+			code:    130,
+		},
+		{
+			name:    "Exit code for terminate",
+			signal:  syscall.SIGTERM,
+			inspect: errors.New("No such container"),
+			code:    143,
+		},
+		{
+			name:   "Container that cannot be signaled is stopped",
+			signal: syscall.SIGTERM,
+			kill:   errors.New("Cannot kill container"),
+			code:   143,
+		},
+	}
+
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			mockCtrl := gomock.NewController(t)
+			defer mockCtrl.Finish()
+			dockerMock := mocks.NewMockClient(mockCtrl)
+
+			killed := make(chan struct{})
+			dockerMock.EXPECT().CreateContainer(gomock.Any()).Return(&docker.Container{ID: "foo"}, nil)
+			dockerMock.EXPECT().StartContainer("foo", gomock.Any())
+			dockerMock.EXPECT().KillContainer(docker.KillContainerOptions{ID: "foo", Signal: docker.Signal(tt.signal)}).DoAndReturn(func(docker.KillContainerOptions) error {
+				if tt.kill == nil {
+					close(killed)
+				}
+				return tt.kill
+			})
+			if tt.kill != nil {
+				dockerMock.EXPECT().StopContainer("foo", tool.StopGrace).DoAndReturn(func(string, uint) error {
+					close(killed)
+					return nil
+				})
+			}
+			dockerMock.EXPECT().AttachToContainer(gomock.Any()).DoAndReturn(func(docker.AttachToContainerOptions) error {
+				syscall.Kill(os.Getpid(), tt.signal)
+				select {
+				case <-killed:
+				case <-time.After(5 * time.Second):
+					t.Error("Signal has not been forwarded")
+				}
+				return nil
+			})
+			if tt.inspect != nil {
+				dockerMock.EXPECT().InspectContainer("foo").Return(nil, tt.inspect)
+			} else {
+				dockerMock.EXPECT().InspectContainer("foo").Return(&docker.Container{State: docker.State{ExitCode: tt.code}}, nil)
+				dockerMock.EXPECT().RemoveContainer(gomock.Any())
+			}
+
+			code, err := tool.StartAndExecute(&tool.ExecutionOptions{
+				Tool: &tool.LocalTool{
+					Core: tool.Data{
+						Name:  "foo",
+						Image: "foo",
+					},
+				},
+				IO: &config.IO{
+					In:  &bytes.Buffer{},
+					Out: &bytes.Buffer{},
+					Err: &bytes.Buffer{},
+				},
+				Docker: &config.Docker{Docker: dockerMock},
+			})
+			assert.NoError(t, err)
+			assert.Equal(t, tt.code, code)
+		})
+	}
+}
+
+func TestExecSignal(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+	dockerMock := mocks.NewMockClient(mockCtrl)
+	previous := tool.SignalProcess
+	defer func() { tool.SignalProcess = previous }()
+
+	killed := make(chan struct{})
+	tool.SignalProcess = func(pid int, container string, sig syscall.Signal) error {
+		assert.Equal(t, 42, pid)
+		assert.Equal(t, "daemon", container)
+		assert.Equal(t, syscall.SIGTERM, sig)
+		close(killed)
+		return nil
+	}
+	dockerMock.EXPECT().CreateExec(gomock.Any()).Return(&docker.Exec{ID: "exec"}, nil)
+	dockerMock.EXPECT().StartExec("exec", gomock.Any()).DoAndReturn(func(id string, opts docker.StartExecOptions) error {
+		syscall.Kill(os.Getpid(), syscall.SIGTERM)
+		select {
+		case <-killed:
+		case <-time.After(5 * time.Second):
+			t.Error("Signal has not been forwarded")
+		}
+		return nil
+	})
+	gomock.InOrder(
+		dockerMock.EXPECT().InspectExec("exec").Return(&docker.ExecInspect{ID: "exec", Running: true, Pid: 42}, nil),
+		dockerMock.EXPECT().InspectExec("exec").Return(&docker.ExecInspect{ID: "exec", ExitCode: 143}, nil),
+	)
+
+	code, err := tool.Execute("daemon", &tool.ExecutionOptions{
+		Tool: &tool.LocalTool{
+			Core: tool.Data{
+				Name:   "foo",
+				Image:  "foo",
+				Daemon: &tool.Daemon{Entry: []string{"sh"}},
+			},
+		},
+		IO: &config.IO{
+			In:  &bytes.Buffer{},
+			Out: &bytes.Buffer{},
+			Err: &bytes.Buffer{},
+		},
+		Docker: &config.Docker{Docker: dockerMock},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, 143, code)
+}
+
+func TestExecSignalFallback(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+	dockerMock := mocks.NewMockClient(mockCtrl)
+	previous, previousExit := tool.SignalProcess, tool.ExitProcess
+	defer func() { tool.SignalProcess, tool.ExitProcess = previous, previousExit }()
+
+	// the docker daemon runs in a VM, the process of the exec cannot be signaled
+	tool.SignalProcess = func(pid int, container string, sig syscall.Signal) error {
+		return utils.ErrorForeignProcess
+	}
+	exited := make(chan int, 1)
+	tool.ExitProcess = func(code int) {
+		exited <- code
+	}
+	dockerMock.EXPECT().CreateExec(gomock.Any()).Return(&docker.Exec{ID: "exec"}, nil)
+	dockerMock.EXPECT().StartExec("exec", gomock.Any()).DoAndReturn(func(id string, opts docker.StartExecOptions) error {
+		syscall.Kill(os.Getpid(), syscall.SIGINT)
+		select {
+		case code := <-exited:
+			assert.Equal(t, 130, code)
+		case <-time.After(5 * time.Second):
+			t.Error("Sledgehammer has not exited")
+		}
+		return nil
+	})
+	gomock.InOrder(
+		dockerMock.EXPECT().InspectExec("exec").Return(&docker.ExecInspect{ID: "exec", Running: true, Pid: 42}, nil),
+		dockerMock.EXPECT().InspectExec("exec").Return(&docker.ExecInspect{ID: "exec", ExitCode: 0}, nil),
+	)
+
+	_, err := tool.Execute("daemon", &tool.ExecutionOptions{
+		Tool: &tool.LocalTool{
+			Core: tool.Data{
+				Name:   "foo",
+				Image:  "foo",
+				Daemon: &tool.Daemon{Entry: []string{"sh"}},
+			},
+		},
+		IO: &config.IO{
+			In:  &bytes.Buffer{},
+			Out: &bytes.Buffer{},
+			Err: &bytes.Buffer{},
+		},
+		Docker: &config.Docker{Docker: dockerMock},
+	})
+	assert.NoError(t, err)
+}
@@ -0,0 +1,79 @@
+/*
+Copyright 2018 Adobe
+All Rights Reserved.
+
+NOTICE: Adobe permits you to use, modify, and distribute this file in
+accordance with the terms of the Adobe license agreement accompanying
+it. If you have received this file from a source other than Adobe,
+then your use, modification, or distribution of it requires the prior
+written permission of Adobe.
+*/
+
+package tool
+
+import (
+	"io"
+	"sync"
+
+	"github.com/adobe/sledgehammer/slh/config"
+	"github.com/sirupsen/logrus"
+)
+
+// Streams describes which of the standard streams of Sledgehammer are connected to a terminal.
+// Streams that are not connected to a terminal are pipes, files or redirects.
+type Streams struct {
+	Stdin  bool
+	Stdout bool
+	Stderr bool
+}
+
+// DetectStreams will check which of the given streams are connected to a terminal
+func DetectStreams(cfg *config.IO) Streams {
+	s := Streams{
+		Stdin:  config.IsTerminal(cfg.In),
+		Stdout: config.IsTerminal(cfg.Out),
+		Stderr: config.IsTerminal(cfg.Err),
+	}
+	logrus.WithFields(logrus.Fields{
+		"stdin":  s.Stdin,
+		"stdout": s.Stdout,
+		"stderr": s.Stderr,
+	}).Debug("Detected terminal streams")
+	return s
+}
+
+// Tty will return if a pseudo terminal should be allocated for the tool.
+// A pseudo terminal merges stdout and stderr, so it will only be allocated if all streams are terminals.
+// In all other cases the streams are multiplexed and stderr stays separate.
+func (s Streams) Tty() bool {
+	return s.Stdin && s.Stdout && s.Stderr
+}
+
+// preparePipes will connect the given streams with pipes that can be attached to a tool.
+// The returned function has to be called after the tool exited, it will wait until all output has been written.
+func preparePipes(cfg *config.IO) (io.Reader, io.Writer, io.Writer, func()) {
+	inR, inW := io.Pipe()
+	outR, outW := io.Pipe()
+	errR, errW := io.Pipe()
+
+	wg := sync.WaitGroup{}
+	wg.Add(2)
+	go func() {
+		defer inW.Close()
+		io.Copy(inW, cfg.In)
+	}()
+	go func() {
+		defer wg.Done()
+		io.Copy(cfg.Out, outR)
+	}()
+	go func() {
+		defer wg.Done()
+		io.Copy(cfg.Err, errR)
+	}()
+
+	return inR, outW, errW, func() {
+		outW.Close()
+		errW.Close()
+		wg.Wait()
+	}
+}
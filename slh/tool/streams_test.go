@@ -0,0 +1,157 @@
+/*
+Copyright 2018 Adobe
+All Rights Reserved.
+
+NOTICE: Adobe permits you to use, modify, and distribute this file in
+accordance with the terms of the Adobe license agreement accompanying
+it. If you have received this file from a source other than Adobe,
+then your use, modification, or distribution of it requires the prior
+written permission of Adobe.
+*/
+
+package tool_test
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"strings"
+	"testing"
+
+	docker "github.com/fsouza/go-dockerclient"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/adobe/sledgehammer/mocks"
+	"github.com/adobe/sledgehammer/slh/config"
+	"github.com/adobe/sledgehammer/slh/tool"
+)
+
+func TestStreamsTty(t *testing.T) {
+	cases := []struct {
+		streams tool.Streams
+		tty     bool
+	}{
+		{streams: tool.Streams{Stdin: false, Stdout: false, Stderr: false}, tty: false},
+		{streams: tool.Streams{Stdin: false, Stdout: false, Stderr: true}, tty: false},
+		{streams: tool.Streams{Stdin: false, Stdout: true, Stderr: false}, tty: false},
+		{streams: tool.Streams{Stdin: false, Stdout: true, Stderr: true}, tty: false},
+		{streams: tool.Streams{Stdin: true, Stdout: false, Stderr: false}, tty: false},
+		{streams: tool.Streams{Stdin: true, Stdout: false, Stderr: true}, tty: false},
+		{streams: tool.Streams{Stdin: true, Stdout: true, Stderr: false}, tty: false},
+		{streams: tool.Streams{Stdin: true, Stdout: true, Stderr: true}, tty: true},
+	}
+
+	for _, tt := range cases {
+		t.Run(fmt.Sprintf("stdin=%t,stdout=%t,stderr=%t", tt.streams.Stdin, tt.streams.Stdout, tt.streams.Stderr), func(t *testing.T) {
+			assert.Equal(t, tt.tty, tt.streams.Tty())
+		})
+	}
+}
+
+func TestDetectStreams(t *testing.T) {
+	file, err := ioutil.TempFile("", "slh")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+
+	assert.Equal(t, tool.Streams{}, tool.DetectStreams(&config.IO{
+		In:  strings.NewReader("foo"),
+		Out: file,
+		Err: &bytes.Buffer{},
+	}))
+}
+
+func TestStreamsExecution(t *testing.T) {
+	cases := []struct {
+		name   string
+		daemon bool
+	}{
+		{
+			name:   "Daemon exec",
+			daemon: true,
+		},
+		{
+			name: "One-shot container",
+		},
+	}
+
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			mockCtrl := gomock.NewController(t)
+			defer mockCtrl.Finish()
+			dockerMock := mocks.NewMockClient(mockCtrl)
+
+			// the tool echoes stdin to stdout and reports on stderr
+			echo := func(in io.Reader, out io.Writer, err io.Writer) {
+				io.Copy(out, in)
+				io.WriteString(err, "done")
+			}
+
+			if tt.daemon {
+				dockerMock.EXPECT().CreateExec(gomock.Any()).DoAndReturn(func(opts docker.CreateExecOptions) (*docker.Exec, error) {
+					assert.True(t, opts.AttachStdin)
+					assert.True(t, opts.AttachStdout)
+					assert.True(t, opts.AttachStderr)
+					assert.False(t, opts.Tty)
+					return &docker.Exec{ID: "exec"}, nil
+				})
+				dockerMock.EXPECT().StartExec("exec", gomock.Any()).DoAndReturn(func(_ string, opts docker.StartExecOptions) error {
+					assert.False(t, opts.Tty)
+					assert.False(t, opts.RawTerminal)
+					echo(opts.InputStream, opts.OutputStream, opts.ErrorStream)
+					return nil
+				})
+				dockerMock.EXPECT().InspectExec("exec").Return(&docker.ExecInspect{ExitCode: 0}, nil)
+			} else {
+				dockerMock.EXPECT().CreateContainer(gomock.Any()).DoAndReturn(func(opts docker.CreateContainerOptions) (*docker.Container, error) {
+					assert.True(t, opts.Config.AttachStdin)
+					assert.True(t, opts.Config.AttachStdout)
+					assert.True(t, opts.Config.AttachStderr)
+					assert.True(t, opts.Config.StdinOnce)
+					assert.False(t, opts.Config.Tty)
+					return &docker.Container{ID: "foo"}, nil
+				})
+				dockerMock.EXPECT().StartContainer("foo", gomock.Any())
+				dockerMock.EXPECT().AttachToContainer(gomock.Any()).DoAndReturn(func(opts docker.AttachToContainerOptions) error {
+					assert.False(t, opts.RawTerminal)
+					echo(opts.InputStream, opts.OutputStream, opts.ErrorStream)
+					return nil
+				})
+				dockerMock.EXPECT().InspectContainer("foo").Return(&docker.Container{}, nil)
+				dockerMock.EXPECT().RemoveContainer(gomock.Any())
+			}
+
+			stdout := &bytes.Buffer{}
+			stderr := &bytes.Buffer{}
+			opt := &tool.ExecutionOptions{
+				Tool: &tool.LocalTool{
+					Core: tool.Data{
+						Name:  "foo",
+						Image: "foo",
+					},
+				},
+				IO: &config.IO{
+					In:  strings.NewReader("x"),
+					Out: stdout,
+					Err: stderr,
+				},
+				Docker: &config.Docker{Docker: dockerMock},
+			}
+
+			var code int
+			var err error
+			if tt.daemon {
+				code, err = tool.Execute("foo", opt)
+			} else {
+				code, err = tool.StartAndExecute(opt)
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, 0, code)
+			assert.Equal(t, "x", stdout.String())
+			assert.Equal(t, "done", stderr.String())
+		})
+	}
+}
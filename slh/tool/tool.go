@@ -22,14 +22,16 @@ import (
 	"os"
 	"regexp"
 	"sort"
-	"strconv"
 	"strings"
 	"time"
 
 	"golang.org/x/crypto/ssh/terminal"
 
 	"github.com/adobe/sledgehammer/slh/config"
-	"github.com/adobe/sledgehammer/utils"
+	"github.com/adobe/sledgehammer/slh/environment"
+	"github.com/adobe/sledgehammer/slh/mount"
+	"github.com/adobe/sledgehammer/slh/out"
+	"github.com/adobe/sledgehammer/slh/profile"
 	secrets "github.com/adobe/sledgehammer/utils/docker"
 	bolt "github.com/coreos/bbolt"
 	docker "github.com/fsouza/go-dockerclient"
@@ -101,7 +103,23 @@ type ExecutionOptions struct {
 	IO        *config.IO
 	Docker    *config.Docker
 	Arguments []string
-	Mounts    []string
+	Mounts    []mount.Mount
+	// TransientMounts are only mounted for this execution, e.g. the working directory
+	TransientMounts []string
+	Env             []string
+	Policies        []environment.Policy
+	// Network is the network mode of the container, the network of the tool is used if it is empty
+	Network string
+	// Limits restrict the resources and the runtime of the tool, the limits of the tool are used for limits that are not set
+	Limits Limits
+	// IdentityDir is the directory the passwd and group files of the user are written to, no user is synthesized if it is empty
+	IdentityDir string
+	// Security is the security profile of the container, the profile the tool requests is used if it is empty
+	Security string
+	// Installation is the installation of Sledgehammer the containers are labeled with
+	Installation string
+	Alias        string
+	Profile      *profile.Profile
 }
 
 var (
@@ -112,6 +130,7 @@ var (
 		"local": &LocalFactory{},
 		"hub":   &HubFactory{},
 		"jfrog": &JFrogFactory{},
+		"oci":   &OCIFactory{},
 		"":      &HubFactory{},
 	}
 )
@@ -432,11 +451,8 @@ func Versions(client config.Docker, to Tool) ([]string, error) {
 func (t *Tools) Get(registry string, name string) (Tool, error) {
 	logrus.WithField("registry", registry).WithField("tool", name).Debug("Getting tool from registry")
 	var selectedTool Tool
-	err := t.DB.Update(func(tx *bolt.Tx) error {
-		bucket, err := tx.CreateBucketIfNotExists([]byte(BucketKey))
-		if err != nil {
-			return err
-		}
+	err := t.DB.View(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket([]byte(BucketKey))
 		if bucket != nil {
 			toolBucket := bucket.Bucket([]byte(name))
 			if toolBucket != nil {
@@ -494,8 +510,9 @@ func (t *Tools) Get(registry string, name string) (Tool, error) {
 	return selectedTool, ErrorToolNotFound
 }
 
-// Pull will try to pull the given tool with the given version from the remote repository
-func Pull(client config.Docker, to Tool, tag string, timeout time.Duration) error {
+// Pull will try to pull the given tool with the given version from the remote repository.
+// The progress of the pull is sent to the given output, which can be nil.
+func Pull(client config.Docker, to Tool, tag string, timeout time.Duration, output *out.Output) error {
 
 	doneChan := make(chan error, 1)
 
@@ -512,14 +529,12 @@ func Pull(client config.Docker, to Tool, tag string, timeout time.Duration) erro
 	defer can()
 
 	pr, pw := io.Pipe()
-	defer pw.Close()
 	defer pr.Close()
 
-	scanner := bufio.NewScanner(pr)
+	image := FullImage(to, tag)
+	decodeChan := make(chan error, 1)
 	go func() {
-		for scanner.Scan() {
-			logrus.Info(scanner.Text())
-		}
+		decodeChan <- decodePull(pr, image, output)
 	}()
 
 	logrus.WithFields(logrus.Fields{
@@ -531,23 +546,41 @@ func Pull(client config.Docker, to Tool, tag string, timeout time.Duration) erro
 	pollFunc := func(doneChan chan error) {
 		err := client.Docker.PullImage(docker.PullImageOptions{
 			// legacy, needed for old clients
-			Registry:     to.Data().ImageRegistry,
-			Repository:   FullImage(to, ""),
-			Tag:          tag,
-			OutputStream: pw,
-			Context:      ctx,
+			Registry:      to.Data().ImageRegistry,
+			Repository:    FullImage(to, ""),
+			Tag:           tag,
+			OutputStream:  pw,
+			RawJSONStream: true,
+			Context:       ctx,
 		}, dockerCreds)
+		pw.Close()
 		doneChan <- err
 	}
 	go pollFunc(doneChan)
 	select {
 	case err := <-doneChan:
-		return err
+		if err != nil {
+			return err
+		}
+		// errors during the pull are only reported in the stream
+		return <-decodeChan
 	case <-ctx.Done():
 		return errors.New("Timeout reached during image polling")
 	}
 }
 
+// daemonOptions will return the options without the transient mounts.
+// A daemon is shared by all calls of the tool, the mounts of a single call are never mounted into it.
+func daemonOptions(opt *ExecutionOptions) *ExecutionOptions {
+	if len(opt.TransientMounts) == 0 {
+		return opt
+	}
+	logrus.WithField("mounts", opt.TransientMounts).Warnln("Transient mounts are not mounted into daemons")
+	daemon := *opt
+	daemon.TransientMounts = nil
+	return &daemon
+}
+
 // StartIfDaemon will start the given tool if it is a daemon and will return the id of the prepared container.
 // If no id is returned, then the tool is no daemon
 func StartIfDaemon(opt *ExecutionOptions) (string, error) {
@@ -555,31 +588,47 @@ func StartIfDaemon(opt *ExecutionOptions) (string, error) {
 		logrus.Info("No daemon tool detected")
 		return "", nil
 	}
+	opt = daemonOptions(opt)
 	logrus.WithFields(logrus.Fields{
 		"image":     opt.Tool.Data().Image,
 		"tool":      opt.Tool.Data().Name,
 		"arguments": opt.Tool.Data().Daemon.Entry,
 	}).Info("Detected daemon tool")
 
+	host, err := hostConfig(opt, true)
+	if err != nil {
+		return "", err
+	}
+	if err := checkPorts(host); err != nil {
+		return "", err
+	}
+	if err := prepareCaches(opt); err != nil {
+		return "", err
+	}
+	if err := prepareIdentity(opt); err != nil {
+		return "", err
+	}
+
 	conf := &docker.Config{
 		Image:        FullImage(opt.Tool, opt.Version),
 		Entrypoint:   opt.Tool.Data().Daemon.Entry,
-		Env:          utils.PrepareEnvironment(os.Environ()),
+		Env:          containerEnv(opt),
+		ExposedPorts: exposedPorts(host),
 		AttachStderr: false,
 		AttachStdout: false,
 		AttachStdin:  false,
 		Tty:          true,
 		OpenStdin:    false,
+		Labels:       labels(opt, true),
+	}
+	if len(runtime(opt.Tool).User) > 0 {
+		conf.User = runtime(opt.Tool).User
 	}
 
+	span := opt.Profile.Span("create daemon")
 	resp, err := opt.Docker.Docker.CreateContainer(docker.CreateContainerOptions{
-		Config: conf,
-		HostConfig: &docker.HostConfig{
-			GroupAdd:    []string{"0"},
-			NetworkMode: "host",
-			AutoRemove:  true,
-			Mounts:      utils.PrepareMounts(opt.Mounts),
-		},
+		Config:     conf,
+		HostConfig: host,
 	})
 
 	if err != nil {
@@ -588,19 +637,32 @@ func StartIfDaemon(opt *ExecutionOptions) (string, error) {
 	if err := opt.Docker.Docker.StartContainer(resp.ID, nil); err != nil {
 		return "", err
 	}
+	span()
+	printPorts(opt, host, resp.ID)
+	span = opt.Profile.Span("wait for readiness")
+	err = waitUntilReady(opt, resp.ID)
+	span()
+	if err != nil {
+		opt.Docker.Docker.RemoveContainer(docker.RemoveContainerOptions{
+			Force: true,
+			ID:    resp.ID,
+		})
+		return "", err
+	}
 
 	return resp.ID, nil
 }
 
 // Execute will execute the given command in an already running container.
 func Execute(containerID string, opt *ExecutionOptions) (int, error) {
-	isPipe := utils.IsPipe()
+	streams := DetectStreams(opt.IO)
 	stdOut := &bytes.Buffer{}
 
 	var state *terminal.State
 	var err error
 
-	workspace, err := utils.WorkingDirectory(opt.Mounts)
+	opt = daemonOptions(opt)
+	workspace, err := workingDirectory(opt.Tool, opt.Mounts, opt.TransientMounts)
 	if err != nil {
 		return 1, err
 	}
@@ -610,38 +672,35 @@ func Execute(containerID string, opt *ExecutionOptions) (int, error) {
 
 	createExecConfig := docker.CreateExecOptions{
 		Container:    containerID,
-		AttachStderr: !isPipe,
-		AttachStdout: !isPipe,
-		AttachStdin:  !isPipe,
+		AttachStderr: true,
+		AttachStdout: true,
+		AttachStdin:  true,
 		Cmd:          arguments,
-		Tty:          !isPipe,
-		Env:          utils.PrepareEnvironment(os.Environ()),
+		Tty:          streams.Tty(),
+		Env:          containerEnv(opt),
 		WorkingDir:   workspace,
-	}
-	if os.Getuid() >= 0 && os.Getgid() >= 0 {
-		createExecConfig.User = strconv.Itoa(os.Getuid()) + ":" + strconv.Itoa(os.Getgid())
+		User:         containerUser(opt.Tool),
 	}
 
-	inP, outP, errP := preparePipes(opt.IO)
+	inP, outP, errP, flush := preparePipes(opt.IO)
 	execConfig := docker.StartExecOptions{
 		ErrorStream:  errP,
 		InputStream:  inP,
 		OutputStream: outP,
-		Tty:          !isPipe,
-	}
-	if !isPipe {
-		execConfig.RawTerminal = true
+		Tty:          streams.Tty(),
+		RawTerminal:  streams.Tty(),
 	}
 
 	logrus.Debugln("Creating Exec")
+	span := opt.Profile.Span("create exec")
 	exec, err := opt.Docker.Docker.CreateExec(createExecConfig)
+	span()
 	if err != nil {
 		logrus.WithField("tool", opt.Tool.Data().Name).Errorln("Could not get logs from tool container: ", err.Error())
 		return 1, err
 	}
 
-	tty := terminal.IsTerminal(int(os.Stdin.Fd()))
-	if tty && os.Stdin == opt.IO.In {
+	if streams.Tty() && os.Stdin == opt.IO.In {
 		logrus.Info("Detected tty terminal, making it raw")
 		// buffer concurrent output, it does not work well with a raw terminal...
 		logrus.SetOutput(stdOut)
@@ -651,11 +710,36 @@ func Execute(containerID string, opt *ExecutionOptions) (int, error) {
 		}
 	}
 
+	var resize func(height, width int) error
+	if state != nil {
+		resize = func(height, width int) error {
+			return opt.Docker.Docker.ResizeExecTTY(exec.ID, height, width)
+		}
+	}
+	relay := relaySignals(signalExec(opt, containerID, exec.ID), exitOnSignal(opt, state, stdOut), resize)
+	defer relay.stop()
+	execConfig.Success = make(chan struct{})
+	relay.attached(execConfig.Success)
+	timedOut, err := watchTimeout(opt, stopExec(opt, containerID, exec.ID))
+	if err != nil {
+		restoreFunc(state, stdOut, opt.IO.Out)
+		return 1, err
+	}
+
 	logrus.Debugln("Starting Exec")
+	span = opt.Profile.Span("attach")
 	err = opt.Docker.Docker.StartExec(exec.ID, execConfig)
+	flush()
+	span()
 
 	if err != nil {
 		restoreFunc(state, stdOut, opt.IO.Out)
+		if err := timedOut(); err != nil {
+			return ExitCodeTimeout, err
+		}
+		if code, signaled := relay.signaled(); signaled {
+			return code, nil
+		}
 		logrus.WithField("tool", opt.Tool.Data().Name).Errorln("Could not get logs from tool container: ", err.Error())
 		return 1, err
 	}
@@ -663,12 +747,21 @@ func Execute(containerID string, opt *ExecutionOptions) (int, error) {
 	if err != nil {
 		return 1, err
 	}
+	if err := timedOut(); err != nil {
+		return ExitCodeTimeout, err
+	}
 
 	// get execute information for the exit code
+	span = opt.Profile.Span("inspect exec")
 	execCon, err := opt.Docker.Docker.InspectExec(exec.ID)
+	span()
 	if err != nil {
+		if code, signaled := relay.signaled(); signaled {
+			return code, nil
+		}
 		return 1, err
 	}
+	// docker does not record out of memory kills of an exec, the daemon keeps running, only the exit code tells
 	return execCon.ExitCode, nil
 }
 
@@ -676,48 +769,59 @@ func Execute(containerID string, opt *ExecutionOptions) (int, error) {
 // When this method is called, the tool is not a daemon tool
 func StartAndExecute(opt *ExecutionOptions) (int, error) {
 	var state *terminal.State
-	isPipe := utils.IsPipe()
+	streams := DetectStreams(opt.IO)
 
 	stdOut := &bytes.Buffer{}
 
-	workspace, err := utils.WorkingDirectory(opt.Mounts)
+	workspace, err := workingDirectory(opt.Tool, opt.Mounts, opt.TransientMounts)
+	if err != nil {
+		return 1, err
+	}
+
+	host, err := hostConfig(opt, false)
 	if err != nil {
 		return 1, err
 	}
+	if err := checkPorts(host); err != nil {
+		return 1, err
+	}
+	if err := prepareCaches(opt); err != nil {
+		return 1, err
+	}
+	if err := prepareIdentity(opt); err != nil {
+		return 1, err
+	}
 
 	conf := &docker.Config{
 		Image:        FullImage(opt.Tool, opt.Version),
 		Cmd:          opt.Arguments,
-		Env:          utils.PrepareEnvironment(os.Environ()),
+		Env:          containerEnv(opt),
 		WorkingDir:   workspace,
-		AttachStderr: !isPipe,
-		AttachStdout: !isPipe,
-		AttachStdin:  !isPipe,
-		Tty:          !isPipe,
+		User:         containerUser(opt.Tool),
+		ExposedPorts: exposedPorts(host),
+		AttachStderr: true,
+		AttachStdout: true,
+		AttachStdin:  true,
+		Tty:          streams.Tty(),
 		OpenStdin:    true,
-		StdinOnce:    isPipe,
-	}
-	if os.Getuid() >= 0 && os.Getgid() >= 0 {
-		conf.User = strconv.Itoa(os.Getuid()) + ":" + strconv.Itoa(os.Getgid())
+		StdinOnce:    !streams.Tty(),
+		Labels:       labels(opt, false),
 	}
 	if len(opt.Tool.Data().Entry) > 0 {
 		conf.Entrypoint = opt.Tool.Data().Entry
 	}
 	logrus.Info("Creating container")
+	span := opt.Profile.Span("create container")
 	resp, err := opt.Docker.Docker.CreateContainer(docker.CreateContainerOptions{
-		Config: conf,
-		HostConfig: &docker.HostConfig{
-			GroupAdd:    []string{"0"},
-			NetworkMode: "host",
-			AutoRemove:  false,
-			Mounts:      utils.PrepareMounts(opt.Mounts),
-		},
+		Config:     conf,
+		HostConfig: host,
 	})
+	span()
 
 	if err != nil {
 		return 1, err
 	}
-	inP, outP, errP := preparePipes(opt.IO)
+	inP, outP, errP, flush := preparePipes(opt.IO)
 	attachConfig := docker.AttachToContainerOptions{
 		Container:    resp.ID,
 		Logs:         true,
@@ -728,18 +832,19 @@ func StartAndExecute(opt *ExecutionOptions) (int, error) {
 		ErrorStream:  errP,
 		InputStream:  inP,
 		OutputStream: outP,
-	}
-	if !isPipe {
-		attachConfig.RawTerminal = true
+		RawTerminal:  streams.Tty(),
 	}
 
-	if err := opt.Docker.Docker.StartContainer(resp.ID, nil); err != nil {
+	span = opt.Profile.Span("start container")
+	err = opt.Docker.Docker.StartContainer(resp.ID, nil)
+	span()
+	if err != nil {
 		logrus.WithField("tool", opt.Tool.Data().Name).Errorln("Could not start tool container")
 		return 1, err
 	}
+	printPorts(opt, host, resp.ID)
 
-	tty := terminal.IsTerminal(int(os.Stdin.Fd()))
-	if tty && os.Stdin == opt.IO.In {
+	if streams.Tty() && os.Stdin == opt.IO.In {
 		logrus.Info("Detected tty terminal, making it raw")
 		// buffer concurrent output, it does not work well with a raw terminal...
 		logrus.SetOutput(stdOut)
@@ -749,12 +854,37 @@ func StartAndExecute(opt *ExecutionOptions) (int, error) {
 		}
 	}
 
+	var resize func(height, width int) error
+	if state != nil {
+		resize = func(height, width int) error {
+			return opt.Docker.Docker.ResizeContainerTTY(resp.ID, height, width)
+		}
+	}
+	relay := relaySignals(killContainer(opt, resp.ID), stopContainerOnSignal(opt, resp.ID), resize)
+	defer relay.stop()
+	attachConfig.Success = make(chan struct{})
+	relay.attached(attachConfig.Success)
+	timedOut, err := watchTimeout(opt, stopContainer(opt, resp.ID))
+	if err != nil {
+		restoreFunc(state, stdOut, opt.IO.Out)
+		return 1, err
+	}
+
 	logrus.Debugln("Attaching to container")
+	span = opt.Profile.Span("attach")
 	err = opt.Docker.Docker.AttachToContainer(attachConfig)
+	flush()
+	span()
 	logrus.Debugln("Done attaching to container")
 
 	if err != nil {
 		restoreFunc(state, stdOut, opt.IO.Out)
+		if err := timedOut(); err != nil {
+			return ExitCodeTimeout, err
+		}
+		if code, signaled := relay.signaled(); signaled {
+			return code, nil
+		}
 		logrus.WithField("tool", opt.Tool.Data().Name).Errorln("Could not get logs from tool container")
 		return 1, err
 	}
@@ -766,22 +896,32 @@ func StartAndExecute(opt *ExecutionOptions) (int, error) {
 	}
 
 	// get container information for the exit code
+	span = opt.Profile.Span("inspect container")
 	cont, err := opt.Docker.Docker.InspectContainer(resp.ID)
+	span()
 	if err != nil {
+		if code, signaled := relay.signaled(); signaled {
+			return code, nil
+		}
 		return 1, err
 	}
 
+	span = opt.Profile.Span("remove container")
 	opt.Docker.Docker.RemoveContainer(docker.RemoveContainerOptions{
 		Force: true,
 		ID:    resp.ID,
 	})
+	span()
 
+	if err := timedOut(); err != nil {
+		return ExitCodeTimeout, err
+	}
+	if err := outOfMemory(opt, cont); err != nil {
+		return ExitCodeOutOfMemory, err
+	}
 	return cont.State.ExitCode, nil
 }
 
-// StopDaemons will stop all tool daeomons if possible
-func StopDaemons() {}
-
 func fullImageName(to Tool) string {
 	if len(to.Data().ImageRegistry) > 0 {
 		return to.Data().ImageRegistry + "/" + to.Data().Image
@@ -805,24 +945,3 @@ func Exists(tools []Tool, tool Tool) bool {
 	}
 	return false
 }
-
-func preparePipes(cfg *config.IO) (io.Reader, io.Writer, io.Writer) {
-	inR, inW := io.Pipe()
-	outR, outW := io.Pipe()
-	errR, errW := io.Pipe()
-
-	go func() {
-		defer inW.Close()
-		io.Copy(inW, cfg.In)
-	}()
-	go func() {
-		defer outW.Close()
-		io.Copy(cfg.Out, outR)
-	}()
-	go func() {
-		defer errW.Close()
-		io.Copy(cfg.Err, errR)
-	}()
-
-	return inR, outW, errW
-}
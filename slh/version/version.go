@@ -100,6 +100,54 @@ func Select(versions []string, constraint string) string {
 	return ""
 }
 
+// Matches will check if the given version satisfies the given constraint.
+// Versions that are no semantic versions only match a constraint that equals them, except latest that matches every constraint
+// because it is selected if no other version matches.
+func Matches(version string, constraint string) bool {
+	if constraint == "" {
+		constraint = DefaultConstraint
+	}
+	if version == constraint || version == "latest" {
+		return true
+	}
+	parsedConstraint, err := semver.NewConstraint(constraint)
+	if err != nil {
+		return false
+	}
+	parsedVersion, err := semver.NewVersion(version)
+	if err != nil {
+		return false
+	}
+	return parsedConstraint.Check(parsedVersion)
+}
+
+// Newest will sort the given versions from the newest to the oldest one.
+// Versions that are no semantic versions are considered older than all semantic versions.
+func Newest(versions []string) []string {
+	sorted := make([]string, len(versions))
+	copy(sorted, versions)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		a, errA := semver.NewVersion(sorted[i])
+		b, errB := semver.NewVersion(sorted[j])
+		switch {
+		case errA == nil && errB == nil:
+			return a.GreaterThan(b)
+		case errA == nil:
+			return true
+		case errB == nil:
+			return false
+		}
+		return sorted[i] < sorted[j]
+	})
+	return sorted
+}
+
+// Semantic will return true if the given version is a semantic version
+func Semantic(version string) bool {
+	_, err := semver.NewVersion(version)
+	return err == nil
+}
+
 // ShouldPull will determine if the remote version should be pulled.
 // It will be pulled if the remote version is newer than the local one.
 func ShouldPull(local string, remote string) bool {
@@ -211,3 +211,38 @@ func TestSelectVersions(t *testing.T) {
 	}
 
 }
+
+func TestMatches(t *testing.T) {
+	cases := []struct {
+		version    string
+		constraint string
+		expected   bool
+	}{
+		{version: "1.2.3", constraint: "", expected: true},
+		{version: "1.2.3", constraint: "^1", expected: true},
+		{version: "2.0.0", constraint: "^1", expected: false},
+		{version: "latest", constraint: "^1", expected: true},
+		{version: "stable", constraint: "stable", expected: true},
+		{version: "stable", constraint: "^1", expected: false},
+		{version: "1.2.3", constraint: "stable", expected: false},
+	}
+	for _, tt := range cases {
+		t.Run(tt.version+" "+tt.constraint, func(t *testing.T) {
+			assert.Equal(t, tt.expected, version.Matches(tt.version, tt.constraint))
+		})
+	}
+}
+
+func TestNewest(t *testing.T) {
+	versions := []string{"1.2.0", "latest", "1.10.0", "alpine", "2.0.0"}
+	assert.Equal(t, []string{"2.0.0", "1.10.0", "1.2.0", "alpine", "latest"}, version.Newest(versions))
+	// the given versions are not changed
+	assert.Equal(t, "1.2.0", versions[0])
+}
+
+func TestSemantic(t *testing.T) {
+	assert.True(t, version.Semantic("1.2.3"))
+	assert.True(t, version.Semantic("v1.2"))
+	assert.False(t, version.Semantic("latest"))
+	assert.False(t, version.Semantic("alpine"))
+}
@@ -13,13 +13,16 @@ package contracts
 
 // Tool - Defines how a tool needs to be represented so that sledgehmmer can recognize it
 type Tool struct {
-	Name        string      `json:"name"`
-	Description string      `json:"description,omitempty"`
-	Registry    string      `json:"registry,omitempty"`
-	Image       string      `json:"image"`
-	Entry       []string    `json:"entry,omitempty"`
-	Type        string      `json:"type,omitempty"`
-	Daemon      *ToolDaemon `json:"daemon,omitempty"`
+	Name        string       `json:"name"`
+	Description string       `json:"description,omitempty"`
+	Registry    string       `json:"registry,omitempty"`
+	Image       string       `json:"image"`
+	Entry       []string     `json:"entry,omitempty"`
+	Type        string       `json:"type,omitempty"`
+	Daemon      *ToolDaemon  `json:"daemon,omitempty"`
+	Runtime     *ToolRuntime `json:"runtime,omitempty"`
+	// Permissions are the elevated accesses to the host the tool needs, the user has to approve them before the tool runs
+	Permissions *ToolPermissions `json:"permissions,omitempty"`
 }
 
 // ToolDaemon defines a tool as daemon. The entry will be the main entrypoint that will be called to keep the container in a daemon state.
@@ -28,4 +31,85 @@ type ToolDaemon struct {
 	// This time should be higher than the stated ttl, sledgehammer will make sure
 	Entry []string `json:"entry,omitempty"`
 	// TTL   int      `json:"ttl,omitempty"`
+	// Ready is an optional probe that needs to succeed before commands are executed in the daemon
+	Ready *ToolReadiness `json:"ready,omitempty"`
+}
+
+// ToolReadiness defines how Sledgehammer detects that a daemon is ready to execute commands.
+// If more than one probe is given, all of them need to succeed.
+type ToolReadiness struct {
+	// Exec is a command that is executed in the daemon, the daemon is ready if it exits with 0
+	Exec []string `json:"exec,omitempty"`
+	// Port is a tcp port of the daemon, the daemon is ready if it accepts connections
+	Port int `json:"port,omitempty"`
+	// Log is a text, the daemon is ready if it is part of its logs
+	Log string `json:"log,omitempty"`
+	// Timeout is the time to wait for the daemon, e.g. 1m. Default is 30s
+	Timeout string `json:"timeout,omitempty"`
+}
+
+// ToolRuntime defines additional settings for the container a tool is executed in.
+// All settings are optional, if they are not given the defaults of Sledgehammer will be used.
+type ToolRuntime struct {
+	// Env are environment variables with their default values. Variables of the host take precedence.
+	Env map[string]string `json:"env,omitempty"`
+	// EnvAllow are host variables (globs allowed) that are passed to the tool, even if the global settings do not allow them.
+	// Variables the user denies globally are never passed, unless the alias allows them.
+	EnvAllow []string `json:"envAllow,omitempty"`
+	// EnvDeny are host variables (globs allowed) that are never passed to the tool
+	EnvDeny []string `json:"envDeny,omitempty"`
+	// Volumes are additional volumes in the form of 'source:target[:ro]'.
+	// If the source is no absolute path, a named docker volume will be used.
+	Volumes []string `json:"volumes,omitempty"`
+	// Ports are ports that should be published in the form of '[ip:]hostPort:containerPort[/protocol]'
+	Ports []string `json:"ports,omitempty"`
+	// Network is the network mode of the container, defaults to 'host'
+	Network string `json:"network,omitempty"`
+	// WorkDir is the working directory policy. 'mount' (default) will use the current directory if it is mounted,
+	// 'image' will use the working directory of the image and any absolute path will be used as is.
+	WorkDir string `json:"workdir,omitempty"`
+	// User will override the user the tool runs with, defaults to the uid:gid of the calling user
+	User string `json:"user,omitempty"`
+	// Caches are directories in the container that are kept between runs in a volume per tool, e.g. /root/.m2 or $HOME/.cache/go-build
+	Caches []string `json:"caches,omitempty"`
+	// Home will keep the home directory of the tool between runs in a volume per tool and set $HOME accordingly
+	Home bool `json:"home,omitempty"`
+	// Forward are credentials of the user that are forwarded into the tool: 'ssh' (agent and known hosts), 'git' (git config)
+	// and 'docker' (credentials of all registries) or 'docker:<registry>'. The user has to allow it on first use.
+	Forward []string `json:"forward,omitempty"`
+	// Limits restrict the resources of the container and the time the tool can run.
+	// Limits of the alias or the global settings of the user take precedence.
+	Limits *ToolLimits `json:"limits,omitempty"`
+	// Root will run the tool as root for images that do not work with another user.
+	// Otherwise the tool runs with the uid:gid of the calling user, with a passwd entry and a writable home directory.
+	Root bool `json:"root,omitempty"`
+	// Security is the security profile the tool requests: 'strict' (no network, no capabilities, read-only root filesystem),
+	// 'default' or 'privileged' (full access to the host). The user can lower the most permissive profile that is allowed.
+	Security string `json:"security,omitempty"`
+}
+
+// ToolLimits defines the resources a tool can use
+type ToolLimits struct {
+	// CPUs is the number of cpus the tool can use, e.g. 1.5
+	CPUs string `json:"cpus,omitempty"`
+	// Memory is the memory the tool can use, e.g. 512m or 2g. The tool is killed if it needs more
+	Memory string `json:"memory,omitempty"`
+	// Pids is the maximum number of processes the tool can start
+	Pids int64 `json:"pids,omitempty"`
+	// Timeout is the time after which the tool is stopped, e.g. 30m
+	Timeout string `json:"timeout,omitempty"`
+}
+
+// ToolPermissions defines the access to the host a tool needs beyond its mounts.
+// Sledgehammer shows them when the tool is installed and asks the user again if an update of the registry widens them.
+// Elevated runtime settings, i.e. forwarded credentials, the privileged security profile and volumes of host paths, are requested permissions as well.
+type ToolPermissions struct {
+	// Docker will mount the socket of the docker daemon into the tool, which gives it full control over the host
+	Docker bool `json:"docker,omitempty"`
+	// Privileged will run the tool with the privileged security profile, unless the runtime requests another one
+	Privileged bool `json:"privileged,omitempty"`
+	// HostPaths are paths of the host that are mounted into the tool in the form of 'source:target[:ro]', the source must be absolute or start with ~
+	HostPaths []string `json:"hostPaths,omitempty"`
+	// Forward are credentials of the user that are forwarded into the tool, the same as the forward of the runtime
+	Forward []string `json:"forward,omitempty"`
 }
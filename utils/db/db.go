@@ -12,8 +12,11 @@ written permission of Adobe.
 package db
 
 import (
+	"fmt"
 	"os"
 	"path/filepath"
+	"sync"
+	"time"
 
 	"github.com/pkg/errors"
 
@@ -22,21 +25,107 @@ import (
 	"github.com/sirupsen/logrus"
 )
 
+var (
+	// Timeout is the maximum time to wait for the lock of the database that is held by another process
+	Timeout = 10 * time.Second
+	// TryTimeout is the time TryOpen waits for the lock of the database
+	TryTimeout = 100 * time.Millisecond
+)
+
+// LockedError will be returned if the database could not be locked within the timeout
+type LockedError struct {
+	Path    string
+	Timeout time.Duration
+}
+
+func (e *LockedError) Error() string {
+	return fmt.Sprintf("The database %s is locked by another Sledgehammer process, gave up after %s. Check if a slh process is stuck", e.Path, e.Timeout)
+}
+
 // Open will try to open the database in the given path and error out if any problem occurs.
+// The database is locked exclusively until it is closed.
 func Open(configDir string) (*bolt.DB, error) {
+	return open(configDir, false, Timeout)
+}
+
+// TryOpen will open the database in the given path like Open, but will only wait shortly if it is locked by another process.
+// It is used for writes that can be skipped if the database is busy.
+func TryOpen(configDir string) (*bolt.DB, error) {
+	return open(configDir, false, TryTimeout)
+}
+
+// OpenReadOnly will open the database in the given path with a shared lock, so other read-only opens do not have to wait.
+// If the database does not exist yet, it will be created and opened for writing instead.
+func OpenReadOnly(configDir string) (*bolt.DB, error) {
+	return open(configDir, true, Timeout)
+}
+
+func open(configDir string, readOnly bool, timeout time.Duration) (*bolt.DB, error) {
 	path, err := filepath.Abs(filepath.Join(configDir, "data.db"))
 	if err != nil {
 		return nil, err
 	}
+	if _, err := os.Stat(path); os.IsNotExist(err) {
+		readOnly = false
+	}
 	logrus.Debugf("Creating path at %s", configDir)
 	err = os.MkdirAll(configDir, 0766)
 	if err != nil {
 		return nil, err
 	}
-	logrus.Debugf("Opening database at %s", path)
-	db, err := bolt.Open(path, 0600, nil)
+	logrus.WithField("readOnly", readOnly).Debugf("Opening database at %s", path)
+	db, err := bolt.Open(path, 0600, &bolt.Options{
+		Timeout:  timeout,
+		ReadOnly: readOnly,
+	})
+	if err == bolt.ErrTimeout {
+		return nil, &LockedError{Path: path, Timeout: timeout}
+	}
 	if err != nil {
 		return nil, errors.New("Error while opening database: " + err.Error())
 	}
 	return db, err
 }
+
+// Batch collects writes to a read-only database, they are applied together in a single transaction later.
+// It is safe to add writes from multiple goroutines.
+type Batch struct {
+	mutex  sync.Mutex
+	writes []func(*bolt.Tx) error
+}
+
+// Add will defer the given write
+func (b *Batch) Add(write func(*bolt.Tx) error) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	b.writes = append(b.writes, write)
+}
+
+// Len will return the number of deferred writes
+func (b *Batch) Len() int {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	return len(b.writes)
+}
+
+// Apply will run all deferred writes in the order they have been added in a single transaction on the given database.
+// If one of the writes fails, none of them is applied. The batch is empty afterwards.
+func (b *Batch) Apply(database *bolt.DB) error {
+	b.mutex.Lock()
+	writes := b.writes
+	b.writes = nil
+	b.mutex.Unlock()
+
+	if len(writes) == 0 {
+		return nil
+	}
+	logrus.WithField("writes", len(writes)).Debug("Applying deferred writes")
+	return database.Update(func(tx *bolt.Tx) error {
+		for _, write := range writes {
+			if err := write(tx); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
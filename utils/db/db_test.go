@@ -0,0 +1,243 @@
+/*
+Copyright 2018 Adobe
+All Rights Reserved.
+
+NOTICE: Adobe permits you to use, modify, and distribute this file in
+accordance with the terms of the Adobe license agreement accompanying
+it. If you have received this file from a source other than Adobe,
+then your use, modification, or distribution of it requires the prior
+written permission of Adobe.
+*/
+
+package db_test
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/coreos/bbolt"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/adobe/sledgehammer/utils/db"
+)
+
+func TestOpen(t *testing.T) {
+	defer func(timeout time.Duration) { db.Timeout = timeout }(db.Timeout)
+	db.Timeout = 50 * time.Millisecond
+
+	cases := []struct {
+		name string
+		// held is the way the database is already opened, empty if it does not exist yet
+		held     string
+		readOnly bool
+		locked   bool
+		// expectReadOnly is the expected mode of the opened database
+		expectReadOnly bool
+	}{
+		{
+			name:     "Read-only open creates a new database for writing",
+			readOnly: true,
+		},
+		{
+			name:           "Read-only opens do not wait for each other",
+			held:           "read-only",
+			readOnly:       true,
+			expectReadOnly: true,
+		},
+		{
+			name:     "Read-only open waits for a writer",
+			held:     "read-write",
+			readOnly: true,
+			locked:   true,
+		},
+		{
+			name:   "Open waits for a reader",
+			held:   "read-only",
+			locked: true,
+		},
+		{
+			name:   "Open waits for a writer",
+			held:   "read-write",
+			locked: true,
+		},
+	}
+
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := tmpDir(t)
+			defer os.RemoveAll(dir)
+
+			if len(tt.held) > 0 {
+				created, err := db.Open(dir)
+				assert.NoError(t, err)
+				created.Close()
+
+				open := db.Open
+				if tt.held == "read-only" {
+					open = db.OpenReadOnly
+				}
+				held, err := open(dir)
+				assert.NoError(t, err)
+				defer held.Close()
+			}
+
+			open := db.Open
+			if tt.readOnly {
+				open = db.OpenReadOnly
+			}
+			database, err := open(dir)
+			if tt.locked {
+				assert.IsType(t, &db.LockedError{}, err)
+				assert.Contains(t, err.Error(), "locked by another Sledgehammer process")
+				return
+			}
+			assert.NoError(t, err)
+			defer database.Close()
+			assert.Equal(t, tt.expectReadOnly, database.IsReadOnly())
+		})
+	}
+}
+
+func TestTryOpen(t *testing.T) {
+	dir := tmpDir(t)
+	defer os.RemoveAll(dir)
+
+	held, err := db.Open(dir)
+	assert.NoError(t, err)
+
+	start := time.Now()
+	_, err = db.TryOpen(dir)
+	assert.IsType(t, &db.LockedError{}, err)
+	assert.True(t, time.Since(start) < db.Timeout, "TryOpen waited for the full timeout")
+
+	held.Close()
+	database, err := db.TryOpen(dir)
+	assert.NoError(t, err)
+	database.Close()
+}
+
+func TestBatch(t *testing.T) {
+	cases := []struct {
+		name     string
+		fail     bool
+		expected []string
+	}{
+		{
+			name:     "Writes are applied in order",
+			expected: []string{"first", "second"},
+		},
+		{
+			name:     "No write is applied if one fails",
+			fail:     true,
+			expected: []string{"", ""},
+		},
+	}
+
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := tmpDir(t)
+			defer os.RemoveAll(dir)
+			database, err := db.Open(dir)
+			assert.NoError(t, err)
+			defer database.Close()
+
+			batch := &db.Batch{}
+			batch.Add(put("a", "first"))
+			batch.Add(put("b", "second"))
+			writes := 2
+			if tt.fail {
+				batch.Add(func(*bolt.Tx) error { return errors.New("failed") })
+				writes++
+			}
+			assert.Equal(t, writes, batch.Len())
+			assert.Equal(t, "", get(t, database, "a"))
+
+			err = batch.Apply(database)
+			assert.Equal(t, tt.fail, err != nil)
+			assert.Equal(t, 0, batch.Len())
+			assert.Equal(t, tt.expected, []string{get(t, database, "a"), get(t, database, "b")})
+		})
+	}
+}
+
+// BenchmarkParallelRun compares the latency of parallel invocations (e.g. make -j8) that read the database while resolving the tool
+func BenchmarkParallelRun(b *testing.B) {
+	// hold is the time the database is kept open to resolve the tool and select the version
+	hold := time.Millisecond
+	for _, readOnly := range []bool{false, true} {
+		b.Run(fmt.Sprintf("readOnly=%t", readOnly), func(b *testing.B) {
+			dir, err := ioutil.TempDir("", "")
+			if err != nil {
+				b.Fatal(err)
+			}
+			defer os.RemoveAll(dir)
+			database, err := db.Open(dir)
+			if err != nil {
+				b.Fatal(err)
+			}
+			database.Update(put("tool", "terraform"))
+			database.Close()
+
+			open := db.Open
+			if readOnly {
+				open = db.OpenReadOnly
+			}
+			// latency is the sum of the time each invocation took, including waiting for the lock
+			latency := int64(0)
+			b.SetParallelism(8)
+			b.ResetTimer()
+			b.RunParallel(func(pb *testing.PB) {
+				for pb.Next() {
+					start := time.Now()
+					database, err := open(dir)
+					if err != nil {
+						b.Fatal(err)
+					}
+					database.View(func(tx *bolt.Tx) error {
+						tx.Bucket([]byte("test")).Get([]byte("tool"))
+						return nil
+					})
+					time.Sleep(hold)
+					database.Close()
+					atomic.AddInt64(&latency, int64(time.Since(start)))
+				}
+			})
+			b.ReportMetric(float64(latency)/float64(b.N)/float64(time.Millisecond), "ms/run")
+		})
+	}
+}
+
+func put(key string, value string) func(*bolt.Tx) error {
+	return func(tx *bolt.Tx) error {
+		bucket, err := tx.CreateBucketIfNotExists([]byte("test"))
+		if err != nil {
+			return err
+		}
+		return bucket.Put([]byte(key), []byte(value))
+	}
+}
+
+func get(t *testing.T, database *bolt.DB, key string) string {
+	value := ""
+	err := database.View(func(tx *bolt.Tx) error {
+		if bucket := tx.Bucket([]byte("test")); bucket != nil {
+			value = string(bucket.Get([]byte(key)))
+		}
+		return nil
+	})
+	assert.NoError(t, err)
+	return value
+}
+
+func tmpDir(t *testing.T) string {
+	dir, err := ioutil.TempDir("", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return dir
+}
@@ -26,9 +26,21 @@ type Client interface {
 	AttachToContainerNonBlocking(opts docker.AttachToContainerOptions) (docker.CloseWaiter, error)
 	AttachToContainer(opts docker.AttachToContainerOptions) error
 	RemoveContainer(opts docker.RemoveContainerOptions) error
+	DownloadFromContainer(id string, opts docker.DownloadFromContainerOptions) error
 	PullImage(opts docker.PullImageOptions, auth docker.AuthConfiguration) error
+	InspectImage(name string) (*docker.Image, error)
+	RemoveImage(name string) error
 	InspectExec(id string) (*docker.ExecInspect, error)
+	KillContainer(opts docker.KillContainerOptions) error
+	StopContainer(id string, timeout uint) error
+	ResizeContainerTTY(id string, height, width int) error
+	ResizeExecTTY(id string, height, width int) error
 
 	Version() (*docker.Env, error)
 	Info() (*docker.DockerInfo, error)
+	WaitContainer(id string) (int, error)
+	CreateVolume(opts docker.CreateVolumeOptions) (*docker.Volume, error)
+	InspectVolume(name string) (*docker.Volume, error)
+	ListVolumes(opts docker.ListVolumesOptions) ([]docker.Volume, error)
+	RemoveVolume(name string) error
 }
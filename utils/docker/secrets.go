@@ -17,6 +17,7 @@ import (
 	"io/ioutil"
 	"os"
 	"path"
+	"sort"
 
 	cred "github.com/docker/docker-credential-helpers/client"
 	"github.com/docker/docker-credential-helpers/credentials"
@@ -70,6 +71,33 @@ func GetCredentials(server string) (*credentials.Credentials, error) {
 	return nil, errors.New("No credentials found")
 }
 
+// Servers will return all registries the user has stored credentials for, either in the credentials store or in the docker config
+func Servers() ([]string, error) {
+	found := map[string]bool{}
+	store, err := credStore()
+	if err == nil {
+		list, err := cred.List(cred.NewShellProgramFunc("docker-credential-" + store))
+		if err != nil {
+			return nil, err
+		}
+		for server := range list {
+			found[server] = true
+		}
+	}
+	auth, err := client.NewAuthConfigurationsFromDockerCfg()
+	if err == nil {
+		for server := range auth.Configs {
+			found[server] = true
+		}
+	}
+	servers := []string{}
+	for server := range found {
+		servers = append(servers, server)
+	}
+	sort.Strings(servers)
+	return servers, nil
+}
+
 func getSecretFromCredStore(store string, server string) (*credentials.Credentials, error) {
 	credFunc := cred.NewShellProgramFunc("docker-credential-" + store)
 	creds, err := cred.Get(credFunc, server)
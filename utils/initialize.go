@@ -22,18 +22,43 @@ var (
 	InitializeFlag = "initialized"
 )
 
-// ShouldInitialize will return if this is the first run of the tool
+// Initialized will return if the first run of the tool is done, the flag is checked with a shared lock
+func Initialized(cfg *config.Config) (bool, error) {
+	if cfg.Initialized {
+		return true, nil
+	}
+	db, err := cfg.OpenDatabaseReadOnly()
+	if err != nil {
+		return false, err
+	}
+	defer cfg.CloseDatabase()
+
+	initialized := false
+	err = db.View(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket([]byte(InitializeFlag))
+		initialized = bucket != nil && bucket.Get([]byte(InitializeFlag)) != nil
+		return nil
+	})
+	return initialized, err
+}
+
+// ShouldInitialize will return if this is the first run of the tool.
+// The database is only locked exclusively to set the flag on the first run.
 func ShouldInitialize(cfg *config.Config) (bool, error) {
 	logrus.Info("Checking if initialize is needed")
-	if cfg.Initialized {
-		return false, nil
+	initialized, err := Initialized(cfg)
+	if err != nil || initialized {
+		return false, err
 	}
+
 	db, err := cfg.OpenDatabase()
 	if err != nil {
 		return false, err
 	}
 	defer cfg.CloseDatabase()
-
+	if err != nil {
+		return false, err
+	}
 	doInit := false
 	err = db.Update(func(tx *bolt.Tx) error {
 		bucket, err := tx.CreateBucketIfNotExists([]byte(InitializeFlag))
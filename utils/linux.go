@@ -2,6 +2,14 @@
 
 package utils
 
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strings"
+	"syscall"
+)
+
 func DecorateExecutable(name string) string {
 	return name
 }
@@ -9,3 +17,58 @@ func DecorateExecutable(name string) string {
 func ContainerPath(path string) string {
 	return path
 }
+
+// ResizeSignals are the signals that notify about a changed terminal size
+func ResizeSignals() []os.Signal {
+	return []os.Signal{syscall.SIGWINCH}
+}
+
+// ProcessAlive will return true if a process with the given id is running
+func ProcessAlive(pid int) bool {
+	process, err := os.FindProcess(pid)
+	if err != nil {
+		return false
+	}
+	// a process of another user can exist without being allowed to signal it
+	err = process.Signal(syscall.Signal(0))
+	return err == nil || err == syscall.EPERM
+}
+
+// FileGroup will return the id of the group that owns the given file
+func FileGroup(path string) (int, bool) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return 0, false
+	}
+	stat, ok := info.Sys().(*syscall.Stat_t)
+	if !ok {
+		return 0, false
+	}
+	return int(stat.Gid), true
+}
+
+// SignalContainerProcess will send the signal to the process with the given pid of the docker daemon, or to its process group if it leads one.
+// The process is only signaled if it belongs to the given container, pids of a docker daemon on another host or in a VM do not belong to this host.
+func SignalContainerProcess(pid int, container string, sig syscall.Signal) error {
+	cgroup, err := ioutil.ReadFile(fmt.Sprintf("/proc/%d/cgroup", pid))
+	if err != nil || len(container) == 0 || !strings.Contains(string(cgroup), container) {
+		return ErrorForeignProcess
+	}
+	if pgid, err := syscall.Getpgid(pid); err == nil && pgid == pid {
+		return syscall.Kill(-pid, sig)
+	}
+	return syscall.Kill(pid, sig)
+}
+
+// LockFile will open the given file and wait until it holds an exclusive lock on it, the lock is released when the file is closed
+func LockFile(path string) (*os.File, error) {
+	f, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0600)
+	if err != nil {
+		return nil, err
+	}
+	if err := syscall.Flock(int(f.Fd()), syscall.LOCK_EX); err != nil {
+		f.Close()
+		return nil, err
+	}
+	return f, nil
+}
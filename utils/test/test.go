@@ -39,16 +39,10 @@ type Step struct {
 	DoAfter  func(cfg *config.Config)
 }
 
-// NewTestDB returns a TestDB using a temporary path.
+// NewTestDB returns a TestDB in its own temporary directory, like the database in the configuration directory.
 func NewTestDB(t *testing.T) *bolt.DB {
 	// Retrieve a temporary path.
-	f, err := ioutil.TempFile("", "")
-	if err != nil {
-		t.Fatal(err)
-	}
-	path := f.Name()
-	f.Close()
-	os.Remove(path)
+	path := filepath.Join(NewTmpDir(t), "data.db")
 	// Open the database.
 	db, err := bolt.Open(path, 0600, nil)
 	if err != nil {
@@ -58,17 +52,14 @@ func NewTestDB(t *testing.T) *bolt.DB {
 	return db
 }
 
-// Close and delete Bolt database.
+// Close and delete Bolt database together with its directory.
 func Close(db *bolt.DB, t *testing.T) {
 	path := db.Path()
 	err := db.Close()
 	if err != nil {
 		t.Fatal(err)
 	}
-	err = os.Remove(path)
-	if err != nil {
-		t.Fatal(err)
-	}
+	DeleteTmpDir(filepath.Dir(path), t)
 }
 
 // NewTmpDir creates a new temp dir for the tests
@@ -13,14 +13,18 @@ package utils
 
 import (
 	"crypto/rand"
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
 	"strings"
 
 	"github.com/sirupsen/logrus"
+)
 
-	docker "github.com/fsouza/go-dockerclient"
+var (
+	// ErrorForeignProcess will be thrown if a process of a container cannot be signaled from this host, e.g. because the docker daemon runs in a VM
+	ErrorForeignProcess = errors.New("The process of the container does not run on this host")
 )
 
 // GetRegistryAndTool will return the registry and the tool name in separat variables if possible
@@ -70,22 +74,6 @@ func RandomString(length int) string {
 	return fmt.Sprintf("%X", b)
 }
 
-// IsPipe will determine if sledgehammer was called as part of a pipe or standalone
-func IsPipe() bool {
-	fi, err := os.Stdin.Stat()
-	if err != nil {
-		logrus.Warnln(err.Error())
-		return false
-	}
-
-	if (fi.Mode() & os.ModeCharDevice) == 0 {
-		logrus.Debugln("Determined that the input is a pipe")
-		return true
-	}
-	logrus.Debugln("Determined that the input is NOT a pipe")
-	return false
-}
-
 // PrepareEnvironment will prepare the local environment variables for the use in the container
 func PrepareEnvironment(envs []string) []string {
 	noEnv := []string{"PATH", "USER", "_", "TMP", "PWD", "SHELL"}
@@ -101,31 +89,20 @@ OUTER:
 		for _, noEnv := range noEnv {
 			if strings.HasPrefix(env, noEnv) {
 				dockerEnvs = append(dockerEnvs, "SLH_HOST_"+env)
-				logrus.WithField("var", "SLH_HOST_"+env).Debugln("Append variable to env")
+				logrus.WithField("var", "SLH_HOST_"+envName(env)).Debugln("Append variable to env")
 				continue OUTER
 			}
 		}
 		dockerEnvs = append(dockerEnvs, env)
-		logrus.WithField("var", env).Debugln("Append variable to env")
+		logrus.WithField("var", envName(env)).Debugln("Append variable to env")
 	}
 
 	return dockerEnvs
 }
 
-// PrepareMounts will prepare all mounts that should be used in the container
-func PrepareMounts(mos []string) []docker.HostMount {
-	var mounts []docker.HostMount
-
-	for _, m := range mos {
-		mounts = append(mounts, docker.HostMount{
-			Source: m,
-			Target: ContainerPath(filepath.ToSlash(m)),
-			Type:   "bind",
-			// MacOS only
-			// Consistency: "delegated",
-		})
-	}
-	return mounts
+// envName will return the name of the variable without the value, values must not be logged
+func envName(env string) string {
+	return strings.SplitN(env, "=", 2)[0]
 }
 
 func ImportPath(path string) string {
@@ -134,21 +111,3 @@ func ImportPath(path string) string {
 	path, _ = filepath.Abs(path)
 	return path
 }
-
-func WorkingDirectory(mounts []string) (string, error) {
-	workspace, err := os.Getwd()
-	if err != nil {
-		return "", err
-	}
-	found := false
-	for _, m := range mounts {
-		if strings.Contains(workspace, m) {
-			found = true
-		}
-	}
-	// If no mount can be found that contains the current working directory, then pass no working directory
-	if !found {
-		return "", nil
-	}
-	return ContainerPath(filepath.ToSlash(workspace)), nil
-}
@@ -4,10 +4,19 @@ package utils
 
 import (
 	"fmt"
+	"os"
 	"path/filepath"
 	"strings"
+	"syscall"
+	"unsafe"
 )
 
+var (
+	procLockFileEx = syscall.NewLazyDLL("kernel32.dll").NewProc("LockFileEx")
+)
+
+const lockfileExclusiveLock = 0x00000002
+
 func DecorateExecutable(name string) string {
 	if !strings.HasSuffix(name, ".exe") {
 		return fmt.Sprintf("%s.exe", name)
@@ -23,3 +32,43 @@ func ContainerPath(path string) string {
 	}
 	return path
 }
+
+// ResizeSignals are the signals that notify about a changed terminal size, windows has none
+func ResizeSignals() []os.Signal {
+	return nil
+}
+
+// ProcessAlive will return true if a process with the given id is running
+func ProcessAlive(pid int) bool {
+	process, err := os.FindProcess(pid)
+	if err != nil {
+		return false
+	}
+	process.Release()
+	return true
+}
+
+// FileGroup will return the id of the group that owns the given file, files on windows have no group id
+func FileGroup(path string) (int, bool) {
+	return 0, false
+}
+
+// SignalContainerProcess will send the signal to the process of the container, docker daemons on windows always run in a VM
+func SignalContainerProcess(pid int, container string, sig syscall.Signal) error {
+	return ErrorForeignProcess
+}
+
+// LockFile will open the given file and wait until it holds an exclusive lock on it, the lock is released when the file is closed
+func LockFile(path string) (*os.File, error) {
+	f, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0600)
+	if err != nil {
+		return nil, err
+	}
+	ol := &syscall.Overlapped{}
+	r, _, err := procLockFileEx.Call(f.Fd(), lockfileExclusiveLock, 0, 1, 0, uintptr(unsafe.Pointer(ol)))
+	if r == 0 {
+		f.Close()
+		return nil, err
+	}
+	return f, nil
+}